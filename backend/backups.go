@@ -0,0 +1,23 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/backup"
+)
+
+// StartBackups writes a backup to dir every interval in the background until
+// ctx is cancelled, keeping the given number of backups
+func (s *Server) StartBackups(ctx context.Context, dir string, interval time.Duration, keep int) {
+	scheduler := backup.NewScheduler(s.storage, dir, interval, keep)
+	scheduler.OnBackup = func(file string, manifest *backup.Manifest) {
+		log.Printf("Backed up %d notes to %s", manifest.Notes, file)
+	}
+	scheduler.OnError = func(err error) {
+		log.Printf("Backup error: %v", err)
+	}
+
+	go scheduler.Run(ctx)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+	"github.com/midimurphdesigns/go-lang-notes/internal/output"
+)
+
+// BulkRequest applies an operation to the notes matching a query or to the
+// listed notes
+type BulkRequest struct {
+	Query string `json:"query,omitempty"`
+	IDs   []int  `json:"ids,omitempty"`
+	// Operation is archive, unarchive, favorite, unfavorite, delete, tag,
+	// untag or move
+	Operation string `json:"operation"`
+	// Value holds the comma separated tags of tag and untag, or the notebook
+	// of move
+	Value  string `json:"value,omitempty"`
+	DryRun bool   `json:"dry_run,omitempty"`
+}
+
+// BulkResponse reports the outcome of POST /api/notes/bulk. Nothing was
+// changed unless Applied is true.
+type BulkResponse struct {
+	Applied bool                 `json:"applied"`
+	DryRun  bool                 `json:"dry_run"`
+	Error   string               `json:"error,omitempty"`
+	Results []output.BatchResult `json:"results"`
+}
+
+// bulkNotes handles POST /api/notes/bulk. The operation is applied to all
+// notes or none: if it fails for one note the response is 422 and the
+// results tell which notes failed and why.
+func (s *Server) bulkNotes(w http.ResponseWriter, r *http.Request) {
+	var req BulkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if (req.Query == "") == (len(req.IDs) == 0) {
+		s.sendError(w, "Give either query or ids", http.StatusBadRequest)
+		return
+	}
+
+	ids := req.IDs
+	if req.Query != "" {
+		query, err := note.ParseQuery(req.Query)
+		if err != nil {
+			s.sendError(w, "Invalid query: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, n := range query.Filter(s.storage.GetAllNotes()) {
+			ids = append(ids, n.ID)
+		}
+	}
+
+	op := note.BatchOperation{Name: req.Operation, Value: req.Value}
+	var results []note.BatchResult
+	var err error
+	if req.DryRun {
+		results, err = s.storage.PlanBatch(ids, op)
+	} else {
+		results, err = s.storage.ApplyBatch(ids, op)
+	}
+	if results == nil && err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := BulkResponse{
+		Applied: !req.DryRun && err == nil,
+		DryRun:  req.DryRun,
+		Results: output.NewBatchResults(results),
+	}
+	if err != nil {
+		response.Error = err.Error()
+	}
+
+	switch {
+	case errors.Is(err, note.ErrBatchFailed):
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(response)
+	case err != nil:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response)
+	default:
+		s.sendJSON(w, response)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+	"github.com/midimurphdesigns/go-lang-notes/internal/vault"
+)
+
+// LockResponse reports whether encrypted notes can be read
+type LockResponse struct {
+	Enabled bool `json:"enabled"`
+	Locked  bool `json:"locked"`
+	// Hidden counts the encrypted notes hidden while locked
+	Hidden int `json:"hidden"`
+}
+
+type UnlockRequest struct {
+	Passphrase string `json:"passphrase"`
+}
+
+// unlockFromEnv unlocks encrypted notes at startup with GONOTES_PASSPHRASE.
+// Otherwise they stay hidden until POST /api/unlock.
+func (s *Server) unlockFromEnv() {
+	passphrase := os.Getenv("GONOTES_PASSPHRASE")
+	if passphrase == "" || !s.storage.EncryptionEnabled() {
+		return
+	}
+
+	if _, err := s.storage.Unlock(passphrase); err != nil {
+		log.Printf("Failed to unlock encrypted notes: %v", err)
+		return
+	}
+	log.Printf("Encrypted notes unlocked")
+}
+
+func (s *Server) lockStatus() LockResponse {
+	return LockResponse{
+		Enabled: s.storage.EncryptionEnabled(),
+		Locked:  s.storage.IsLocked(),
+		Hidden:  len(s.storage.LockedNotes()),
+	}
+}
+
+func (s *Server) getLock(w http.ResponseWriter, r *http.Request) {
+	s.sendJSON(w, s.lockStatus())
+}
+
+func (s *Server) unlockNotes(w http.ResponseWriter, r *http.Request) {
+	var req UnlockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := s.storage.Unlock(req.Passphrase); err != nil {
+		switch {
+		case errors.Is(err, vault.ErrWrongPassphrase):
+			s.sendError(w, "Wrong passphrase", http.StatusForbidden)
+		case errors.Is(err, note.ErrNoEncryption):
+			s.sendError(w, "No notes are encrypted", http.StatusBadRequest)
+		default:
+			s.sendError(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	s.sendJSON(w, s.lockStatus())
+}
+
+func (s *Server) lockNotes(w http.ResponseWriter, r *http.Request) {
+	if err := s.storage.Lock(); err != nil {
+		s.sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.sendJSON(w, s.lockStatus())
+}
+
+// sendLocked answers 423 Locked for errors about encrypted notes that cannot
+// be read until the notes are unlocked, and reports whether it did
+func (s *Server) sendLocked(w http.ResponseWriter, err error) bool {
+	if !errors.Is(err, note.ErrLocked) {
+		return false
+	}
+	s.sendError(w, "Note is encrypted, unlock the notes first", http.StatusLocked)
+	return true
+}
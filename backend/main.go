@@ -1,9 +1,13 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
 	"os"
+	"time"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/backup"
 
 	"github.com/midimurphdesigns/go-lang-notes/cmd"
 )
@@ -11,28 +15,48 @@ import (
 func main() {
 	// Define command line flags
 	var (
-		webMode  = flag.Bool("web", false, "Run in web server mode")
-		port     = flag.String("port", "8080", "Port for web server (default: 8080)")
-		notesDir = flag.String("notes-dir", "notes", "Directory to store notes")
+		webMode    = flag.Bool("web", false, "Run in web server mode")
+		port       = flag.String("port", "8080", "Port for web server (default: 8080)")
+		notesDir   = flag.String("notes-dir", "notes", "Directory to store notes")
+		remindCmd  = flag.String("reminder-cmd", "", "Command to run when a reminder fires")
+		backupDir  = flag.String("backup-dir", "", "Directory for scheduled backups (disabled if empty)")
+		backupInt  = flag.Duration("backup-interval", backup.DefaultInterval, "Time between scheduled backups")
+		backupKeep = flag.Int("backup-keep", backup.DefaultKeep, "Number of scheduled backups to keep")
 	)
 	flag.Parse()
 
 	if *webMode {
 		// Web server mode
-		runWebServer(*port, *notesDir)
+		runWebServer(*port, *notesDir, *remindCmd, backupOptions{*backupDir, *backupInt, *backupKeep})
 	} else {
 		// CLI mode (default)
 		runCLI(*notesDir)
 	}
 }
 
-func runWebServer(port, notesDir string) {
+// backupOptions configures the scheduled backups of the web server
+type backupOptions struct {
+	dir      string
+	interval time.Duration
+	keep     int
+}
+
+func runWebServer(port, notesDir, remindCmd string, backups backupOptions) {
 	// Create and start the server
 	server, err := NewServer(notesDir)
 	if err != nil {
 		log.Fatalf("Failed to create server: %v", err)
 	}
 
+	// Fire reminders in the background while the server is running
+	server.StartReminders(context.Background(), remindCmd)
+
+	// Write rotating backups in the background if a backup folder is set
+	if backups.dir != "" {
+		server.StartBackups(context.Background(), backups.dir, backups.interval, backups.keep)
+		log.Printf("Backups: every %s to %s, keeping %d", backups.interval, backups.dir, backups.keep)
+	}
+
 	log.Printf("Starting GoNotes web server on port %s", port)
 	log.Printf("Notes directory: %s", notesDir)
 	log.Printf("Web interface: http://localhost:%s", port)
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// MoveRequest places a note right before or after another one
+type MoveRequest struct {
+	Before int `json:"before,omitempty"`
+	After  int `json:"after,omitempty"`
+}
+
+// OrderRequest lists notes in their new order, such as after a drag and drop
+type OrderRequest struct {
+	IDs []int `json:"ids"`
+}
+
+func (s *Server) pinNote(w http.ResponseWriter, r *http.Request) {
+	s.setPinned(w, r, true)
+}
+
+func (s *Server) unpinNote(w http.ResponseWriter, r *http.Request) {
+	s.setPinned(w, r, false)
+}
+
+func (s *Server) setPinned(w http.ResponseWriter, r *http.Request, pinned bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		s.sendError(w, "Invalid note ID", http.StatusBadRequest)
+		return
+	}
+
+	n, err := s.storage.PinNote(id, pinned)
+	if s.sendLocked(w, err) {
+		return
+	}
+	if err != nil {
+		s.sendError(w, "Note not found", http.StatusNotFound)
+		return
+	}
+
+	s.sendJSON(w, newNoteResponse(n))
+}
+
+// moveNote handles POST /api/notes/{id}/move with {"before": 5} or
+// {"after": 5}
+func (s *Server) moveNote(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		s.sendError(w, "Invalid note ID", http.StatusBadRequest)
+		return
+	}
+
+	var req MoveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if (req.Before == 0) == (req.After == 0) {
+		s.sendError(w, "Give either before or after", http.StatusBadRequest)
+		return
+	}
+
+	target := req.Before
+	if req.After != 0 {
+		target = req.After
+	}
+
+	n, err := s.storage.MoveNote(id, target, req.After != 0)
+	if s.sendLocked(w, err) {
+		return
+	}
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.sendJSON(w, newNoteResponse(n))
+}
+
+// reorderNotes handles PUT /api/notes/order with the IDs of the notes in
+// their new order and answers with the active notes in list order
+func (s *Server) reorderNotes(w http.ResponseWriter, r *http.Request) {
+	var req OrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	err := s.storage.ReorderNotes(req.IDs)
+	if s.sendLocked(w, err) {
+		return
+	}
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.getNotes(w, r)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+)
+
+// The /api/peer routes let another gonotes instance sync with this server
+// ("gonotes sync http://host:8080"). Unlike the other note routes they
+// exchange notes with all their fields, including archived notes.
+
+func (s *Server) getPeerNotes(w http.ResponseWriter, r *http.Request) {
+	// Hidden notes would look deleted to the peer
+	if len(s.storage.LockedNotes()) > 0 {
+		s.sendError(w, "Encrypted notes are locked, unlock the server first", http.StatusLocked)
+		return
+	}
+
+	notes := s.storage.GetAllNotes()
+	if notes == nil {
+		notes = []*note.Note{}
+	}
+	s.sendJSON(w, notes)
+}
+
+func (s *Server) putPeerNote(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		s.sendError(w, "Invalid note ID", http.StatusBadRequest)
+		return
+	}
+
+	var n note.Note
+	if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+		s.sendError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	n.ID = id
+
+	stored, err := s.storage.PutNote(&n)
+	if s.sendLocked(w, err) {
+		return
+	}
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.sendJSON(w, stored)
+}
+
+func (s *Server) deletePeerNote(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		s.sendError(w, "Invalid note ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.storage.DeleteNote(id); err != nil {
+		s.sendError(w, "Note not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+)
+
+// PropertyRequest sets a property; the value may be given as text, such as
+// "2024-05-31", or as a JSON number or bool
+type PropertyRequest struct {
+	Value interface{} `json:"value"`
+}
+
+// PropertiesResponse lists the properties in use and the declared types
+type PropertiesResponse struct {
+	Names  []string    `json:"names"`
+	Schema note.Schema `json:"schema"`
+}
+
+func (s *Server) getProperties(w http.ResponseWriter, r *http.Request) {
+	s.sendJSON(w, PropertiesResponse{
+		Names:  s.storage.GetPropertyNames(),
+		Schema: s.storage.Schema(),
+	})
+}
+
+// setProperty handles PUT /api/notes/{id}/properties/{name} with
+// {"value": "draft"}
+func (s *Server) setProperty(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		s.sendError(w, "Invalid note ID", http.StatusBadRequest)
+		return
+	}
+
+	var req PropertyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Value == nil {
+		s.sendError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	n, err := s.storage.SetProperty(id, mux.Vars(r)["name"], note.PropertyText(req.Value))
+	if s.sendLocked(w, err) {
+		return
+	}
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.sendJSON(w, newNoteResponse(n))
+}
+
+func (s *Server) unsetProperty(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		s.sendError(w, "Invalid note ID", http.StatusBadRequest)
+		return
+	}
+
+	n, err := s.storage.UnsetProperty(id, mux.Vars(r)["name"])
+	if s.sendLocked(w, err) {
+		return
+	}
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	s.sendJSON(w, newNoteResponse(n))
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+	"github.com/midimurphdesigns/go-lang-notes/internal/reminder"
+)
+
+// StartReminders runs the reminder scheduler in the background until ctx is
+// cancelled. Fired reminders are logged, pushed to connected web clients as
+// "reminder" events and, if command is set, passed to that local command.
+// The notes are re-read when their files changed, so reminders set with the
+// CLI while the server runs fire too.
+func (s *Server) StartReminders(ctx context.Context, command string) {
+	notifiers := []reminder.Notifier{
+		reminder.NotifierFunc(func(n *note.Note) error {
+			log.Printf("Reminder fired for note %d: %s", n.ID, n.Title)
+			s.events.publish(Event{Type: "reminder", Data: newNoteResponse(n)})
+			return nil
+		}),
+	}
+	if command != "" {
+		notifiers = append(notifiers, reminder.CommandNotifier{Command: command})
+	}
+
+	scheduler := reminder.NewScheduler(s.storage, reminder.DefaultInterval, notifiers...)
+	scheduler.Reload = true
+	scheduler.OnError = func(err error) {
+		log.Printf("Reminder error: %v", err)
+	}
+
+	go scheduler.Run(ctx)
+}
+
+func (s *Server) setReminder(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		s.sendError(w, "Invalid note ID", http.StatusBadRequest)
+		return
+	}
+
+	var req ReminderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	when, err := reminder.ParseWhen(req.When, time.Now())
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var updatedNote *note.Note
+	if req.Due {
+		updatedNote, err = s.storage.SetDueDate(id, &when)
+	} else {
+		updatedNote, err = s.storage.SetReminder(id, when)
+	}
+	if err != nil {
+		s.sendError(w, "Failed to set reminder", http.StatusInternalServerError)
+		return
+	}
+
+	s.sendJSON(w, newNoteResponse(updatedNote))
+}
+
+func (s *Server) clearReminder(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		s.sendError(w, "Invalid note ID", http.StatusBadRequest)
+		return
+	}
+
+	var updatedNote *note.Note
+	if r.URL.Query().Get("due") == "true" {
+		updatedNote, err = s.storage.SetDueDate(id, nil)
+	} else {
+		updatedNote, err = s.storage.ClearReminder(id)
+	}
+	if err != nil {
+		s.sendError(w, "Failed to clear reminder", http.StatusInternalServerError)
+		return
+	}
+
+	s.sendJSON(w, newNoteResponse(updatedNote))
+}
+
+func (s *Server) snoozeReminder(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		s.sendError(w, "Invalid note ID", http.StatusBadRequest)
+		return
+	}
+
+	var req SnoozeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	d := 10 * time.Minute
+	if req.Duration != "" {
+		d, err = reminder.ParseDuration(req.Duration)
+		if err != nil {
+			s.sendError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	updatedNote, err := s.storage.SnoozeReminder(id, d)
+	if err != nil {
+		s.sendError(w, "Failed to snooze reminder", http.StatusInternalServerError)
+		return
+	}
+
+	s.sendJSON(w, newNoteResponse(updatedNote))
+}
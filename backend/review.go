@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/midimurphdesigns/go-lang-notes/internal/flashcard"
+)
+
+type ReviewCardResponse struct {
+	ID        string          `json:"id"`
+	NoteID    int             `json:"note_id"`
+	NoteTitle string          `json:"note_title"`
+	Kind      string          `json:"kind"`
+	Front     string          `json:"front"`
+	Back      string          `json:"back"`
+	State     flashcard.State `json:"state"`
+}
+
+type NextReviewResponse struct {
+	Card *ReviewCardResponse `json:"card"`
+	Due  int                 `json:"due"`
+}
+
+type GradeRequest struct {
+	Grade int `json:"grade"`
+}
+
+func (s *Server) nextReview(w http.ResponseWriter, r *http.Request) {
+	s.deck.Refresh(s.storage.GetActiveNotes())
+
+	due := s.deck.Due(time.Now())
+	response := NextReviewResponse{Due: len(due)}
+
+	if len(due) > 0 {
+		card, state, err := s.deck.Card(due[0].ID)
+		if err != nil {
+			s.sendError(w, "Failed to load card", http.StatusInternalServerError)
+			return
+		}
+		response.Card = s.newReviewCardResponse(card, state)
+	}
+
+	s.sendJSON(w, response)
+}
+
+func (s *Server) gradeReview(w http.ResponseWriter, r *http.Request) {
+	cardID := mux.Vars(r)["card"]
+
+	var req GradeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Grade < flashcard.GradeBlackout || req.Grade > flashcard.GradeEasy {
+		s.sendError(w, "Grade must be between 0 and 5", http.StatusBadRequest)
+		return
+	}
+
+	s.deck.Refresh(s.storage.GetActiveNotes())
+
+	state, err := s.deck.Grade(cardID, req.Grade, time.Now())
+	if errors.Is(err, flashcard.ErrNotFound) {
+		s.sendError(w, "Card not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	card, _, err := s.deck.Card(cardID)
+	if err != nil {
+		s.sendError(w, "Card not found", http.StatusNotFound)
+		return
+	}
+
+	s.sendJSON(w, s.newReviewCardResponse(card, state))
+}
+
+func (s *Server) newReviewCardResponse(card flashcard.Card, state flashcard.State) *ReviewCardResponse {
+	response := &ReviewCardResponse{
+		ID:     card.ID,
+		NoteID: card.NoteID,
+		Kind:   card.Kind,
+		Front:  card.Front,
+		Back:   card.Back,
+		State:  state,
+	}
+
+	if n, err := s.storage.GetNote(card.NoteID); err == nil {
+		response.NoteTitle = n.Title
+	}
+
+	return response
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"os"
 	"strconv"
@@ -10,21 +11,26 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/midimurphdesigns/go-lang-notes/internal/flashcard"
+	"github.com/midimurphdesigns/go-lang-notes/internal/journal"
 	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+	"github.com/midimurphdesigns/go-lang-notes/internal/output"
 )
 
 type Server struct {
 	storage *note.Storage
 	router  *mux.Router
+	events  *eventHub
+	deck    *flashcard.Deck
+	// journal records changes so they can be undone
+	journal *journal.Journal
 }
 
-type NoteResponse struct {
-	ID        int       `json:"id"`
-	Title     string    `json:"title"`
-	Content   string    `json:"content"`
-	Tags      []string  `json:"tags"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+// NoteResponse is shared with the CLI so both print notes the same way
+type NoteResponse = output.NoteResponse
+
+func newNoteResponse(n *note.Note) NoteResponse {
+	return output.NewNoteResponse(n)
 }
 
 type CreateNoteRequest struct {
@@ -39,6 +45,15 @@ type UpdateNoteRequest struct {
 	Tags    []string `json:"tags"`
 }
 
+type ReminderRequest struct {
+	When string `json:"when"`
+	Due  bool   `json:"due"`
+}
+
+type SnoozeRequest struct {
+	Duration string `json:"duration"`
+}
+
 type ErrorResponse struct {
 	Error string `json:"error"`
 }
@@ -49,12 +64,24 @@ func NewServer(notesDir string) (*Server, error) {
 		return nil, fmt.Errorf("failed to initialize storage: %w", err)
 	}
 
+	deck, err := flashcard.OpenDeck(storage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open flashcards: %w", err)
+	}
+
 	router := mux.NewRouter()
 	server := &Server{
 		storage: storage,
 		router:  router,
+		events:  newEventHub(),
+		deck:    deck,
 	}
 
+	server.journal = journal.Open(storage, func(err error) {
+		log.Printf("Journal error: %v", err)
+	})
+	server.enableAutoCommit()
+	server.unlockFromEnv()
 	server.setupRoutes()
 	return server, nil
 }
@@ -80,6 +107,69 @@ func (s *Server) setupRoutes() {
 	api.HandleFunc("/notes/{id:[0-9]+}", s.deleteNote).Methods("DELETE")
 	fmt.Println("✓ Registered /api/notes/{id} routes")
 
+	// Pinning and ordering routes
+	api.HandleFunc("/notes/order", s.reorderNotes).Methods("PUT")
+	api.HandleFunc("/notes/{id:[0-9]+}/pin", s.pinNote).Methods("POST")
+	api.HandleFunc("/notes/{id:[0-9]+}/pin", s.unpinNote).Methods("DELETE")
+	api.HandleFunc("/notes/{id:[0-9]+}/move", s.moveNote).Methods("POST")
+	fmt.Println("✓ Registered pin and order routes")
+
+	// Archive and favorite routes
+	api.HandleFunc("/notes/{id:[0-9]+}/archive", s.archiveNote).Methods("POST")
+	api.HandleFunc("/notes/{id:[0-9]+}/archive", s.unarchiveNote).Methods("DELETE")
+	api.HandleFunc("/notes/{id:[0-9]+}/favorite", s.favoriteNote).Methods("POST")
+	api.HandleFunc("/notes/{id:[0-9]+}/favorite", s.unfavoriteNote).Methods("DELETE")
+	fmt.Println("✓ Registered archive and favorite routes")
+
+	// Bulk operations
+	api.HandleFunc("/notes/bulk", s.bulkNotes).Methods("POST")
+	fmt.Println("✓ Registered /api/notes/bulk route")
+
+	// Undo and redo
+	api.HandleFunc("/undo", s.getUndoLog).Methods("GET")
+	api.HandleFunc("/undo", s.undo).Methods("POST")
+	api.HandleFunc("/redo", s.redo).Methods("POST")
+	fmt.Println("✓ Registered /api/undo and /api/redo routes")
+
+	// Reminders
+	api.HandleFunc("/notes/{id:[0-9]+}/reminder", s.setReminder).Methods("POST")
+	api.HandleFunc("/notes/{id:[0-9]+}/reminder", s.clearReminder).Methods("DELETE")
+	api.HandleFunc("/notes/{id:[0-9]+}/reminder/snooze", s.snoozeReminder).Methods("POST")
+	api.HandleFunc("/events", s.streamEvents).Methods("GET")
+	fmt.Println("✓ Registered reminder and event routes")
+
+	// Flashcard review
+	api.HandleFunc("/review/next", s.nextReview).Methods("GET")
+	api.HandleFunc("/review/{card}/grade", s.gradeReview).Methods("POST")
+	fmt.Println("✓ Registered /api/review routes")
+
+	// Bulk import and export
+	api.HandleFunc("/export", s.exportNotes).Methods("GET")
+	api.HandleFunc("/import", s.importNotes).Methods("POST")
+	fmt.Println("✓ Registered /api/export and /api/import routes")
+
+	// Git sync
+	api.HandleFunc("/sync", s.syncNotes).Methods("POST")
+	fmt.Println("✓ Registered /api/sync route")
+
+	// Peer sync between gonotes instances
+	api.HandleFunc("/peer/notes", s.getPeerNotes).Methods("GET")
+	api.HandleFunc("/peer/notes/{id:[0-9]+}", s.putPeerNote).Methods("PUT")
+	api.HandleFunc("/peer/notes/{id:[0-9]+}", s.deletePeerNote).Methods("DELETE")
+	fmt.Println("✓ Registered /api/peer routes")
+
+	// Encryption routes
+	api.HandleFunc("/lock", s.getLock).Methods("GET")
+	api.HandleFunc("/lock", s.lockNotes).Methods("POST")
+	api.HandleFunc("/unlock", s.unlockNotes).Methods("POST")
+	fmt.Println("✓ Registered /api/lock and /api/unlock routes")
+
+	// Property routes
+	api.HandleFunc("/properties", s.getProperties).Methods("GET")
+	api.HandleFunc("/notes/{id:[0-9]+}/properties/{name}", s.setProperty).Methods("PUT")
+	api.HandleFunc("/notes/{id:[0-9]+}/properties/{name}", s.unsetProperty).Methods("DELETE")
+	fmt.Println("✓ Registered property routes")
+
 	// Search and stats
 	api.HandleFunc("/search", s.searchNotes).Methods("GET")
 	api.HandleFunc("/stats", s.getStats).Methods("GET")
@@ -102,14 +192,7 @@ func (s *Server) getNotes(w http.ResponseWriter, r *http.Request) {
 
 	response := make([]NoteResponse, len(notes))
 	for i, note := range notes {
-		response[i] = NoteResponse{
-			ID:        note.ID,
-			Title:     note.Title,
-			Content:   note.Content,
-			Tags:      note.Tags,
-			CreatedAt: note.CreatedAt,
-			UpdatedAt: note.UpdatedAt,
-		}
+		response[i] = newNoteResponse(note)
 	}
 
 	s.sendJSON(w, response)
@@ -133,14 +216,7 @@ func (s *Server) createNote(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response := NoteResponse{
-		ID:        createdNote.ID,
-		Title:     createdNote.Title,
-		Content:   createdNote.Content,
-		Tags:      createdNote.Tags,
-		CreatedAt: createdNote.CreatedAt,
-		UpdatedAt: createdNote.UpdatedAt,
-	}
+	response := newNoteResponse(createdNote)
 
 	s.sendJSON(w, response)
 }
@@ -161,19 +237,15 @@ func (s *Server) getNote(w http.ResponseWriter, r *http.Request) {
 	}
 
 	note, err := s.storage.GetNote(id)
+	if s.sendLocked(w, err) {
+		return
+	}
 	if err != nil {
 		s.sendError(w, "Note not found", http.StatusNotFound)
 		return
 	}
 
-	response := NoteResponse{
-		ID:        note.ID,
-		Title:     note.Title,
-		Content:   note.Content,
-		Tags:      note.Tags,
-		CreatedAt: note.CreatedAt,
-		UpdatedAt: note.UpdatedAt,
-	}
+	response := newNoteResponse(note)
 
 	s.sendJSON(w, response)
 }
@@ -193,19 +265,15 @@ func (s *Server) updateNote(w http.ResponseWriter, r *http.Request) {
 	}
 
 	updatedNote, err := s.storage.UpdateNote(id, req.Title, req.Content, req.Tags)
+	if s.sendLocked(w, err) {
+		return
+	}
 	if err != nil {
 		s.sendError(w, "Failed to update note", http.StatusInternalServerError)
 		return
 	}
 
-	response := NoteResponse{
-		ID:        updatedNote.ID,
-		Title:     updatedNote.Title,
-		Content:   updatedNote.Content,
-		Tags:      updatedNote.Tags,
-		CreatedAt: updatedNote.CreatedAt,
-		UpdatedAt: updatedNote.UpdatedAt,
-	}
+	response := newNoteResponse(updatedNote)
 
 	s.sendJSON(w, response)
 }
@@ -245,21 +313,30 @@ func (s *Server) searchNotes(w http.ResponseWriter, r *http.Request) {
 		searchType = "all"
 	}
 
-	// Get all notes first
+	// Get all notes first, keeping those matching the prop filters such as
+	// ?prop=status=draft&prop=difficulty>=3
 	allNotes := s.storage.GetActiveNotes()
+	for _, condition := range r.URL.Query()["prop"] {
+		match, err := note.ParsePropertyFilter(condition)
+		if err != nil {
+			s.sendError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var matched []*note.Note
+		for _, n := range allNotes {
+			if match(n) {
+				matched = append(matched, n)
+			}
+		}
+		allNotes = matched
+	}
 
 	// If no query, return all notes
 	if query == "" {
 		response := make([]NoteResponse, len(allNotes))
 		for i, note := range allNotes {
-			response[i] = NoteResponse{
-				ID:        note.ID,
-				Title:     note.Title,
-				Content:   note.Content,
-				Tags:      note.Tags,
-				CreatedAt: note.CreatedAt,
-				UpdatedAt: note.UpdatedAt,
-			}
+			response[i] = newNoteResponse(note)
 		}
 		s.sendJSON(w, response)
 		return
@@ -312,14 +389,7 @@ func (s *Server) searchNotes(w http.ResponseWriter, r *http.Request) {
 	// Convert to response format
 	response := make([]NoteResponse, len(filteredNotes))
 	for i, note := range filteredNotes {
-		response[i] = NoteResponse{
-			ID:        note.ID,
-			Title:     note.Title,
-			Content:   note.Content,
-			Tags:      note.Tags,
-			CreatedAt: note.CreatedAt,
-			UpdatedAt: note.UpdatedAt,
-		}
+		response[i] = newNoteResponse(note)
 	}
 
 	// Add search metadata
@@ -334,8 +404,15 @@ func (s *Server) searchNotes(w http.ResponseWriter, r *http.Request) {
 	s.sendJSON(w, searchResponse)
 }
 
+// getStats handles GET /api/stats?range=30d, with the activity of the range
+// (all time by default)
 func (s *Server) getStats(w http.ResponseWriter, r *http.Request) {
-	stats := s.storage.GetStats()
+	since, err := note.ParseRange(r.URL.Query().Get("range"), time.Now())
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	stats := s.storage.GetStats(since)
 
 	s.sendJSON(w, stats)
 }
@@ -441,7 +518,7 @@ func (s *Server) executeCommand(command string, args []string) (interface{}, err
 		notes := s.storage.SearchNotes(query)
 		return notes, nil
 	case "stats":
-		stats := s.storage.GetStats()
+		stats := s.storage.GetStats(time.Time{})
 		return stats, nil
 	case "tag":
 		if len(args) < 3 {
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+)
+
+func (s *Server) archiveNote(w http.ResponseWriter, r *http.Request) {
+	s.setStatus(w, r, func(id int) (*note.Note, error) {
+		return s.storage.ArchiveNote(id, true)
+	})
+}
+
+func (s *Server) unarchiveNote(w http.ResponseWriter, r *http.Request) {
+	s.setStatus(w, r, func(id int) (*note.Note, error) {
+		return s.storage.ArchiveNote(id, false)
+	})
+}
+
+func (s *Server) favoriteNote(w http.ResponseWriter, r *http.Request) {
+	s.setStatus(w, r, func(id int) (*note.Note, error) {
+		return s.storage.FavoriteNote(id, true)
+	})
+}
+
+func (s *Server) unfavoriteNote(w http.ResponseWriter, r *http.Request) {
+	s.setStatus(w, r, func(id int) (*note.Note, error) {
+		return s.storage.FavoriteNote(id, false)
+	})
+}
+
+// setStatus applies update to the note of the request and sends it back.
+// Updates set a status rather than toggle it, so repeating a request is safe.
+func (s *Server) setStatus(w http.ResponseWriter, r *http.Request, update func(id int) (*note.Note, error)) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		s.sendError(w, "Invalid note ID", http.StatusBadRequest)
+		return
+	}
+
+	n, err := update(id)
+	if s.sendLocked(w, err) {
+		return
+	}
+	if errors.Is(err, note.ErrNotFound) {
+		s.sendError(w, "Note not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.sendJSON(w, newNoteResponse(n))
+}
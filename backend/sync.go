@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/gitsync"
+)
+
+// SyncResponse reports the outcome of POST /api/sync
+type SyncResponse struct {
+	Branch    string             `json:"branch"`
+	Committed bool               `json:"committed"`
+	Pulled    int                `json:"pulled"`
+	Pushed    int                `json:"pushed"`
+	Conflicts []gitsync.Conflict `json:"conflicts,omitempty"`
+}
+
+// enableAutoCommit commits every change made through the server if the
+// notes directory has automatic commits turned on
+func (s *Server) enableAutoCommit() {
+	if !s.storage.Settings().GitAutoCommit {
+		return
+	}
+
+	repo, err := gitsync.Open(s.storage.Dir())
+	if err != nil {
+		log.Printf("Automatic commits are on but %v", err)
+		return
+	}
+
+	gitsync.AutoCommit(s.storage, repo, func(err error) {
+		log.Printf("Git error: %v", err)
+	})
+	log.Printf("Committing every change to git")
+}
+
+// syncNotes synchronizes the notes repository with its remote, e.g.
+// POST /api/sync?remote=origin&prefer=remote. Conflicts that stop the sync
+// are reported with status 409 and leave the notes unchanged.
+func (s *Server) syncNotes(w http.ResponseWriter, r *http.Request) {
+	repo, err := gitsync.Open(s.storage.Dir())
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	result, err := repo.Sync(gitsync.SyncOptions{Remote: query.Get("remote"), Prefer: query.Get("prefer")})
+	var conflictErr *gitsync.ConflictError
+	if errors.As(err, &conflictErr) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		s.sendJSON(w, SyncResponse{Conflicts: conflictErr.Conflicts})
+		return
+	}
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := s.storage.Reload(); err != nil {
+		s.sendError(w, "Failed to reload notes", http.StatusInternalServerError)
+		return
+	}
+
+	s.sendJSON(w, SyncResponse{
+		Branch:    result.Branch,
+		Committed: result.Committed,
+		Pulled:    result.Pulled,
+		Pushed:    result.Pushed,
+		Conflicts: result.Resolved,
+	})
+}
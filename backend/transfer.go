@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/transfer"
+)
+
+// ImportResponse reports the outcome of POST /api/import
+type ImportResponse struct {
+	transfer.Result
+	Errors []string `json:"errors"`
+}
+
+// transferOptions reads the "map" and "delimiter" query parameters. Mappings
+// are given as field=column, repeated or comma separated.
+func transferOptions(query url.Values) (transfer.Options, error) {
+	var pairs []string
+	for _, value := range query["map"] {
+		pairs = append(pairs, strings.Split(value, ",")...)
+	}
+
+	mapping, err := transfer.ParseMapping(pairs)
+	if err != nil {
+		return transfer.Options{}, err
+	}
+
+	opts := transfer.Options{Mapping: mapping}
+	if delimiter := query.Get("delimiter"); delimiter != "" {
+		if utf8.RuneCountInString(delimiter) != 1 {
+			return opts, fmt.Errorf("delimiter must be a single character")
+		}
+		opts.Comma, _ = utf8.DecodeRuneInString(delimiter)
+	}
+	return opts, nil
+}
+
+// exportNotes streams all notes as CSV, JSON or JSON Lines, e.g.
+// GET /api/export?format=csv&fields=id,title,tags&map=title=Name
+func (s *Server) exportNotes(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = transfer.FormatJSON
+	}
+	if err := transfer.CheckFormat(format); err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	opts, err := transferOptions(query)
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if opts.Fields, err = transfer.ParseFields(query.Get("fields")); err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	notes := s.storage.GetAllNotes()
+	sort.Slice(notes, func(i, j int) bool {
+		return notes[i].ID < notes[j].ID
+	})
+
+	contentTypes := map[string]string{
+		transfer.FormatCSV:   "text/csv; charset=utf-8",
+		transfer.FormatJSON:  "application/json",
+		transfer.FormatJSONL: "application/x-ndjson",
+	}
+	w.Header().Set("Content-Type", contentTypes[format])
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="notes.%s"`, format))
+
+	// The response has started, so errors can only be logged
+	if err := transfer.Export(w, notes, format, opts); err != nil {
+		log.Printf("Export failed: %v", err)
+	}
+}
+
+// importNotes reads notes from the request body, e.g.
+// POST /api/import?format=csv&conflict=overwrite&map=title=Name
+func (s *Server) importNotes(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = transfer.FormatJSON
+	}
+	conflict := query.Get("conflict")
+	if conflict == "" {
+		conflict = transfer.ConflictSkip
+	}
+	if err := transfer.CheckConflict(conflict); err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	opts, err := transferOptions(query)
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	decoder, err := transfer.NewDecoder(r.Body, format, opts)
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := transfer.Import(s.storage, decoder, conflict, nil)
+	response := ImportResponse{Result: result, Errors: []string{}}
+	for _, recordErr := range result.Errors {
+		response.Errors = append(response.Errors, recordErr.Error())
+	}
+	if err != nil {
+		response.Errors = append(response.Errors, fmt.Sprintf("import stopped: %v", err))
+	}
+
+	s.sendJSON(w, response)
+}
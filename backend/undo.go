@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/journal"
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+	"github.com/midimurphdesigns/go-lang-notes/internal/output"
+)
+
+// UndoResponse lists the operations undone or redone by POST /api/undo and
+// /api/redo. Error is set when one could not be, after those listed.
+type UndoResponse struct {
+	Operations []output.Operation `json:"operations"`
+	Error      string             `json:"error,omitempty"`
+}
+
+// getUndoLog handles GET /api/undo, listing the operations that can be
+// undone or, if undone, redone, newest first
+func (s *Server) getUndoLog(w http.ResponseWriter, r *http.Request) {
+	entries, err := s.journal.Entries()
+	if err != nil {
+		s.sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	operations := output.NewOperations(entries)
+	for i, j := 0, len(operations)-1; i < j; i, j = i+1, j-1 {
+		operations[i], operations[j] = operations[j], operations[i]
+	}
+	s.sendJSON(w, operations)
+}
+
+// undo handles POST /api/undo?n=2, undoing the last n operations (default 1)
+func (s *Server) undo(w http.ResponseWriter, r *http.Request) {
+	s.replay(w, r, s.journal.Undo)
+}
+
+// redo handles POST /api/redo?n=2, redoing the last n operations undone
+func (s *Server) redo(w http.ResponseWriter, r *http.Request) {
+	s.replay(w, r, s.journal.Redo)
+}
+
+// replay undoes or redoes operations. Operations that conflict with later
+// changes, or that are not there, are reported with status 409.
+func (s *Server) replay(w http.ResponseWriter, r *http.Request, replay func(n int) ([]journal.Entry, error)) {
+	n := 1
+	if value := r.URL.Query().Get("n"); value != "" {
+		var err error
+		n, err = strconv.Atoi(value)
+		if err != nil || n < 1 {
+			s.sendError(w, "Invalid number of operations", http.StatusBadRequest)
+			return
+		}
+	}
+
+	done, err := replay(n)
+	response := UndoResponse{Operations: output.NewOperations(done)}
+	if err == nil {
+		s.sendJSON(w, response)
+		return
+	}
+
+	response.Error = err.Error()
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, note.ErrLocked):
+		status = http.StatusLocked
+	case errors.Is(err, note.ErrConflict), errors.Is(err, journal.ErrNothingToUndo), errors.Is(err, journal.ErrNothingToRedo):
+		status = http.StatusConflict
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
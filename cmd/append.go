@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var appendCmd = &cobra.Command{
+	Use:   "append [id] [text]",
+	Short: "Add text to the end of a note",
+	Long: `Add text to the end of a note. Without a text argument, the text is read from
+standard input or from --file.
+
+Examples:
+  gonotes append 3 "- buy milk"
+  date | gonotes append 3
+  gonotes append 3 --file snippet.go`,
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: completeNoteID,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return addToNote(cmd, args, false)
+	},
+}
+
+var prependCmd = &cobra.Command{
+	Use:   "prepend [id] [text]",
+	Short: "Add text to the start of a note",
+	Long: `Add text to the start of a note. Without a text argument, the text is read
+from standard input or from --file.
+
+Examples:
+  gonotes prepend 3 "UPDATE: this was fixed in Go 1.22"
+  git log -1 --oneline | gonotes prepend 3`,
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: completeNoteID,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return addToNote(cmd, args, true)
+	},
+}
+
+func addToNote(cmd *cobra.Command, args []string, prepend bool) error {
+	id, err := resolveID(args[0])
+	if err != nil {
+		return err
+	}
+
+	var text string
+	if len(args) == 2 {
+		text = args[1]
+	} else {
+		file, _ := cmd.Flags().GetString("file")
+		if text, err = readCapture(file); err != nil {
+			return err
+		}
+	}
+	if strings.TrimSpace(text) == "" {
+		return fmt.Errorf("nothing to add")
+	}
+
+	if prepend {
+		n, err := storage.PrependToNote(id, text)
+		if err != nil {
+			return fmt.Errorf("failed to update note: %w", err)
+		}
+		color.Green("✅ Added text to the start of '%s'", n.Title)
+		return nil
+	}
+
+	n, err := storage.AppendToNote(id, text)
+	if err != nil {
+		return fmt.Errorf("failed to update note: %w", err)
+	}
+	color.Green("✅ Added text to the end of '%s'", n.Title)
+	return nil
+}
+
+func init() {
+	appendCmd.Flags().StringP("file", "f", "", "Read the text from a file (- for standard input)")
+	prependCmd.Flags().StringP("file", "f", "", "Read the text from a file (- for standard input)")
+	rootCmd.AddCommand(appendCmd)
+	rootCmd.AddCommand(prependCmd)
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+	"github.com/midimurphdesigns/go-lang-notes/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive [id...]",
+	Short: "Archive notes",
+	Long: `Archive notes so they are hidden from lists and searches. They can still be
+viewed and are listed with 'gonotes list --all'. Archiving a note that is
+already archived does nothing.
+
+Examples:
+  gonotes archive 3
+  gonotes archive 3 7 12
+  gonotes unarchive 3`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeNoteIDs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return updateNotes(args, note.BatchOperation{Name: note.BatchArchive}, "📦 Archived [%d] %s")
+	},
+}
+
+var unarchiveCmd = &cobra.Command{
+	Use:   "unarchive [id...]",
+	Short: "Unarchive notes",
+	Long: `Move archived notes back to the active notes.
+
+Examples:
+  gonotes unarchive 3
+  gonotes unarchive 3 7 12`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeNoteIDs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return updateNotes(args, note.BatchOperation{Name: note.BatchUnarchive}, "📤 Unarchived [%d] %s")
+	},
+}
+
+// updateNotes applies op to the notes given by args and prints each with
+// message, which takes the ID and title. The notes are changed as a whole:
+// if one is missing or cannot be changed, none is.
+func updateNotes(args []string, op note.BatchOperation, message string) error {
+	ids := make([]int, 0, len(args))
+	seen := make(map[int]bool)
+	for _, arg := range args {
+		id, err := resolveID(arg)
+		if err != nil {
+			return err
+		}
+		if !seen[id] {
+			ids = append(ids, id)
+			seen[id] = true
+		}
+	}
+
+	results, err := storage.ApplyBatch(ids, op)
+	if errors.Is(err, note.ErrBatchFailed) {
+		for _, result := range results {
+			if result.Err != nil {
+				return fmt.Errorf("failed to update note %d, no note was changed: %w", result.ID, result.Err)
+			}
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update notes: %w", err)
+	}
+
+	updated := make([]output.NoteResponse, 0, len(results))
+	for _, result := range results {
+		updated = append(updated, output.NewNoteResponse(result.After))
+		if !structured() {
+			fmt.Printf(message+"\n", result.ID, result.After.Title)
+		}
+	}
+
+	if structured() {
+		return printResult(updated)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(archiveCmd)
+	rootCmd.AddCommand(unarchiveCmd)
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/midimurphdesigns/go-lang-notes/internal/backup"
+	"github.com/spf13/cobra"
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup [file.tar.gz]",
+	Short: "Back up all notes to a single archive",
+	Long: `Write every note, attachment and the settings and review state kept in the
+.gonotes folder to a gzipped tar archive. The archive ends with a manifest
+holding the format version and a SHA-256 checksum of every file, which
+"gonotes restore" checks before restoring anything.
+
+Without a file name the archive is written to the current folder as
+gonotes-YYYYMMDD-HHMMSS.tar.gz.
+
+Examples:
+  gonotes backup
+  gonotes backup ~/Backups/notes.tar.gz`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file := backup.FileName(time.Now())
+		if len(args) > 0 {
+			file = args[0]
+		}
+
+		manifest, err := backup.CreateFile(storage, file)
+		if err != nil {
+			return fmt.Errorf("failed to back up notes: %w", err)
+		}
+
+		var size int64
+		if info, err := os.Stat(file); err == nil {
+			size = info.Size()
+		}
+
+		color.Green("✅ Backed up %d notes to %s", manifest.Notes, file)
+		color.New(color.FgHiBlack).Printf("   %d files, %s\n", len(manifest.Files), formatSize(size))
+		return nil
+	},
+}
+
+// formatSize formats a file size in bytes for display
+func formatSize(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	}
+	return fmt.Sprintf("%d bytes", size)
+}
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+}
@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+	"github.com/midimurphdesigns/go-lang-notes/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var bulkCmd = &cobra.Command{
+	Use:   "bulk --query QUERY operation",
+	Short: "Change all notes matching a query at once",
+	Long: `Apply an operation to every note matching a query, including archived notes
+unless the query says -is:archived. The matching notes are listed first and
+nothing changes until you confirm. The operation is all or nothing: if it
+cannot be applied to one of the notes, no note is changed.
+
+Operations:
+  archive, unarchive       archive notes or bring them back
+  fav, unfav               mark notes as favorites or not
+  delete                   delete notes
+  tag add TAGS             add comma separated tags
+  tag remove TAGS          remove tags
+  move NOTEBOOK            put notes in a notebook ("" to take them out)
+
+Examples:
+  gonotes bulk --query 'tag:old' archive
+  gonotes bulk --query 'is:archived tag:scratch' delete --dry-run
+  gonotes bulk --query 'title:goroutine' tag add go,concurrency
+  gonotes bulk --query 'notebook:inbox' move work/projects --yes`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeBulkArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		queryText, _ := cmd.Flags().GetString("query")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		yes, _ := cmd.Flags().GetBool("yes")
+
+		if strings.TrimSpace(queryText) == "" {
+			return fmt.Errorf("give the notes to change with --query, such as --query 'tag:old'")
+		}
+		query, err := note.ParseQuery(queryText)
+		if err != nil {
+			return fmt.Errorf("invalid query: %w", err)
+		}
+		op, err := parseBulkOperation(args)
+		if err != nil {
+			return err
+		}
+		if structured() && !dryRun && !yes {
+			return fmt.Errorf("use --yes or --dry-run with --output and --format")
+		}
+
+		notes := query.Filter(storage.GetAllNotes())
+		ids := make([]int, len(notes))
+		for i, n := range notes {
+			ids[i] = n.ID
+		}
+
+		results, err := storage.PlanBatch(ids, op)
+		if err != nil && !errors.Is(err, note.ErrBatchFailed) {
+			return err
+		}
+		if structured() && (dryRun || err != nil) {
+			if printErr := printResult(output.NewBatchResults(results)); printErr != nil {
+				return printErr
+			}
+			return err
+		}
+
+		changes := 0
+		for _, result := range results {
+			if result.Changed && result.Err == nil {
+				changes++
+			}
+		}
+
+		if !structured() {
+			printBulkPlan(queryText, results)
+			if len(results) == 0 {
+				return nil
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("nothing was changed, since the operation fails for some notes")
+		}
+		if dryRun {
+			color.Green("✅ Dry run, nothing was changed.")
+			return nil
+		}
+		if changes == 0 {
+			fmt.Println("Nothing to change.")
+			return nil
+		}
+
+		if !yes {
+			ok, err := confirm(fmt.Sprintf("%s? (y/N): ", describeBulkOperation(op, changes)))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				color.Green("✅ Nothing was changed.")
+				return nil
+			}
+		}
+
+		results, err = storage.ApplyBatch(ids, op)
+		if structured() {
+			if printErr := printResult(output.NewBatchResults(results)); printErr != nil {
+				return printErr
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("failed to apply %s: %w", op.Name, err)
+		}
+		if !structured() {
+			color.Green("✅ Changed %s.", countNotes(changes))
+		}
+		return nil
+	},
+}
+
+// parseBulkOperation reads an operation such as "archive", "tag add go" or
+// "move work"
+func parseBulkOperation(args []string) (note.BatchOperation, error) {
+	name, rest := args[0], args[1:]
+	usage := func(form string) (note.BatchOperation, error) {
+		return note.BatchOperation{}, fmt.Errorf("usage: gonotes bulk --query QUERY %s", form)
+	}
+
+	switch name {
+	case "archive", "unarchive", "delete":
+		if len(rest) > 0 {
+			return usage(name)
+		}
+		return note.BatchOperation{Name: name}, nil
+	case "fav", "favorite", "unfav", "unfavorite":
+		if len(rest) > 0 {
+			return usage(name)
+		}
+		if strings.HasPrefix(name, "un") {
+			return note.BatchOperation{Name: note.BatchUnfavorite}, nil
+		}
+		return note.BatchOperation{Name: note.BatchFavorite}, nil
+	case "tag":
+		if len(rest) != 2 || (rest[0] != "add" && rest[0] != "remove") {
+			return usage("tag add|remove TAGS")
+		}
+		if rest[0] == "remove" {
+			return note.BatchOperation{Name: note.BatchUntag, Value: rest[1]}, nil
+		}
+		return note.BatchOperation{Name: note.BatchTag, Value: rest[1]}, nil
+	case "move":
+		if len(rest) != 1 {
+			return usage("move NOTEBOOK")
+		}
+		return note.BatchOperation{Name: note.BatchMove, Value: rest[0]}, nil
+	}
+	return note.BatchOperation{}, fmt.Errorf("unknown operation %q (use archive, unarchive, fav, unfav, delete, tag add, tag remove or move)", name)
+}
+
+// completeBulkArgs completes the operation and its tags or notebook
+func completeBulkArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch {
+	case len(args) == 0:
+		return []string{
+			"archive\tarchive the notes",
+			"unarchive\tbring archived notes back",
+			"fav\tmark the notes as favorites",
+			"unfav\tremove the notes from favorites",
+			"delete\tdelete the notes",
+			"tag\tadd or remove tags",
+			"move\tput the notes in a notebook",
+		}, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+	case args[0] == "tag" && len(args) == 1:
+		return []string{"add", "remove"}, cobra.ShellCompDirectiveNoFileComp
+	case args[0] == "tag" && len(args) == 2:
+		return completeTagList(cmd, args, toComplete)
+	case args[0] == "move" && len(args) == 1:
+		return completeNotebooks(cmd, args, toComplete)
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+// describeBulkOperation describes applying op to count notes, such as
+// "Tag 3 notes with go"
+func describeBulkOperation(op note.BatchOperation, count int) string {
+	notes := countNotes(count)
+	switch op.Name {
+	case note.BatchArchive:
+		return "Archive " + notes
+	case note.BatchUnarchive:
+		return "Unarchive " + notes
+	case note.BatchFavorite:
+		return fmt.Sprintf("Mark %s as favorites", notes)
+	case note.BatchUnfavorite:
+		return fmt.Sprintf("Remove %s from favorites", notes)
+	case note.BatchDelete:
+		return "Delete " + notes
+	case note.BatchTag:
+		return fmt.Sprintf("Tag %s with %s", notes, op.Value)
+	case note.BatchUntag:
+		return fmt.Sprintf("Remove tag %s from %s", op.Value, notes)
+	case note.BatchMove:
+		if op.Value == "" {
+			return fmt.Sprintf("Take %s out of their notebook", notes)
+		}
+		return fmt.Sprintf("Move %s to notebook %s", notes, op.Value)
+	}
+	return fmt.Sprintf("Change %s", notes)
+}
+
+// countNotes returns "1 note" or "n notes"
+func countNotes(count int) string {
+	if count == 1 {
+		return "1 note"
+	}
+	return fmt.Sprintf("%d notes", count)
+}
+
+// printBulkPlan lists the notes of a bulk operation with what happens to
+// each
+func printBulkPlan(query string, results []note.BatchResult) {
+	if len(results) == 0 {
+		fmt.Printf("🔍 No notes found matching '%s'\n", query)
+		return
+	}
+
+	color.Cyan("🔍 Notes matching '%s' (%d found):\n", query, len(results))
+	for _, result := range results {
+		title := ""
+		if result.Before != nil {
+			title = result.Before.Title
+		}
+
+		switch {
+		case result.Err != nil:
+			color.Red("  ❌ [%d] %s: %v", result.ID, title, result.Err)
+		case !result.Changed:
+			color.New(color.FgHiBlack).Printf("  [%d] %s (unchanged)\n", result.ID, title)
+		default:
+			fmt.Printf("  [%d] %s\n", result.ID, title)
+		}
+	}
+	fmt.Println()
+}
+
+func init() {
+	bulkCmd.Flags().StringP("query", "q", "", "Notes to change, such as 'tag:old -is:pinned'")
+	bulkCmd.Flags().Bool("dry-run", false, "Show what would change without changing anything")
+	bulkCmd.Flags().BoolP("yes", "y", false, "Change the notes without asking for confirmation")
+	rootCmd.AddCommand(bulkCmd)
+}
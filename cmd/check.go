@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/midimurphdesigns/go-lang-notes/internal/gocode"
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+	"github.com/spf13/cobra"
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check [id...]",
+	Short: "Check the Go code blocks of notes",
+	Long: `Check the fenced Go code blocks of notes for syntax errors, gofmt formatting
+and type errors such as undefined names or unused variables.
+
+Without IDs every note is checked.
+
+Examples:
+  gonotes check
+  gonotes check 1 4
+  gonotes check --no-types`,
+	ValidArgsFunction: completeNoteIDs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		noTypes, _ := cmd.Flags().GetBool("no-types")
+
+		notes, err := notesByID(args)
+		if err != nil {
+			return err
+		}
+
+		checker := gocode.NewChecker()
+		checker.TypeCheck = !noTypes
+
+		blocks, failing := 0, 0
+		for _, n := range notes {
+			blocks += len(gocode.Extract(n.Content))
+
+			problems := checker.Check(n.Content)
+			if len(problems) == 0 {
+				continue
+			}
+			failing++
+
+			fmt.Printf("[%d] ", n.ID)
+			color.New(color.Bold).Printf("%s\n", n.Title)
+			for _, problem := range problems {
+				color.Red("   block %d, line %d: %s: %s", problem.Block, problem.Line, problem.Kind, problem.Message)
+			}
+			fmt.Println()
+		}
+
+		if failing > 0 {
+			return fmt.Errorf("%d of %d notes have problems in their Go code blocks", failing, len(notes))
+		}
+
+		color.Green("✅ Checked %d Go code blocks in %d notes, no problems found.", blocks, len(notes))
+		return nil
+	},
+}
+
+// notesByID returns the notes with the given IDs, or all notes if none are given
+func notesByID(args []string) ([]*note.Note, error) {
+	if len(args) == 0 {
+		return storage.GetAllNotes(), nil
+	}
+
+	notes := make([]*note.Note, 0, len(args))
+	for _, arg := range args {
+		n, err := resolveNote(arg)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, n)
+	}
+
+	return notes, nil
+}
+
+func init() {
+	checkCmd.Flags().Bool("no-types", false, "Skip type checking (faster)")
+	rootCmd.AddCommand(checkCmd)
+}
@@ -0,0 +1,259 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+	"github.com/midimurphdesigns/go-lang-notes/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var completionCmd = &cobra.Command{
+	Use:   "completion",
+	Short: "Generate or install shell completion scripts",
+	Long: `Print the completion script for bash, zsh, fish or PowerShell, or install it
+for your shell with 'gonotes completion install'. Completion offers note IDs
+with their titles, tags, notebooks, property names and values.
+
+Examples:
+  gonotes completion install           # For the shell in $SHELL
+  gonotes completion install zsh
+  source <(gonotes completion bash)    # For the current shell only`,
+	// Completion scripts do not need the notes
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return nil
+	},
+}
+
+var completionInstallCmd = &cobra.Command{
+	Use:       "install [bash|zsh|fish]",
+	Short:     "Install the completion script for your shell",
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"bash", "zsh", "fish"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		shell := filepath.Base(os.Getenv("SHELL"))
+		if len(args) > 0 {
+			shell = args[0]
+		}
+
+		path, err := completionPath(shell)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+		}
+		file, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", path, err)
+		}
+		err = writeCompletion(file, shell)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+
+		color.Green("✅ Installed %s completion in %s", shell, path)
+		if shell == "zsh" {
+			fmt.Printf("Make sure ~/.zshrc has this line before compinit:\n  fpath=(%s $fpath)\n", filepath.Dir(path))
+		}
+		fmt.Println("Open a new shell to use it.")
+		return nil
+	},
+}
+
+// completionPath returns where a shell loads completion scripts from
+func completionPath(shell string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(home, ".config")
+	}
+
+	name := rootCmd.Name()
+	switch shell {
+	case "bash":
+		return filepath.Join(dataHome, "bash-completion", "completions", name), nil
+	case "zsh":
+		return filepath.Join(home, ".zsh", "completions", "_"+name), nil
+	case "fish":
+		return filepath.Join(configHome, "fish", "completions", name+".fish"), nil
+	case "powershell", "pwsh":
+		return "", fmt.Errorf("add '%s completion powershell | Out-String | Invoke-Expression' to your PowerShell profile instead", name)
+	}
+	return "", fmt.Errorf("unsupported shell %q (use bash, zsh or fish)", shell)
+}
+
+// writeCompletion writes the completion script of a shell
+func writeCompletion(w io.Writer, shell string) error {
+	switch shell {
+	case "bash":
+		return rootCmd.GenBashCompletionV2(w, true)
+	case "zsh":
+		return rootCmd.GenZshCompletion(w)
+	case "fish":
+		return rootCmd.GenFishCompletion(w, true)
+	case "powershell":
+		return rootCmd.GenPowerShellCompletionWithDesc(w)
+	}
+	return fmt.Errorf("unsupported shell %q", shell)
+}
+
+// completionStorage opens the notes for completion, which runs without the
+// PersistentPreRunE of the root command. Without a notes directory there is
+// nothing to complete, and none is created.
+func completionStorage() *note.Storage {
+	if storage != nil {
+		return storage
+	}
+	if _, err := os.Stat(notesDir); err != nil {
+		return nil
+	}
+
+	s, err := note.NewStorage(notesDir)
+	if err != nil {
+		return nil
+	}
+	storage = s
+	unlockFromSession()
+	return storage
+}
+
+// completionNote returns the note an argument refers to, if only one matches
+func completionNote(s *note.Storage, ref string) *note.Note {
+	matches, err := s.FindNotes(ref)
+	if err != nil || len(matches) != 1 {
+		return nil
+	}
+	return matches[0]
+}
+
+// completeNoteIDs completes note IDs, described by their titles, leaving out
+// the IDs already given
+func completeNoteIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	s := completionStorage()
+	if s == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	given := make(map[string]bool)
+	for _, arg := range args {
+		given[arg] = true
+	}
+
+	var completions []string
+	for _, n := range s.GetAllNotes() {
+		id := strconv.Itoa(n.ID)
+		if given[id] || !strings.HasPrefix(id, toComplete) {
+			continue
+		}
+		completions = append(completions, id+"\t"+strings.Join(strings.Fields(n.Title), " "))
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
+// completeNoteID completes the note ID of commands taking it as their first
+// argument
+func completeNoteID(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeNoteIDs(cmd, args, toComplete)
+}
+
+// completeTags completes a tag name, described by its number of notes
+func completeTags(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	s := completionStorage()
+	if s == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return tagCompletions(s, s.GetAllTags(), ""), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeTagList completes the last tag of a comma separated list
+func completeTagList(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	s := completionStorage()
+	if s == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	prefix := ""
+	if i := strings.LastIndex(toComplete, ","); i >= 0 {
+		prefix = toComplete[:i+1]
+	}
+	return tagCompletions(s, s.GetAllTags(), prefix), cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
+func tagCompletions(s *note.Storage, tags []string, prefix string) []string {
+	completions := make([]string, len(tags))
+	for i, tag := range tags {
+		completions[i] = fmt.Sprintf("%s%s\t%s", prefix, tag, countNotes(len(s.GetNotesByTag(tag))))
+	}
+	return completions
+}
+
+// completeNotebooks completes the notebooks in use
+func completeNotebooks(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	s := completionStorage()
+	if s == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	counts := make(map[string]int)
+	for _, n := range s.GetAllNotes() {
+		if n.Notebook != "" {
+			counts[n.Notebook]++
+		}
+	}
+
+	completions := make([]string, 0, len(counts))
+	for notebook, count := range counts {
+		completions = append(completions, notebook+"\t"+countNotes(count))
+	}
+	sort.Strings(completions)
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeOutput completes the formats of --output
+func completeOutput(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return []string{
+		output.FormatText + "\tfor people (default)",
+		output.FormatJSON + "\tindented JSON",
+		output.FormatJSONL + "\tone JSON object per line",
+		output.FormatYAML,
+		output.FormatTable + "\taligned columns",
+		output.FormatCSV,
+	}, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
+func init() {
+	for _, shell := range []string{"bash", "zsh", "fish", "powershell"} {
+		shell := shell
+		completionCmd.AddCommand(&cobra.Command{
+			Use:   shell,
+			Short: fmt.Sprintf("Print the completion script for %s", shell),
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return writeCompletion(os.Stdout, shell)
+			},
+		})
+	}
+	completionCmd.AddCommand(completionInstallCmd)
+	rootCmd.AddCommand(completionCmd)
+}
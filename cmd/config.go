@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config [key] [value]",
+	Short: "Show or change settings of the notes directory",
+	Long: `Show or change settings stored with the notes directory. Unlike the global
+config file, these settings travel with the notes.
+
+Settings:
+  format_go_blocks   gofmt-format Go code blocks when notes are saved (true/false)
+  git_autocommit     commit every change to the notes git repository (true/false)
+  encrypt_all        encrypt every note when it is saved (true/false, see 'gonotes encrypt')
+
+Examples:
+  gonotes config                          # Show all settings
+  gonotes config format_go_blocks         # Show a single setting
+  gonotes config format_go_blocks true    # Change a setting`,
+	Args: cobra.MaximumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		settings := storage.Settings()
+		values := settings.Values()
+
+		switch len(args) {
+		case 0:
+			color.Cyan("⚙️  Settings for %s\n", storage.Dir())
+			for _, key := range settings.Keys() {
+				fmt.Printf("%s = %s\n", key, values[key])
+			}
+		case 1:
+			value, exists := values[args[0]]
+			if !exists {
+				return fmt.Errorf("unknown setting: %s", args[0])
+			}
+			fmt.Println(value)
+		default:
+			if err := settings.Set(args[0], args[1]); err != nil {
+				return err
+			}
+			if err := storage.SaveSettings(settings); err != nil {
+				return fmt.Errorf("failed to save settings: %w", err)
+			}
+			color.Green("✅ %s = %s", args[0], settings.Values()[args[0]])
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+}
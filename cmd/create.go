@@ -2,24 +2,35 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"strings"
 
+	"github.com/fatih/color"
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+	"github.com/midimurphdesigns/go-lang-notes/internal/output"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 var createCmd = &cobra.Command{
 	Use:   "create [title] [content]",
 	Short: "Create a new note",
 	Long: `Create a new note with the specified title and content.
-	
+
+The content can also be piped in or read from a file with --file. Without a
+title, the first "# Heading" of the content becomes the title, or else its
+first line. With --split, the input is split into several notes at every
+top-level heading; with --separator, at lines consisting of the separator.
+
 Examples:
   gonotes create "My First Note" "This is the content of my note"
-  gonotes create "Go Slices" "Slices are dynamic arrays in Go" --tags "go,data-structures,slices"`,
-	Args: cobra.ExactArgs(2),
+  gonotes create "Go Slices" "Slices are dynamic arrays in Go" --tags "go,data-structures,slices"
+  go doc sync.Once | gonotes create "sync.Once" -t go
+  gonotes create --file ideas.md
+  gonotes create --file journal.md --separator=---`,
+	Args: cobra.MaximumNArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		title := args[0]
-		content := args[1]
-
 		// Get tags from flag
 		tagsFlag, _ := cmd.Flags().GetString("tags")
 		var tags []string
@@ -31,26 +42,107 @@ Examples:
 			}
 		}
 
-		// Create the note
-		newNote, err := storage.CreateNote(title, content, tags)
+		if len(args) == 2 {
+			return createNote(args[0], args[1], tags)
+		}
+
+		file, _ := cmd.Flags().GetString("file")
+		input, err := readCapture(file)
 		if err != nil {
-			return fmt.Errorf("failed to create note: %w", err)
+			return err
 		}
 
-		fmt.Printf("✅ Note created successfully!\n")
-		fmt.Printf("ID: %d\n", newNote.ID)
-		fmt.Printf("Title: %s\n", newNote.Title)
-		fmt.Printf("Content: %s\n", newNote.Content)
-		if len(newNote.Tags) > 0 {
-			fmt.Printf("Tags: %s\n", strings.Join(newNote.Tags, ", "))
+		split, _ := cmd.Flags().GetBool("split")
+		separator, _ := cmd.Flags().GetString("separator")
+		if !split && separator == "" {
+			title, content := note.InferTitle(input)
+			if len(args) == 1 {
+				title, content = args[0], input
+			}
+			return createNote(title, content, tags)
 		}
-		fmt.Printf("Created: %s\n", newNote.CreatedAt.Format("2006-01-02 15:04:05"))
 
+		if len(args) == 1 {
+			return fmt.Errorf("titles are taken from each note when splitting, remove %q", args[0])
+		}
+		parts := note.SplitCapture(input, separator)
+		created := make([]output.NoteResponse, 0, len(parts))
+		err = storage.Group(func() error {
+			for _, part := range parts {
+				title, content := note.InferTitle(part)
+				newNote, err := storage.CreateNote(title, content, tags)
+				if err != nil {
+					return fmt.Errorf("failed to create note '%s': %w", title, err)
+				}
+				created = append(created, output.NewNoteResponse(newNote))
+				if !structured() {
+					fmt.Printf("✅ [%d] %s\n", newNote.ID, newNote.Title)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if structured() {
+			return printResult(created)
+		}
+		color.Green("✅ Created %d notes.", len(parts))
 		return nil
 	},
 }
 
+// createNote creates a single note and prints it
+func createNote(title, content string, tags []string) error {
+	// Create the note
+	newNote, err := storage.CreateNote(title, content, tags)
+	if err != nil {
+		return fmt.Errorf("failed to create note: %w", err)
+	}
+	if structured() {
+		return printResult(output.NewNoteResponse(newNote))
+	}
+
+	fmt.Printf("✅ Note created successfully!\n")
+	fmt.Printf("ID: %d\n", newNote.ID)
+	fmt.Printf("Title: %s\n", newNote.Title)
+	fmt.Printf("Content: %s\n", newNote.Content)
+	if len(newNote.Tags) > 0 {
+		fmt.Printf("Tags: %s\n", strings.Join(newNote.Tags, ", "))
+	}
+	fmt.Printf("Created: %s\n", newNote.CreatedAt.Format("2006-01-02 15:04:05"))
+
+	return nil
+}
+
+// readCapture reads the text to capture from a file, "-" or piped standard
+// input
+func readCapture(file string) (string, error) {
+	if file != "" && file != "-" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		return string(data), nil
+	}
+
+	if file == "" && term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("no content given: pass it as argument, pipe it in or use --file")
+	}
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		return "", fmt.Errorf("failed to read standard input: %w", err)
+	}
+	if strings.TrimSpace(string(data)) == "" {
+		return "", fmt.Errorf("no content given on standard input")
+	}
+	return string(data), nil
+}
+
 func init() {
 	createCmd.Flags().StringP("tags", "t", "", "Comma-separated list of tags")
+	createCmd.Flags().StringP("file", "f", "", "Read the content from a file (- for standard input)")
+	createCmd.Flags().Bool("split", false, "Create a note for every top-level heading of the input")
+	createCmd.Flags().String("separator", "", "Create a note for every part between lines consisting of this text, such as --separator=---")
 	rootCmd.AddCommand(createCmd)
 }
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var decryptCmd = &cobra.Command{
+	Use:   "decrypt [id...]",
+	Short: "Store encrypted notes in plain text again",
+	Long: `Decrypt notes so they are stored in plain text again. With --all, every
+encrypted note is decrypted and encrypt_all is turned off.
+
+Examples:
+  gonotes decrypt 3
+  gonotes decrypt --all`,
+	ValidArgsFunction: completeNoteIDs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		all, _ := cmd.Flags().GetBool("all")
+		if len(args) == 0 && !all {
+			return fmt.Errorf("give the IDs of the notes to decrypt, or --all")
+		}
+		if !storage.EncryptionEnabled() {
+			return fmt.Errorf("no notes are encrypted")
+		}
+		if err := requireUnlocked(); err != nil {
+			return err
+		}
+
+		var ids []int
+		for _, arg := range args {
+			id, err := resolveID(arg)
+			if err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+
+		if all {
+			settings := storage.Settings()
+			settings.EncryptAll = false
+			if err := storage.SaveSettings(settings); err != nil {
+				return fmt.Errorf("failed to save settings: %w", err)
+			}
+			for _, n := range storage.GetAllNotes() {
+				if n.Encrypted {
+					ids = append(ids, n.ID)
+				}
+			}
+		}
+
+		for _, id := range ids {
+			n, err := storage.DecryptNote(id)
+			if err != nil {
+				return fmt.Errorf("failed to decrypt note %d: %w", id, err)
+			}
+			fmt.Printf("🔓 [%d] %s\n", n.ID, n.Title)
+		}
+
+		color.Green("✅ Decrypted %d notes.", len(ids))
+		return nil
+	},
+}
+
+func init() {
+	decryptCmd.Flags().Bool("all", false, "Decrypt every note and stop encrypting new ones")
+	rootCmd.AddCommand(decryptCmd)
+}
@@ -1,52 +1,65 @@
 package cmd
 
 import (
-	"bufio"
+	"errors"
 	"fmt"
-	"os"
-	"strconv"
-	"strings"
 
 	"github.com/fatih/color"
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
 	"github.com/spf13/cobra"
 )
 
 var deleteCmd = &cobra.Command{
 	Use:   "delete [id]",
 	Short: "Delete a note",
-	Long: `Delete a note by its ID.
+	Long: `Delete a note by its ID or title.
 	
 Examples:
   gonotes delete 1
+  gonotes delete "Go Slices"
   gonotes delete 5 --force`,
-	Args: cobra.ExactArgs(1),
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeNoteID,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		id, err := strconv.Atoi(args[0])
-		if err != nil {
-			return fmt.Errorf("invalid note ID: %s", args[0])
-		}
+		force, _ := cmd.Flags().GetBool("force")
 
-		// Get the note first to show what will be deleted
-		note, err := storage.GetNote(id)
+		// Without --force the deletion is confirmed below, which also covers
+		// a note found by part of its title
+		var id int
+		var err error
+		if force {
+			id, err = resolveID(args[0])
+		} else {
+			id, _, err = lookupID(args[0])
+		}
 		if err != nil {
-			return fmt.Errorf("failed to get note: %w", err)
+			return err
 		}
 
-		force, _ := cmd.Flags().GetBool("force")
+		// Get the note first to show what will be deleted; an encrypted note
+		// can be deleted while locked, but only its ID is known
+		n, err := storage.GetNote(id)
+		if err != nil && !errors.Is(err, note.ErrLocked) {
+			return err
+		}
+		name := fmt.Sprintf("Note %d", id)
+		if n != nil {
+			name = fmt.Sprintf("Note '%s' (ID: %d)", n.Title, id)
+		}
 
 		if !force {
 			color.Yellow("🗑️  About to delete note:")
-			printNoteSummary(note)
+			if n != nil {
+				printNoteSummary(n)
+			} else {
+				fmt.Printf("🔒 [%d] encrypted note, locked\n", id)
+			}
 
-			fmt.Print("Are you sure? (y/N): ")
-			reader := bufio.NewReader(os.Stdin)
-			response, err := reader.ReadString('\n')
+			ok, err := confirm("Are you sure? (y/N): ")
 			if err != nil {
-				return fmt.Errorf("failed to read input: %w", err)
+				return err
 			}
-
-			response = strings.ToLower(strings.TrimSpace(response))
-			if response != "y" && response != "yes" {
+			if !ok {
 				color.Green("✅ Deletion cancelled.")
 				return nil
 			}
@@ -57,7 +70,7 @@ Examples:
 			return fmt.Errorf("failed to delete note: %w", err)
 		}
 
-		color.Green("✅ Note '%s' (ID: %d) deleted successfully.", note.Title, id)
+		color.Green("✅ %s deleted successfully.", name)
 		return nil
 	},
 }
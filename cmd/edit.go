@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var editCmd = &cobra.Command{
+	Use:   "edit [id]",
+	Short: "Edit a note in your editor",
+	Long: `Open a note in $VISUAL or $EDITOR (vi if neither is set). The title and tags
+are at the top of the file between --- lines, followed by the content:
+
+  ---
+  title: Go Slices
+  tags: [go, slices]
+  ---
+  Slices are dynamic arrays in Go.
+
+The note is saved when the editor exits, if anything changed. If the note was
+changed by someone else in the meantime, nothing is saved and the edited file
+is kept so your changes are not lost, except for encrypted notes: their
+temporary file is always removed so the text never stays on disk unencrypted.
+
+Examples:
+  gonotes edit 1
+  EDITOR="code --wait" gonotes edit 1`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeNoteID,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		original, err := resolveNote(args[0])
+		if err != nil {
+			return err
+		}
+		id := original.ID
+
+		data, err := encodeEditFile(original)
+		if err != nil {
+			return err
+		}
+		// The file is readable by the user only, which matters for the
+		// decrypted text of encrypted notes, and removed when done
+		file, err := os.CreateTemp("", fmt.Sprintf("gonotes-%d-*.md", id))
+		if err != nil {
+			return fmt.Errorf("failed to create temporary file: %w", err)
+		}
+		path := file.Name()
+		kept := false
+		defer func() {
+			if !kept {
+				os.Remove(path)
+			}
+		}()
+		if err := file.Chmod(0600); err != nil && runtime.GOOS != "windows" {
+			file.Close()
+			return fmt.Errorf("failed to protect temporary file: %w", err)
+		}
+		_, err = file.Write(data)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write temporary file: %w", err)
+		}
+
+		if err := runEditor(path); err != nil {
+			return err
+		}
+
+		// From here on the file is kept whenever the note cannot be saved,
+		// unless it is encrypted, so its text never stays on disk unencrypted
+		keep := func(err error) error {
+			if original.Encrypted {
+				color.Yellow("📝 Your changes are discarded, since the note is encrypted")
+				return err
+			}
+			kept = true
+			color.Yellow("📝 Your changes are kept in %s", path)
+			return err
+		}
+
+		edited, err := os.ReadFile(path)
+		if err != nil {
+			return keep(fmt.Errorf("failed to read edited file: %w", err))
+		}
+		if bytes.Equal(edited, data) {
+			fmt.Println("No changes made.")
+			return nil
+		}
+
+		title, tags, content, err := decodeEditFile(edited)
+		if err != nil {
+			return keep(err)
+		}
+		if title == original.Title && content == original.Content && sameTags(tags, original.Tags) {
+			fmt.Println("No changes made.")
+			return nil
+		}
+
+		// Another process may have changed the note while it was open
+		if err := storage.Reload(); err != nil {
+			return keep(fmt.Errorf("failed to reload notes: %w", err))
+		}
+		current, err := storage.GetNote(id)
+		if err != nil {
+			return keep(fmt.Errorf("failed to get note: %w", err))
+		}
+		changed := !current.UpdatedAt.Equal(original.UpdatedAt) || current.Title != original.Title ||
+			current.Content != original.Content || !sameTags(current.Tags, original.Tags)
+		if changed {
+			return keep(fmt.Errorf("note %d was changed elsewhere while you were editing it", id))
+		}
+
+		updated, err := storage.UpdateNote(id, title, content, tags)
+		if err != nil {
+			return keep(fmt.Errorf("failed to update note: %w", err))
+		}
+
+		color.Green("✅ Note '%s' (ID: %d) updated.", updated.Title, updated.ID)
+		return nil
+	},
+}
+
+// sameTags reports whether two lists hold the same tags in the same order
+func sameTags(a, b []string) bool {
+	return strings.Join(a, ",") == strings.Join(b, ",")
+}
+
+// editHeader is the frontmatter of a note opened in the editor
+type editHeader struct {
+	Title string   `yaml:"title"`
+	Tags  []string `yaml:"tags,flow"`
+}
+
+// encodeEditFile writes a note as Markdown with its title and tags as YAML
+// frontmatter
+func encodeEditFile(n *note.Note) ([]byte, error) {
+	header, err := yaml.Marshal(editHeader{Title: n.Title, Tags: n.Tags})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write frontmatter: %w", err)
+	}
+
+	var b bytes.Buffer
+	b.WriteString("---\n")
+	b.Write(header)
+	b.WriteString("---\n")
+	b.WriteString(n.Content)
+	b.WriteString("\n")
+	return b.Bytes(), nil
+}
+
+// decodeEditFile reads back a file written by encodeEditFile
+func decodeEditFile(data []byte) (title string, tags []string, content string, err error) {
+	text := strings.ReplaceAll(strings.TrimPrefix(string(data), "\ufeff"), "\r\n", "\n")
+	if !strings.HasPrefix(text, "---\n") {
+		return "", nil, "", fmt.Errorf("the file must start with the --- line of the title and tags")
+	}
+
+	rest := text[len("---\n"):]
+	end := strings.Index(rest, "\n---\n")
+	if end == -1 {
+		if !strings.HasSuffix(rest, "\n---") {
+			return "", nil, "", fmt.Errorf("missing the --- line closing the title and tags")
+		}
+		end = len(rest) - len("\n---")
+	}
+
+	var header editHeader
+	if err := yaml.Unmarshal([]byte(rest[:end]), &header); err != nil {
+		return "", nil, "", fmt.Errorf("invalid title or tags: %w", err)
+	}
+
+	content = strings.TrimSpace(strings.TrimPrefix(rest[end:], "\n---"))
+	for _, tag := range header.Tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return strings.TrimSpace(header.Title), tags, content, nil
+}
+
+// runEditor opens path in the user's editor and waits for it to exit
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	// The editor may come with arguments, such as "code --wait"
+	fields := strings.Fields(editor)
+	cmd := exec.Command(fields[0], append(fields[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %s failed: %w", fields[0], err)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(editCmd)
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/midimurphdesigns/go-lang-notes/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+var encryptCmd = &cobra.Command{
+	Use:   "encrypt [id...]",
+	Short: "Encrypt notes with a passphrase",
+	Long: `Store notes encrypted with AES-256-GCM under a key derived from a passphrase
+with scrypt. The first time, a new passphrase is asked for; it cannot be
+recovered, so keep it safe.
+
+Encrypted notes are hidden until they are unlocked with 'gonotes unlock' or
+GONOTES_PASSPHRASE, and are never published by 'gonotes export site'. Earlier
+plain text versions remain in git history and older backups.
+
+Examples:
+  gonotes encrypt 3 7
+  gonotes encrypt --all    # Encrypt every note, now and whenever one is saved`,
+	ValidArgsFunction: completeNoteIDs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		all, _ := cmd.Flags().GetBool("all")
+		if len(args) == 0 && !all {
+			return fmt.Errorf("give the IDs of the notes to encrypt, or --all")
+		}
+
+		if storage.EncryptionEnabled() {
+			if err := requireUnlocked(); err != nil {
+				return err
+			}
+		} else if err := setupEncryption(); err != nil {
+			return err
+		}
+
+		var ids []int
+		for _, arg := range args {
+			id, err := resolveID(arg)
+			if err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+
+		if all {
+			settings := storage.Settings()
+			settings.EncryptAll = true
+			if err := storage.SaveSettings(settings); err != nil {
+				return fmt.Errorf("failed to save settings: %w", err)
+			}
+			for _, n := range storage.GetAllNotes() {
+				if !n.Encrypted {
+					ids = append(ids, n.ID)
+				}
+			}
+		}
+
+		for _, id := range ids {
+			n, err := storage.EncryptNote(id)
+			if err != nil {
+				return fmt.Errorf("failed to encrypt note %d: %w", id, err)
+			}
+			fmt.Printf("🔒 [%d] %s\n", n.ID, n.Title)
+		}
+
+		color.Green("✅ Encrypted %d notes.", len(ids))
+		if all {
+			color.Green("✅ New and changed notes will be encrypted too (encrypt_all = true).")
+		}
+		return nil
+	},
+}
+
+// setupEncryption asks for a new passphrase and creates the key of the notes
+// directory, keeping it unlocked for the default session
+func setupEncryption() error {
+	color.Cyan("🔑 Choose a passphrase for encrypted notes. It cannot be recovered if lost.")
+	passphrase, err := readPassphrase("New passphrase: ")
+	if err != nil {
+		return err
+	}
+	confirmation, err := readPassphrase("Repeat passphrase: ")
+	if err != nil {
+		return err
+	}
+	if passphrase != confirmation {
+		return fmt.Errorf("passphrases do not match")
+	}
+
+	if err := storage.SetupEncryption(passphrase); err != nil {
+		return fmt.Errorf("failed to set up encryption: %w", err)
+	}
+
+	key, err := storage.Unlock(passphrase)
+	if err != nil {
+		return err
+	}
+	return vault.SaveSession(storage.Dir(), key, vault.DefaultSessionTimeout)
+}
+
+func init() {
+	encryptCmd.Flags().Bool("all", false, "Encrypt every note and all notes saved from now on")
+	rootCmd.AddCommand(encryptCmd)
+}
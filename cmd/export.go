@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+	"github.com/midimurphdesigns/go-lang-notes/internal/transfer"
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export notes as CSV, JSON or JSON Lines",
+	Long: `Export all notes, including archived ones, as CSV, a JSON array or JSON Lines
+(one object per line). Notes are written to standard output unless --file is
+given.
+
+Columns can be chosen and ordered with --fields and renamed with --map, so the
+output matches what a spreadsheet or script expects. The same options read the
+file back with "gonotes import csv|json|jsonl".
+
+Fields: id, title, content, tags, notebook, created_at, updated_at,
+is_archived, is_favorite, is_pinned, position, remind_at, due_at, links,
+properties
+
+Examples:
+  gonotes export --format csv --file notes.csv
+  gonotes export --format jsonl > notes.jsonl
+  gonotes export --format csv --fields title,tags --map title=Name`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		file, _ := cmd.Flags().GetString("file")
+		fieldList, _ := cmd.Flags().GetString("fields")
+
+		opts, err := transferOptions(cmd)
+		if err != nil {
+			return err
+		}
+		if opts.Fields, err = transfer.ParseFields(fieldList); err != nil {
+			return err
+		}
+
+		notes := storage.GetAllNotes()
+		sort.Slice(notes, func(i, j int) bool {
+			return notes[i].ID < notes[j].ID
+		})
+
+		var w io.Writer = os.Stdout
+		if file != "" && file != "-" {
+			f, err := os.Create(file)
+			if err != nil {
+				return fmt.Errorf("failed to create %s: %w", file, err)
+			}
+			defer f.Close()
+			w = f
+		}
+
+		if err := transfer.Export(w, notes, format, opts); err != nil {
+			return fmt.Errorf("failed to export notes: %w", err)
+		}
+
+		if w != os.Stdout {
+			color.Green("✅ Exported %d notes to %s", len(notes), file)
+		}
+		return nil
+	},
+}
+
+// transferOptions reads the --map and --delimiter flags shared by export and
+// the CSV/JSON importers
+func transferOptions(cmd *cobra.Command) (transfer.Options, error) {
+	pairs, _ := cmd.Flags().GetStringSlice("map")
+	delimiter, _ := cmd.Flags().GetString("delimiter")
+
+	mapping, err := transfer.ParseMapping(pairs)
+	if err != nil {
+		return transfer.Options{}, err
+	}
+
+	opts := transfer.Options{Mapping: mapping}
+	if delimiter != "" {
+		if utf8.RuneCountInString(delimiter) != 1 {
+			return opts, fmt.Errorf("delimiter must be a single character")
+		}
+		opts.Comma, _ = utf8.DecodeRuneInString(delimiter)
+	}
+	return opts, nil
+}
+
+// addTransferFlags registers the flags read by transferOptions
+func addTransferFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSlice("map", nil, "Map a field to a column or key name, as field=column (repeatable)")
+	cmd.Flags().String("delimiter", "", "CSV delimiter (default \",\")")
+}
+
+func init() {
+	exportCmd.Flags().String("format", transfer.FormatJSON, "Output format: csv, json or jsonl")
+	exportCmd.Flags().StringP("file", "f", "", "Write to a file instead of standard output")
+	exportCmd.Flags().String("fields", "", "Comma separated fields to export, in order (default all)")
+	addTransferFlags(exportCmd)
+	rootCmd.AddCommand(exportCmd)
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+	"github.com/midimurphdesigns/go-lang-notes/internal/site"
+	"github.com/spf13/cobra"
+)
+
+var exportSiteCmd = &cobra.Command{
+	Use:   "site [outdir]",
+	Short: "Export notes as a static HTML site",
+	Long: `Render notes as a static HTML site that can be published as is: a page per
+note with its backlinks, a page per tag, a notebook tree and a search box
+backed by a JSON index (search.json, loaded by the browser).
+
+Only notes matching --query are published. Links to notes that are not
+published are rendered as plain text. Files generated by an earlier export
+to the same folder are replaced.
+
+Query terms (all must match, "-" negates):
+  tag:go  notebook:work  is:archived  is:favorite  has:attachments
+  title:word  id:12  word  "a phrase"
+
+The built-in templates can be replaced by putting layout.html, index.html,
+note.html, tag.html, tags.html or notebook.html in a folder given with
+--templates.
+
+Examples:
+  gonotes export site public --query "tag:public -is:archived"
+  gonotes export site ~/www/notes --title "Go Study Notes"`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		queryText, _ := cmd.Flags().GetString("query")
+		title, _ := cmd.Flags().GetString("title")
+		templates, _ := cmd.Flags().GetString("templates")
+
+		query, err := note.ParseQuery(queryText)
+		if err != nil {
+			return fmt.Errorf("invalid query: %w", err)
+		}
+
+		result, err := site.Build(storage, args[0], site.Options{
+			Query:     query,
+			Title:     title,
+			Templates: templates,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to export site: %w", err)
+		}
+
+		color.Green("✅ Exported %d notes to %s", result.Notes, args[0])
+		color.New(color.FgHiBlack).Printf("   %d tags, %d notebooks, %d attachments\n", result.Tags, result.Notebooks, result.Attachments)
+		return nil
+	},
+}
+
+func init() {
+	exportSiteCmd.Flags().StringP("query", "q", "-is:archived", "Only publish notes matching this query")
+	exportSiteCmd.Flags().String("title", "Notes", "Site title")
+	exportSiteCmd.Flags().String("templates", "", "Folder with templates replacing the built-in ones")
+	exportSiteCmd.MarkFlagDirname("templates")
+	exportCmd.AddCommand(exportSiteCmd)
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+	"github.com/spf13/cobra"
+)
+
+var favCmd = &cobra.Command{
+	Use:     "fav [id...]",
+	Aliases: []string{"favorite"},
+	Short:   "Mark notes as favorites",
+	Long: `Mark notes as favorites, listed with 'gonotes list --favorites'. Marking a
+note that already is a favorite does nothing.
+
+Examples:
+  gonotes fav 3
+  gonotes fav 3 7 12
+  gonotes unfav 3`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeNoteIDs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return updateNotes(args, note.BatchOperation{Name: note.BatchFavorite}, "⭐ Added [%d] %s to favorites")
+	},
+}
+
+var unfavCmd = &cobra.Command{
+	Use:     "unfav [id...]",
+	Aliases: []string{"unfavorite"},
+	Short:   "Remove notes from favorites",
+	Long: `Remove notes from the favorites.
+
+Examples:
+  gonotes unfav 3
+  gonotes unfav 3 7 12`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeNoteIDs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return updateNotes(args, note.BatchOperation{Name: note.BatchUnfavorite}, "✅ Removed [%d] %s from favorites")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(favCmd)
+	rootCmd.AddCommand(unfavCmd)
+}
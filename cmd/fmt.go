@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/midimurphdesigns/go-lang-notes/internal/gocode"
+	"github.com/spf13/cobra"
+)
+
+var fmtCmd = &cobra.Command{
+	Use:   "fmt [id...]",
+	Short: "Format the Go code blocks of notes",
+	Long: `Reformat the fenced Go code blocks of notes with gofmt. Blocks with syntax
+errors are reported and left untouched.
+
+Without IDs every note is formatted. With --check nothing is changed; notes
+whose Go blocks are not gofmt-clean are listed instead.
+
+Examples:
+  gonotes fmt
+  gonotes fmt 1 3
+  gonotes fmt --check`,
+	ValidArgsFunction: completeNoteIDs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		check, _ := cmd.Flags().GetBool("check")
+
+		notes, err := notesByID(args)
+		if err != nil {
+			return err
+		}
+
+		changed := 0
+		for _, n := range notes {
+			formatted, problems := gocode.FormatContent(n.Content)
+			for _, problem := range problems {
+				color.Yellow("⚠️  [%d] %s: block %d, line %d: %s", n.ID, n.Title, problem.Block, problem.Line, problem.Message)
+			}
+
+			if formatted == n.Content {
+				continue
+			}
+			changed++
+
+			if check {
+				fmt.Printf("[%d] %s\n", n.ID, n.Title)
+				continue
+			}
+
+			if _, err := storage.UpdateNote(n.ID, n.Title, formatted, n.Tags); err != nil {
+				return fmt.Errorf("failed to save note %d: %w", n.ID, err)
+			}
+			color.Green("✅ Formatted [%d] %s", n.ID, n.Title)
+		}
+
+		if check && changed > 0 {
+			return fmt.Errorf("%d notes have Go code blocks that are not gofmt-formatted", changed)
+		}
+		if changed == 0 {
+			color.Green("✅ All Go code blocks are gofmt-formatted.")
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	fmtCmd.Flags().BoolP("check", "c", false, "List notes that need formatting without changing them")
+	rootCmd.AddCommand(fmtCmd)
+}
@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/midimurphdesigns/go-lang-notes/internal/godoc"
+	"github.com/midimurphdesigns/go-lang-notes/internal/importer"
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import notes from other sources",
+	Long: `Import notes from other sources such as Go documentation, Markdown folders,
+Evernote, Joplin, or CSV and JSON files.
+
+Examples:
+  gonotes import godoc strings.Builder
+  gonotes import markdown ~/vault --dry-run
+  gonotes import enex ~/Downloads/Notebook.enex
+  gonotes import joplin ~/Downloads/export.jex
+  gonotes import csv notes.csv --conflict renumber`,
+}
+
+var importGodocCmd = &cobra.Command{
+	Use:   "godoc [package][.Symbol]",
+	Short: "Create a note from Go documentation",
+	Long: `Create a note from the documentation of a Go package or symbol, including its
+signature, doc comment and examples. Documentation is read from GOROOT, the
+current module or the local module cache; the network is never used.
+
+The note is tagged with "godoc" and the package path, and existing notes that
+mention the symbol are linked to it. Importing the same symbol again refreshes
+the existing note.
+
+Examples:
+  gonotes import godoc sync
+  gonotes import godoc sync.Once
+  gonotes import godoc strings.Builder.WriteString
+  gonotes import godoc github.com/spf13/cobra.Command`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		noLink, _ := cmd.Flags().GetBool("no-link")
+
+		d, err := godoc.Load(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to load documentation: %w", err)
+		}
+
+		// The note and the links to it are one change, undone together
+		return storage.Group(func() error {
+			return importGodoc(d, !noLink)
+		})
+	},
+}
+
+// importGodoc creates or refreshes the note of d and, with link, links to it
+// from the notes mentioning it
+func importGodoc(d *godoc.Doc, link bool) error {
+	title := d.Title()
+	tags := []string{"godoc", strings.ToLower(d.ImportPath)}
+
+	var (
+		saved *note.Note
+		err   error
+	)
+	if existing := findGodocNote(title); existing != nil {
+		saved, err = storage.UpdateNote(existing.ID, title, d.Markdown(), existing.Tags)
+		if err != nil {
+			return fmt.Errorf("failed to update note: %w", err)
+		}
+		color.Green("✅ Refreshed note '%s' (ID: %d)", saved.Title, saved.ID)
+	} else {
+		saved, err = storage.CreateNote(title, d.Markdown(), tags)
+		if err != nil {
+			return fmt.Errorf("failed to create note: %w", err)
+		}
+		color.Green("✅ Imported '%s' (ID: %d)", saved.Title, saved.ID)
+	}
+
+	if !link {
+		return nil
+	}
+
+	mention := d.MentionPattern()
+	for _, n := range storage.GetAllNotes() {
+		if n.ID == saved.ID || n.HasLink(saved.ID) || !mention.MatchString(n.Content) {
+			continue
+		}
+
+		if _, err := storage.AddLink(n.ID, saved.ID); err != nil {
+			return fmt.Errorf("failed to link note %d: %w", n.ID, err)
+		}
+		color.New(color.FgHiBlack).Printf("   Linked from [%d] %s\n", n.ID, n.Title)
+	}
+
+	return nil
+}
+
+// findGodocNote returns the note previously imported with the given title
+func findGodocNote(title string) *note.Note {
+	for _, n := range storage.GetNotesByTag("godoc") {
+		if n.Title == title {
+			return n
+		}
+	}
+	return nil
+}
+
+// runImport reviews a batch read by an importer and writes it to storage.
+// Duplicates are skipped unless the "allow-duplicates" flag is set, and
+// nothing is written with "dry-run" or when the user declines.
+func runImport(cmd *cobra.Command, batch *importer.Batch) error {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	yes, _ := cmd.Flags().GetBool("yes")
+	allowDuplicates, _ := cmd.Flags().GetBool("allow-duplicates")
+
+	existing := storage.GetAllNotes()
+	duplicates := batch.Duplicates(existing)
+	if !allowDuplicates {
+		batch = batch.Without(duplicates)
+	}
+	summary := batch.Summarize(existing)
+
+	color.Cyan("📥 Import summary")
+	color.Cyan("=" + strings.Repeat("=", 30))
+	color.White("Notes to import: %d", summary.Notes)
+	if len(summary.Notebooks) > 0 {
+		color.White("Notebooks: %s", strings.Join(summary.Notebooks, ", "))
+	}
+	if len(summary.Tags) > 0 {
+		color.White("Tags: %s", strings.Join(summary.Tags, ", "))
+	}
+
+	if len(duplicates) > 0 {
+		action := "skipped"
+		if allowDuplicates {
+			action = "imported anyway"
+		}
+		color.Yellow("\nDuplicates (%d, %s):", len(duplicates), action)
+		for _, d := range duplicates {
+			if d.Existing != nil {
+				color.Yellow("  %s → existing note [%d] %s", d.Item.Source, d.Existing.ID, d.Existing.Title)
+			} else {
+				color.Yellow("  %s → same title as %s", d.Item.Source, d.Other.Source)
+			}
+		}
+	}
+
+	if len(summary.UnresolvedLinks) > 0 {
+		color.Yellow("\nUnresolved links (%d): %s", len(summary.UnresolvedLinks), strings.Join(summary.UnresolvedLinks, ", "))
+	}
+
+	printImportErrors(batch.Errors)
+	fmt.Println()
+
+	if dryRun || summary.Notes == 0 {
+		if dryRun {
+			color.Green("✅ Dry run, nothing was written.")
+		}
+		return nil
+	}
+
+	if !yes {
+		ok, err := confirm(fmt.Sprintf("Import %d notes? (y/N): ", summary.Notes))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			color.Green("✅ Import cancelled.")
+			return nil
+		}
+	}
+
+	result := importer.Write(storage, batch, func(done, total int) {
+		fmt.Printf("\rImporting %d/%d", done, total)
+	})
+	fmt.Println()
+
+	// Errors from reading were already reported above
+	printImportErrors(result.Errors[len(batch.Errors):])
+	color.Green("✅ Imported %d notes.", len(result.Imported))
+	return nil
+}
+
+// printReadProgress reports the progress of reading an export
+func printReadProgress(done, total int) {
+	if total > 0 {
+		fmt.Printf("\rReading %d/%d", done, total)
+	} else {
+		fmt.Printf("\rReading %d", done)
+	}
+}
+
+// printImportErrors reports items that could not be imported
+func printImportErrors(errs []importer.ItemError) {
+	if len(errs) == 0 {
+		return
+	}
+
+	color.Red("\nErrors (%d):", len(errs))
+	for _, err := range errs {
+		color.Red("  %v", err)
+	}
+}
+
+// confirm asks a yes/no question on the terminal
+func confirm(prompt string) (bool, error) {
+	fmt.Print(prompt)
+	response, err := stdin.ReadString('\n')
+	if err != nil {
+		return false, fmt.Errorf("failed to read input: %w", err)
+	}
+
+	response = strings.ToLower(strings.TrimSpace(response))
+	return response == "y" || response == "yes", nil
+}
+
+// addImportFlags registers the flags shared by the file importers
+func addImportFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("dry-run", false, "Show what would be imported without writing anything")
+	cmd.Flags().BoolP("yes", "y", false, "Import without asking for confirmation")
+	cmd.Flags().Bool("allow-duplicates", false, "Import notes whose title matches an existing note")
+}
+
+func init() {
+	importGodocCmd.Flags().Bool("no-link", false, "Do not link notes that mention the symbol")
+	importCmd.AddCommand(importGodocCmd)
+	rootCmd.AddCommand(importCmd)
+}
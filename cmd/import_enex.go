@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/importer"
+	"github.com/spf13/cobra"
+)
+
+var importENEXCmd = &cobra.Command{
+	Use:   "enex [file]",
+	Short: "Import an Evernote export (.enex)",
+	Long: `Import the notes of an Evernote export file (.enex).
+
+- note content is converted from Evernote's HTML to Markdown, including
+  checklists, tables and code blocks
+- images and other embedded files are stored as attachments
+- tags and created/updated dates are kept
+- links to other notes of the export become note links
+
+Notes that cannot be converted are reported at the end and do not stop the
+import. A summary including duplicates is shown before anything is written.
+
+Examples:
+  gonotes import enex ~/Downloads/Notebook.enex --dry-run
+  gonotes import enex ~/Downloads/Notebook.enex --yes`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to open export: %w", err)
+		}
+		defer f.Close()
+
+		batch, err := importer.ReadENEX(f, printReadProgress)
+		fmt.Println()
+		if err != nil {
+			return fmt.Errorf("failed to read export: %w", err)
+		}
+
+		return runImport(cmd, batch)
+	},
+}
+
+func init() {
+	addImportFlags(importENEXCmd)
+	importCmd.AddCommand(importENEXCmd)
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/importer"
+	"github.com/spf13/cobra"
+)
+
+var importJoplinCmd = &cobra.Command{
+	Use:   "joplin [path]",
+	Short: "Import a Joplin export (JEX archive or RAW directory)",
+	Long: `Import the notes of a Joplin export, either a JEX archive or a RAW export
+directory.
+
+- folders become notebooks, keeping nested folders as "Parent/Child"
+- tags and created/updated dates are kept, and open to-dos keep their due date
+- resources such as images become attachments
+- links to other notes of the export become note links
+
+Encrypted items and notes that cannot be converted are reported at the end
+and do not stop the import. A summary including duplicates is shown before
+anything is written.
+
+Examples:
+  gonotes import joplin ~/Downloads/export.jex --dry-run
+  gonotes import joplin ~/Downloads/joplin-raw --yes`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		batch, err := importer.ReadJoplin(args[0], printReadProgress)
+		fmt.Println()
+		if err != nil {
+			return fmt.Errorf("failed to read export: %w", err)
+		}
+
+		return runImport(cmd, batch)
+	},
+}
+
+func init() {
+	addImportFlags(importJoplinCmd)
+	importCmd.AddCommand(importJoplinCmd)
+}
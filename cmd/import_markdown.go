@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/importer"
+	"github.com/spf13/cobra"
+)
+
+var importMarkdownCmd = &cobra.Command{
+	Use:   "markdown [dir]",
+	Short: "Import a folder of Markdown files or an Obsidian vault",
+	Long: `Import every Markdown file below a folder, such as a plain notes folder or an
+Obsidian vault.
+
+- YAML frontmatter provides the title, tags, aliases and created/updated dates
+- #hashtags in the text are added to the tags
+- [[wikilinks]] to other imported or existing notes become note links
+- file modification times are kept when the frontmatter has no dates
+- folders become notebooks (--folders notebook), tags (--folders tag) or
+  are ignored (--folders none)
+
+A summary including duplicates is shown before anything is written.
+
+Examples:
+  gonotes import markdown ~/notes --dry-run
+  gonotes import markdown ~/vault --folders tag --yes`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		folders, _ := cmd.Flags().GetString("folders")
+
+		batch, err := importer.ReadMarkdown(args[0], importer.MarkdownOptions{Folders: folders})
+		if err != nil {
+			return fmt.Errorf("failed to read Markdown files: %w", err)
+		}
+
+		return runImport(cmd, batch)
+	},
+}
+
+func init() {
+	importMarkdownCmd.Flags().String("folders", importer.FoldersNotebook, "Map folders to notebook, tag or none")
+	addImportFlags(importMarkdownCmd)
+	importCmd.AddCommand(importMarkdownCmd)
+}
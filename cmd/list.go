@@ -6,6 +6,7 @@ import (
 
 	"github.com/fatih/color"
 	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+	"github.com/midimurphdesigns/go-lang-notes/internal/output"
 	"github.com/spf13/cobra"
 )
 
@@ -18,7 +19,10 @@ Examples:
   gonotes list                    # List all active notes
   gonotes list --all             # List all notes including archived
   gonotes list --favorites       # List only favorite notes
-  gonotes list --tag "go"        # List notes with specific tag`,
+  gonotes list --tag "go"        # List notes with specific tag
+
+Pinned notes are listed first, then notes in the order set with
+'gonotes move', then the newest notes.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		all, _ := cmd.Flags().GetBool("all")
 		favorites, _ := cmd.Flags().GetBool("favorites")
@@ -37,6 +41,12 @@ Examples:
 			notes = storage.GetActiveNotes()
 		}
 
+		if structured() {
+			return printResult(output.NewNoteResponses(notes))
+		}
+
+		defer printLockedHint()
+
 		if len(notes) == 0 {
 			fmt.Println("📝 No notes found.")
 			return nil
@@ -56,12 +66,18 @@ Examples:
 func printNoteSummary(note *note.Note) {
 	// Status indicators
 	status := ""
+	if note.IsPinned {
+		status += "📌 "
+	}
 	if note.IsArchived {
 		status += "📦 "
 	}
 	if note.IsFavorite {
 		status += "⭐ "
 	}
+	if note.Encrypted {
+		status += "🔒 "
+	}
 
 	// Title with color
 	titleColor := color.New(color.Bold)
@@ -98,5 +114,6 @@ func init() {
 	listCmd.Flags().BoolP("all", "a", false, "Show all notes including archived")
 	listCmd.Flags().BoolP("favorites", "f", false, "Show only favorite notes")
 	listCmd.Flags().StringP("tag", "t", "", "Show notes with specific tag")
+	listCmd.RegisterFlagCompletionFunc("tag", completeTags)
 	rootCmd.AddCommand(listCmd)
 }
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"github.com/fatih/color"
+	"github.com/midimurphdesigns/go-lang-notes/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+var lockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Lock encrypted notes again",
+	Long: `Forget the key kept by 'gonotes unlock', so encrypted notes are hidden until
+they are unlocked again.
+
+Examples:
+  gonotes lock`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := vault.ClearSession(storage.Dir()); err != nil {
+			return err
+		}
+
+		color.Green("🔒 Notes locked.")
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lockCmd)
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var moveCmd = &cobra.Command{
+	Use:   "move [id]",
+	Short: "Change the place of a note in lists",
+	Long: `Move a note right before or after another note in the list order. Pinned
+notes are ordered among themselves, as are the other notes.
+
+Examples:
+  gonotes move 7 --before 2
+  gonotes move 7 --after 5`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeNoteID,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := resolveID(args[0])
+		if err != nil {
+			return err
+		}
+
+		before, _ := cmd.Flags().GetInt("before")
+		after, _ := cmd.Flags().GetInt("after")
+		if (before == 0) == (after == 0) {
+			return fmt.Errorf("give either --before or --after")
+		}
+
+		target := before
+		if after != 0 {
+			target = after
+		}
+
+		n, err := storage.MoveNote(id, target, after != 0)
+		if err != nil {
+			return fmt.Errorf("failed to move note: %w", err)
+		}
+
+		color.Green("✅ Moved note '%s' (ID: %d).", n.Title, n.ID)
+		return nil
+	},
+}
+
+func init() {
+	moveCmd.Flags().Int("before", 0, "ID of the note to move this note before")
+	moveCmd.Flags().Int("after", 0, "ID of the note to move this note after")
+	moveCmd.RegisterFlagCompletionFunc("before", completeNoteIDs)
+	moveCmd.RegisterFlagCompletionFunc("after", completeNoteIDs)
+	rootCmd.AddCommand(moveCmd)
+}
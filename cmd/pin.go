@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var pinCmd = &cobra.Command{
+	Use:   "pin [id]",
+	Short: "Pin a note to the top of lists",
+	Long: `Pin a note so it is listed before all other notes.
+
+Examples:
+  gonotes pin 3
+  gonotes unpin 3`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeNoteID,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setPinned(args[0], true)
+	},
+}
+
+var unpinCmd = &cobra.Command{
+	Use:   "unpin [id]",
+	Short: "Unpin a note",
+	Long: `Unpin a note so it is listed with the other notes again.
+
+Examples:
+  gonotes unpin 3`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeNoteID,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setPinned(args[0], false)
+	},
+}
+
+func setPinned(arg string, pinned bool) error {
+	id, err := resolveID(arg)
+	if err != nil {
+		return err
+	}
+
+	n, err := storage.PinNote(id, pinned)
+	if err != nil {
+		return fmt.Errorf("failed to update note: %w", err)
+	}
+
+	if pinned {
+		color.Green("📌 Pinned note '%s' (ID: %d).", n.Title, n.ID)
+	} else {
+		color.Green("✅ Unpinned note '%s' (ID: %d).", n.Title, n.ID)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(pinCmd)
+	rootCmd.AddCommand(unpinCmd)
+}
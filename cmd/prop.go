@@ -0,0 +1,266 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+	"github.com/spf13/cobra"
+)
+
+var propCmd = &cobra.Command{
+	Use:   "prop",
+	Short: "Manage custom properties of notes",
+	Long: `Set, remove and list custom properties of notes, such as a status, a
+difficulty or a source URL.
+
+Properties may hold text, numbers, dates or true/false. Declare a property with
+'gonotes prop define' to make sure every note uses the same type, or a value
+from a fixed list; undeclared properties take whatever type the value looks
+like. Find notes by property with 'gonotes search --prop', or with the prop:
+filter of queries such as 'gonotes export site --query "prop:status=done"'.
+
+Examples:
+  gonotes prop define status enum draft review done
+  gonotes prop define difficulty number
+  gonotes prop set 3 status review
+  gonotes prop set 3 difficulty 4
+  gonotes prop unset 3 difficulty
+  gonotes prop list 3`,
+}
+
+var propSetCmd = &cobra.Command{
+	Use:               "set [id] [name] [value]",
+	Short:             "Set a property of a note",
+	Args:              cobra.ExactArgs(3),
+	ValidArgsFunction: completePropSet,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := resolveID(args[0])
+		if err != nil {
+			return err
+		}
+
+		n, err := storage.SetProperty(id, args[1], args[2])
+		if err != nil {
+			return fmt.Errorf("failed to set property: %w", err)
+		}
+
+		name, _ := note.PropertyName(args[1])
+		color.Green("🏷️  Set %s of '%s' to %s", name, n.Title, n.PropertyString(name))
+		return nil
+	},
+}
+
+var propUnsetCmd = &cobra.Command{
+	Use:               "unset [id] [name]",
+	Short:             "Remove a property from a note",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completePropUnset,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := resolveID(args[0])
+		if err != nil {
+			return err
+		}
+
+		n, err := storage.UnsetProperty(id, args[1])
+		if err != nil {
+			return fmt.Errorf("failed to remove property: %w", err)
+		}
+
+		color.Green("✅ Removed %s from '%s'", args[1], n.Title)
+		return nil
+	},
+}
+
+var propListCmd = &cobra.Command{
+	Use:   "list [id]",
+	Short: "List the properties of a note, or all properties in use",
+	Long: `List the properties of a note. Without a note ID, list every property that
+is declared or used, with its type and the number of notes that have it.
+
+Examples:
+  gonotes prop list 3
+  gonotes prop list`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeNoteID,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return listProperties()
+		}
+
+		n, err := resolveNote(args[0])
+		if err != nil {
+			return err
+		}
+
+		if len(n.Properties) == 0 {
+			fmt.Printf("🏷️  Note '%s' has no properties.\n", n.Title)
+			return nil
+		}
+
+		color.Cyan("🏷️  Properties of '%s':\n", n.Title)
+		for _, name := range n.PropertyNames() {
+			fmt.Printf("   %s: %s\n", name, n.PropertyString(name))
+		}
+		return nil
+	},
+}
+
+func listProperties() error {
+	names := storage.GetPropertyNames()
+	if len(names) == 0 {
+		fmt.Println("🏷️  No properties found. Set one with 'gonotes prop set'.")
+		return nil
+	}
+
+	counts := make(map[string]int)
+	for _, n := range storage.GetAllNotes() {
+		for name := range n.Properties {
+			counts[name]++
+		}
+	}
+
+	schema := storage.Schema()
+	color.Cyan("🏷️  Properties (%d):\n", len(names))
+	for _, name := range names {
+		typ := "any"
+		if def, ok := schema[name]; ok {
+			typ = def.String()
+		}
+		fmt.Printf("   %s (%s) - %d notes\n", name, typ, counts[name])
+	}
+	return nil
+}
+
+var propDefineCmd = &cobra.Command{
+	Use:   "define [name] [type] [values...]",
+	Short: "Declare the type of a property",
+	Long: `Declare the type of a property: string, number, date, enum or bool. Enum
+properties take the list of allowed values. Setting a property checks the value
+against its type; notes that already have the property are checked the next
+time they are changed.
+
+Examples:
+  gonotes prop define status enum draft review done
+  gonotes prop define reviewed date
+  gonotes prop define source string`,
+	Args: cobra.MinimumNArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 1 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		types := []note.PropertyType{note.TypeString, note.TypeNumber, note.TypeDate, note.TypeEnum, note.TypeBool}
+		completions := make([]string, len(types))
+		for i, typ := range types {
+			completions[i] = string(typ)
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		schema := storage.Schema()
+		if err := schema.Define(args[0], note.PropertyType(args[1]), args[2:]); err != nil {
+			return err
+		}
+		if err := storage.SaveSchema(schema); err != nil {
+			return fmt.Errorf("failed to save schema: %w", err)
+		}
+
+		name, _ := note.PropertyName(args[0])
+		color.Green("✅ Property %s is now %s", name, schema[name])
+		return nil
+	},
+}
+
+var propUndefineCmd = &cobra.Command{
+	Use:   "undefine [name]",
+	Short: "Remove the declaration of a property",
+	Long: `Remove a property from the schema. Notes keep their values, which may then
+be of any type.`,
+	Args: cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		s := completionStorage()
+		if s == nil || len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		var names []string
+		for name, def := range s.Schema() {
+			names = append(names, name+"\t"+def.String())
+		}
+		sort.Strings(names)
+		return names, cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, err := note.PropertyName(args[0])
+		if err != nil {
+			return err
+		}
+
+		schema := storage.Schema()
+		if _, ok := schema[name]; !ok {
+			return fmt.Errorf("property %s is not declared", name)
+		}
+		delete(schema, name)
+		if err := storage.SaveSchema(schema); err != nil {
+			return fmt.Errorf("failed to save schema: %w", err)
+		}
+
+		color.Green("✅ Property %s is no longer declared", name)
+		return nil
+	},
+}
+
+// completePropSet completes the note ID, the property name and then the
+// values allowed by the schema
+func completePropSet(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	s := completionStorage()
+	if s == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	switch len(args) {
+	case 0:
+		return completeNoteIDs(cmd, args, toComplete)
+	case 1:
+		return s.GetPropertyNames(), cobra.ShellCompDirectiveNoFileComp
+	case 2:
+		def, ok := s.Schema()[args[1]]
+		switch {
+		case !ok:
+		case def.Type == note.TypeEnum:
+			return def.Values, cobra.ShellCompDirectiveNoFileComp
+		case def.Type == note.TypeBool:
+			return []string{"true", "false"}, cobra.ShellCompDirectiveNoFileComp
+		case def.Type == note.TypeDate:
+			return []string{time.Now().Format(note.DateLayout) + "\ttoday"}, cobra.ShellCompDirectiveNoFileComp
+		}
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completePropUnset completes the note ID and then its property names
+func completePropUnset(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	s := completionStorage()
+	if s == nil || len(args) > 1 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	if len(args) == 0 {
+		return completeNoteIDs(cmd, args, toComplete)
+	}
+
+	n := completionNote(s, args[0])
+	if n == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return n.PropertyNames(), cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	propCmd.AddCommand(propSetCmd)
+	propCmd.AddCommand(propUnsetCmd)
+	propCmd.AddCommand(propListCmd)
+	propCmd.AddCommand(propDefineCmd)
+	propCmd.AddCommand(propUndefineCmd)
+	rootCmd.AddCommand(propCmd)
+}
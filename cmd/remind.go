@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/midimurphdesigns/go-lang-notes/internal/reminder"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var remindCmd = &cobra.Command{
+	Use:   "remind [id] [when]",
+	Short: "Set a reminder or due date on a note",
+	Long: `Set a reminder or due date on a note using a natural date expression.
+
+Examples:
+  gonotes remind 1 "tomorrow 9am"
+  gonotes remind 1 "in 2 hours"
+  gonotes remind 1 "next friday 17:00" --due
+  gonotes remind 1 --clear
+  gonotes remind 1 --clear --due`,
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: completeNoteID,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := resolveID(args[0])
+		if err != nil {
+			return err
+		}
+
+		due, _ := cmd.Flags().GetBool("due")
+		clear, _ := cmd.Flags().GetBool("clear")
+
+		if clear {
+			return clearSchedule(id, due)
+		}
+
+		if len(args) < 2 {
+			return fmt.Errorf("remind command requires note ID and a date")
+		}
+
+		when, err := reminder.ParseWhen(args[1], time.Now())
+		if err != nil {
+			return err
+		}
+
+		if due {
+			note, err := storage.SetDueDate(id, &when)
+			if err != nil {
+				return fmt.Errorf("failed to set due date: %w", err)
+			}
+			color.Green("✅ Note '%s' is due %s", note.Title, when.Format("Mon 2006-01-02 15:04"))
+			return nil
+		}
+
+		note, err := storage.SetReminder(id, when)
+		if err != nil {
+			return fmt.Errorf("failed to set reminder: %w", err)
+		}
+		color.Green("⏰ Reminder for '%s' set for %s", note.Title, when.Format("Mon 2006-01-02 15:04"))
+		return nil
+	},
+}
+
+func clearSchedule(id int, due bool) error {
+	if due {
+		note, err := storage.SetDueDate(id, nil)
+		if err != nil {
+			return fmt.Errorf("failed to clear due date: %w", err)
+		}
+		color.Green("✅ Cleared due date of '%s'", note.Title)
+		return nil
+	}
+
+	note, err := storage.ClearReminder(id)
+	if err != nil {
+		return fmt.Errorf("failed to clear reminder: %w", err)
+	}
+	color.Green("✅ Cleared reminder of '%s'", note.Title)
+	return nil
+}
+
+var remindersCmd = &cobra.Command{
+	Use:   "reminders",
+	Short: "List and watch reminders",
+	Long: `List upcoming reminders and due dates, snooze reminders, or watch for reminders
+and fire them as they come due.
+
+Reminders are printed to the terminal. A local command can also be run for every
+reminder with --exec or the "reminders.command" config key; the note is passed
+in the GONOTES_NOTE_ID, GONOTES_NOTE_TITLE and GONOTES_NOTE_DUE environment variables.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listReminders()
+	},
+}
+
+var remindersListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List upcoming reminders and due dates",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listReminders()
+	},
+}
+
+var remindersSnoozeCmd = &cobra.Command{
+	Use:   "snooze [id] [duration]",
+	Short: "Snooze a reminder",
+	Long: `Push a reminder back by a duration counted from now (default 10m).
+
+Examples:
+  gonotes reminders snooze 1
+  gonotes reminders snooze 1 "2 hours"`,
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: completeNoteID,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := resolveID(args[0])
+		if err != nil {
+			return err
+		}
+
+		d := 10 * time.Minute
+		if len(args) == 2 {
+			d, err = reminder.ParseDuration(args[1])
+			if err != nil {
+				return err
+			}
+		}
+
+		note, err := storage.SnoozeReminder(id, d)
+		if err != nil {
+			return fmt.Errorf("failed to snooze reminder: %w", err)
+		}
+
+		color.Green("😴 Snoozed '%s' until %s", note.Title, note.RemindAt.Format("15:04"))
+		return nil
+	},
+}
+
+var remindersWatchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Watch for reminders and fire them as they come due",
+	Long: `Run in the foreground, checking for due reminders periodically.
+
+Examples:
+  gonotes reminders watch
+  gonotes reminders watch --interval 10s
+  gonotes reminders watch --exec 'notify-send "$GONOTES_NOTE_TITLE"'`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		interval, _ := cmd.Flags().GetDuration("interval")
+		command, _ := cmd.Flags().GetString("exec")
+		if command == "" {
+			command = viper.GetString("reminders.command")
+		}
+
+		notifiers := []reminder.Notifier{reminder.TerminalNotifier{Out: os.Stdout}}
+		if command != "" {
+			notifiers = append(notifiers, reminder.CommandNotifier{Command: command})
+		}
+
+		scheduler := reminder.NewScheduler(storage, interval, notifiers...)
+		scheduler.Reload = true
+		scheduler.OnError = func(err error) {
+			color.Red("⚠️  %v", err)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		color.Cyan("⏰ Watching for reminders every %s (Ctrl+C to stop)", interval)
+		if err := scheduler.Run(ctx); err != nil && err != context.Canceled {
+			return err
+		}
+		return nil
+	},
+}
+
+func listReminders() error {
+	notes := storage.GetScheduledNotes()
+
+	if len(notes) == 0 {
+		fmt.Println("⏰ No reminders or due dates set.")
+		return nil
+	}
+
+	color.Cyan("⏰ Scheduled notes (%d found):\n", len(notes))
+
+	now := time.Now()
+	for _, note := range notes {
+		fmt.Printf("[%d] ", note.ID)
+		color.New(color.Bold).Printf("%s\n", note.Title)
+
+		if note.RemindAt != nil {
+			reminderColor := color.New(color.FgCyan)
+			if note.ReminderDue(now) {
+				reminderColor = color.New(color.FgYellow)
+			}
+			reminderColor.Printf("   Remind: %s\n", note.RemindAt.Format("Mon 2006-01-02 15:04"))
+		}
+
+		if note.DueAt != nil {
+			dueColor := color.New(color.FgGreen)
+			if note.IsOverdue(now) {
+				dueColor = color.New(color.FgRed)
+			}
+			dueColor.Printf("   Due: %s\n", note.DueAt.Format("Mon 2006-01-02 15:04"))
+		}
+	}
+
+	fmt.Println()
+	return nil
+}
+
+func init() {
+	remindCmd.Flags().BoolP("due", "d", false, "Set (or with --clear, clear) the due date instead of a reminder")
+	remindCmd.Flags().BoolP("clear", "c", false, "Clear the reminder or due date")
+	rootCmd.AddCommand(remindCmd)
+
+	remindersWatchCmd.Flags().Duration("interval", reminder.DefaultInterval, "How often to check for due reminders")
+	remindersWatchCmd.Flags().StringP("exec", "e", "", "Command to run for every reminder")
+	remindersCmd.AddCommand(remindersListCmd)
+	remindersCmd.AddCommand(remindersSnoozeCmd)
+	remindersCmd.AddCommand(remindersWatchCmd)
+	rootCmd.AddCommand(remindersCmd)
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+	"golang.org/x/term"
+)
+
+// maxCandidates is the number of notes listed when a reference is ambiguous
+const maxCandidates = 10
+
+// resolveNote finds the note meant by an ID, a title, the start of a title or
+// a fuzzy match such as "gslc" for "Go Slices". When several notes match, the
+// user picks one on a terminal; otherwise the error lists them.
+func resolveNote(ref string) (*note.Note, error) {
+	n, _, err := findNote(ref)
+	return n, err
+}
+
+// resolveID returns the ID of a note to change, see resolveNote. An ID is
+// taken as it is, so encrypted notes can be deleted while locked, and a note
+// found by only part of its title is confirmed first on a terminal.
+func resolveID(ref string) (int, error) {
+	id, sure, err := lookupID(ref)
+	if err != nil || sure {
+		return id, err
+	}
+	if err := confirmMatch(ref, id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// lookupID returns the ID of the note ref means without asking to confirm a
+// partial match. sure reports whether ref is the ID or the full title, or
+// the user picked the note.
+func lookupID(ref string) (id int, sure bool, err error) {
+	ref = strings.TrimSpace(ref)
+	if note.IsID(ref) {
+		id, err := strconv.Atoi(ref)
+		if err != nil || !storage.HasNote(id) {
+			return 0, false, fmt.Errorf("note with ID %s not found", ref)
+		}
+		return id, true, nil
+	}
+
+	n, sure, err := findNote(ref)
+	if err != nil {
+		return 0, false, err
+	}
+	return n.ID, sure, nil
+}
+
+// findNote is resolveNote, also reporting whether ref is the ID or the full
+// title of the note, or the user picked it
+func findNote(ref string) (*note.Note, bool, error) {
+	matches, err := storage.FindNotes(ref)
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, false, fmt.Errorf("no note matches %q", ref)
+	case 1:
+		ref = strings.TrimSpace(ref)
+		return matches[0], note.IsID(ref) || strings.EqualFold(matches[0].Title, ref), nil
+	}
+
+	candidates := matches
+	if len(candidates) > maxCandidates {
+		candidates = candidates[:maxCandidates]
+	}
+	if interactive() {
+		n, err := pickNote(ref, candidates, len(matches))
+		return n, err == nil, err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%q matches %d notes, use an ID or more of the title:", ref, len(matches))
+	for _, n := range candidates {
+		fmt.Fprintf(&b, "\n  [%d] %s", n.ID, n.Title)
+	}
+	if len(matches) > len(candidates) {
+		fmt.Fprintf(&b, "\n  and %d more", len(matches)-len(candidates))
+	}
+	return nil, false, errors.New(b.String())
+}
+
+// confirmMatch asks whether the note with the ID, found by part of its title,
+// is the one meant. Without a terminal to ask on, the only match is taken.
+func confirmMatch(ref string, id int) error {
+	if !interactive() {
+		return nil
+	}
+	n, err := storage.GetNote(id)
+	if err != nil {
+		return err
+	}
+
+	ok, err := confirm(fmt.Sprintf("🔍 %q matches [%d] %s, use it? (y/N): ", ref, n.ID, n.Title))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("no note chosen")
+	}
+	return nil
+}
+
+// interactive reports whether the user can be asked to choose
+func interactive() bool {
+	return !structured() && term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// pickNote asks which of the candidates was meant
+func pickNote(ref string, candidates []*note.Note, total int) (*note.Note, error) {
+	color.Yellow("🔍 %q matches %d notes:", ref, total)
+	for i, n := range candidates {
+		fmt.Printf("  %d) [%d] %s\n", i+1, n.ID, n.Title)
+	}
+	if total > len(candidates) {
+		fmt.Printf("  and %d more, use more of the title to see them\n", total-len(candidates))
+	}
+
+	fmt.Printf("Which one? (1-%d): ", len(candidates))
+	line, err := stdin.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+	choice, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil || choice < 1 || choice > len(candidates) {
+		return nil, fmt.Errorf("no note chosen")
+	}
+	return candidates[choice-1], nil
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/midimurphdesigns/go-lang-notes/internal/backup"
+	"github.com/spf13/cobra"
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore [file.tar.gz]",
+	Short: "Restore notes from a backup archive",
+	Long: `Restore notes from an archive written by "gonotes backup". The archive is
+always verified first: if a checksum does not match, a file is missing or
+the archive was written by a newer version of gonotes, nothing is restored.
+
+Modes:
+  merge     add notes missing from the notes folder and update notes whose
+            backed up version is newer; nothing is deleted (default)
+  replace   make the notes folder match the backup exactly, deleting notes
+            that are not in it and restoring settings and review state
+
+Use --verify to only check an archive and --dry-run to see what a restore
+would change.
+
+Examples:
+  gonotes restore notes.tar.gz --verify
+  gonotes restore notes.tar.gz --dry-run
+  gonotes restore notes.tar.gz --mode replace`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		modeName, _ := cmd.Flags().GetString("mode")
+		verifyOnly, _ := cmd.Flags().GetBool("verify")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		mode, err := backup.ParseMode(modeName)
+		if err != nil {
+			return err
+		}
+
+		archive, err := backup.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("backup verification failed: %w", err)
+		}
+
+		manifest := archive.Manifest
+		color.Green("✅ Verified %s", args[0])
+		color.New(color.FgHiBlack).Printf("   Format %d, created %s, %d notes, %d files\n",
+			manifest.Version, manifest.CreatedAt.Local().Format("2006-01-02 15:04"), manifest.Notes, len(manifest.Files))
+		if verifyOnly {
+			return nil
+		}
+
+		result, err := backup.Restore(storage, archive, backup.Options{Mode: mode, DryRun: dryRun})
+		if err != nil {
+			return fmt.Errorf("failed to restore backup: %w", err)
+		}
+
+		if dryRun {
+			color.Yellow("\n🔍 Dry run (%s), nothing was changed:", mode)
+		} else {
+			color.Green("\n✅ Restored backup (%s):", mode)
+		}
+		fmt.Printf("   Added:     %d\n", result.Added)
+		fmt.Printf("   Updated:   %d\n", result.Updated)
+		fmt.Printf("   Unchanged: %d\n", result.Unchanged)
+		if mode == backup.ModeMerge {
+			fmt.Printf("   Kept newer local version: %d\n", result.Skipped)
+		} else {
+			fmt.Printf("   Removed:   %d\n", result.Removed)
+			fmt.Printf("   Metadata files: %d\n", result.MetaFiles)
+		}
+		fmt.Printf("   Attachments: %d\n", result.Attachments)
+		return nil
+	},
+}
+
+func init() {
+	restoreCmd.Flags().String("mode", string(backup.ModeMerge), "Restore mode: merge or replace")
+	restoreCmd.Flags().Bool("verify", false, "Only verify the archive")
+	restoreCmd.Flags().Bool("dry-run", false, "Show what would change without restoring")
+	rootCmd.AddCommand(restoreCmd)
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/midimurphdesigns/go-lang-notes/internal/flashcard"
+	"github.com/spf13/cobra"
+)
+
+// gradeShortcuts maps the review prompt shortcuts to SM-2 grades
+var gradeShortcuts = map[string]int{
+	"a": flashcard.GradeWrong,
+	"h": flashcard.GradeDifficult,
+	"g": flashcard.GradeGood,
+	"e": flashcard.GradeEasy,
+}
+
+var reviewCmd = &cobra.Command{
+	Use:   "review",
+	Short: "Review flashcards derived from your notes",
+	Long: `Start an interactive spaced-repetition review session.
+
+Flashcards are derived from notes containing question/answer blocks:
+
+  Q: What is the zero value of a slice?
+  A: nil
+
+or cloze deletions such as "A {{c1::goroutine}} is a lightweight thread".
+
+Grade each answer from 0 (blackout) to 5 (perfect), or use the shortcuts
+a(gain), h(ard), g(ood) and e(asy). Cards are scheduled with SM-2.
+
+Examples:
+  gonotes review
+  gonotes review --tag go --limit 10`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		limit, _ := cmd.Flags().GetInt("limit")
+		tag, _ := cmd.Flags().GetString("tag")
+
+		deck, err := flashcard.OpenDeck(storage)
+		if err != nil {
+			return fmt.Errorf("failed to open flashcards: %w", err)
+		}
+		if tag != "" {
+			deck.Refresh(storage.GetNotesByTag(tag))
+		}
+
+		due := deck.Due(time.Now())
+		if len(due) == 0 {
+			color.Green("🎉 No cards due for review.")
+			return nil
+		}
+		if limit > 0 && len(due) > limit {
+			due = due[:limit]
+		}
+
+		color.Cyan("🧠 Reviewing %d cards (q to quit)\n", len(due))
+
+		reviewed := 0
+		for i, card := range due {
+			grade, quit, err := reviewCard(stdin, card, i+1, len(due))
+			if err != nil {
+				return err
+			}
+			if quit {
+				break
+			}
+
+			state, err := deck.Grade(card.ID, grade, time.Now())
+			if err != nil {
+				return fmt.Errorf("failed to grade card: %w", err)
+			}
+			reviewed++
+
+			color.New(color.FgHiBlack).Printf("   Next review: %s\n\n", state.Due.Format("2006-01-02"))
+		}
+
+		color.Green("✅ Reviewed %d cards.", reviewed)
+		return nil
+	},
+}
+
+// reviewCard shows a card, waits for the answer to be revealed and reads a grade
+func reviewCard(reader *bufio.Reader, card flashcard.Card, position, total int) (int, bool, error) {
+	note, err := storage.GetNote(card.NoteID)
+	if err == nil {
+		color.New(color.FgHiBlack).Printf("[%d/%d] %s\n", position, total, note.Title)
+	}
+	color.New(color.Bold).Printf("%s\n", card.Front)
+
+	fmt.Print("Press Enter to show the answer...")
+	response, err := reader.ReadString('\n')
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read input: %w", err)
+	}
+	if strings.TrimSpace(response) == "q" {
+		return 0, true, nil
+	}
+
+	color.Green("%s\n", card.Back)
+
+	for {
+		fmt.Print("Grade (0-5, a/h/g/e, q to quit): ")
+		response, err := reader.ReadString('\n')
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to read input: %w", err)
+		}
+
+		response = strings.ToLower(strings.TrimSpace(response))
+		if response == "q" {
+			return 0, true, nil
+		}
+		if grade, ok := gradeShortcuts[response]; ok {
+			return grade, false, nil
+		}
+		if grade, err := strconv.Atoi(response); err == nil && grade >= flashcard.GradeBlackout && grade <= flashcard.GradeEasy {
+			return grade, false, nil
+		}
+
+		color.Yellow("Please enter a grade between 0 and 5.")
+	}
+}
+
+func init() {
+	reviewCmd.Flags().IntP("limit", "n", 20, "Maximum number of cards to review")
+	reviewCmd.Flags().StringP("tag", "t", "", "Only review cards from notes with this tag")
+	reviewCmd.RegisterFlagCompletionFunc("tag", completeTags)
+	rootCmd.AddCommand(reviewCmd)
+}
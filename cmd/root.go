@@ -1,10 +1,13 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
+	"github.com/midimurphdesigns/go-lang-notes/internal/journal"
 	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+	"github.com/midimurphdesigns/go-lang-notes/internal/output"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -13,6 +16,10 @@ var (
 	cfgFile  string
 	notesDir string
 	storage  *note.Storage
+	// history records the changes made by commands so they can be undone
+	history *journal.Journal
+	// outputOptions select machine-readable output with --output and --format
+	outputOptions output.Options
 )
 
 var rootCmd = &cobra.Command{
@@ -23,13 +30,29 @@ var rootCmd = &cobra.Command{
 It allows you to create, read, update, delete, and search notes with a simple and intuitive interface.
 Notes are stored as JSON files for easy backup and version control.`,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Completion opens the notes itself, see completionStorage
+		if cmd.Name() == cobra.ShellCompRequestCmd {
+			return nil
+		}
+
+		if err := outputOptions.Check(); err != nil {
+			return err
+		}
+
 		// Initialize storage
 		var err error
 		storage, err = note.NewStorage(notesDir)
 		if err != nil {
 			return fmt.Errorf("failed to initialize storage: %w", err)
 		}
-		return nil
+
+		history = journal.Open(storage, func(err error) {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		})
+		if storage.Settings().GitAutoCommit {
+			enableAutoCommit()
+		}
+		return unlockFromSession()
 	},
 }
 
@@ -37,6 +60,9 @@ Notes are stored as JSON files for easy backup and version control.`,
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
+		if errors.Is(err, note.ErrLocked) {
+			fmt.Fprintln(os.Stderr, "Run 'gonotes unlock' to read encrypted notes.")
+		}
 		os.Exit(1)
 	}
 }
@@ -47,11 +73,26 @@ func init() {
 	// Global flags
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.gonotes.yaml)")
 	rootCmd.PersistentFlags().StringVar(&notesDir, "notes-dir", "notes", "directory to store notes")
+	rootCmd.PersistentFlags().StringVarP(&outputOptions.Format, "output", "o", output.FormatText, "output format: text, json, jsonl, yaml, table or csv")
+	rootCmd.PersistentFlags().StringVar(&outputOptions.Template, "format", "", "print results with a Go template, such as '{{.ID}} {{.Title}}'")
+	rootCmd.RegisterFlagCompletionFunc("output", completeOutput)
 
 	// Local flags
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
 
+// structured reports whether results are printed for scripts rather than
+// people
+func structured() bool {
+	return outputOptions.Structured()
+}
+
+// printResult prints a result in the format selected with --output or
+// --format
+func printResult(value interface{}) error {
+	return output.Write(os.Stdout, outputOptions, value)
+}
+
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
 	if cfgFile != "" {
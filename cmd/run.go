@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/midimurphdesigns/go-lang-notes/internal/gocode"
+	"github.com/spf13/cobra"
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run [id]",
+	Short: "Run the Go code blocks of a note",
+	Long: `Extract the fenced Go code blocks of a note and build and run them in an
+isolated temporary module.
+
+Snippets without a package clause are wrapped in package main, statements are
+wrapped in a main function, and imports of common standard library packages
+are added when missing.
+
+Examples:
+  gonotes run 1
+  gonotes run 1 --block 2
+  gonotes run 1 --timeout 5s`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeNoteID,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		note, err := resolveNote(args[0])
+		if err != nil {
+			return err
+		}
+
+		blocks := gocode.Extract(note.Content)
+		if len(blocks) == 0 {
+			return fmt.Errorf("note '%s' has no Go code blocks", note.Title)
+		}
+
+		blockIndex, _ := cmd.Flags().GetInt("block")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+
+		if blockIndex != 0 {
+			if blockIndex < 1 || blockIndex > len(blocks) {
+				return fmt.Errorf("note '%s' has %d Go code blocks, no block %d", note.Title, len(blocks), blockIndex)
+			}
+			blocks = blocks[blockIndex-1 : blockIndex]
+		}
+
+		failed := 0
+		for _, block := range blocks {
+			color.Cyan("▶ Block %d (line %d)", block.Index, block.Line)
+
+			result, err := gocode.Run(context.Background(), block.Code, timeout)
+			if err != nil {
+				return err
+			}
+
+			if result.Output != "" {
+				fmt.Print(result.Output)
+				if !strings.HasSuffix(result.Output, "\n") {
+					fmt.Println()
+				}
+			}
+
+			switch {
+			case result.TimedOut:
+				failed++
+				color.Red("⏱  Timed out after %s\n", timeout)
+			case !result.Success():
+				failed++
+				color.Red("❌ Exit status %d\n", result.ExitCode)
+			default:
+				color.Green("✅ Finished in %s\n", result.Duration.Round(1e6))
+			}
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d of %d Go code blocks failed", failed, len(blocks))
+		}
+		return nil
+	},
+}
+
+func init() {
+	runCmd.Flags().IntP("block", "b", 0, "Only run the Nth Go code block (1-based)")
+	runCmd.Flags().Duration("timeout", gocode.DefaultTimeout, "Maximum time to build and run each block")
+	rootCmd.AddCommand(runCmd)
+}
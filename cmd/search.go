@@ -2,25 +2,51 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/fatih/color"
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+	"github.com/midimurphdesigns/go-lang-notes/internal/output"
 	"github.com/spf13/cobra"
 )
 
 var searchCmd = &cobra.Command{
 	Use:   "search [query]",
 	Short: "Search notes",
-	Long: `Search notes by title, content, or tags.
+	Long: `Search notes by title, content, or tags, and optionally by property.
 	
 Examples:
   gonotes search "go slices"
   gonotes search "data structures"
-  gonotes search "practice"`,
-	Args: cobra.ExactArgs(1),
+  gonotes search "practice"
+  gonotes search "channels" --prop status=draft
+  gonotes search --prop "difficulty>=3" --prop "reviewed<2024-06-01"`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		query := args[0]
+		props, _ := cmd.Flags().GetStringArray("prop")
+		if len(args) == 0 && len(props) == 0 {
+			return fmt.Errorf("search command requires a query or --prop")
+		}
+
+		var query string
+		var results []*note.Note
+		if len(args) > 0 {
+			query = args[0]
+			results = storage.SearchNotes(query)
+		} else {
+			results = storage.GetActiveNotes()
+		}
 
-		results := storage.SearchNotes(query)
+		results, err := filterByProperties(results, props)
+		if err != nil {
+			return err
+		}
+		if structured() {
+			return printResult(output.NewNoteResponses(results))
+		}
+		if query == "" {
+			query = strings.Join(props, " ")
+		}
 
 		if len(results) == 0 {
 			fmt.Printf("🔍 No notes found matching '%s'\n", query)
@@ -37,6 +63,27 @@ Examples:
 	},
 }
 
+// filterByProperties keeps the notes matching every property condition,
+// such as "status=draft" or "difficulty>=3"
+func filterByProperties(notes []*note.Note, conditions []string) ([]*note.Note, error) {
+	for _, condition := range conditions {
+		match, err := note.ParsePropertyFilter(condition)
+		if err != nil {
+			return nil, err
+		}
+
+		var matched []*note.Note
+		for _, n := range notes {
+			if match(n) {
+				matched = append(matched, n)
+			}
+		}
+		notes = matched
+	}
+	return notes, nil
+}
+
 func init() {
+	searchCmd.Flags().StringArray("prop", nil, "Only notes whose property matches, such as status=draft or difficulty>=3 (repeatable)")
 	rootCmd.AddCommand(searchCmd)
 }
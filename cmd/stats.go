@@ -3,44 +3,232 @@ package cmd
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+	"github.com/midimurphdesigns/go-lang-notes/internal/output"
 	"github.com/spf13/cobra"
 )
 
+// heatmapWeeks is the number of weeks shown by the activity heatmap and
+// sparkline
+const heatmapWeeks = 52
+
 var statsCmd = &cobra.Command{
 	Use:   "stats",
 	Short: "Show note statistics",
-	Long: `Display statistics about your notes.
-	
+	Long: `Display statistics about your notes. With --detailed, also show word counts,
+your activity as a heatmap and weekly sparkline, streaks, the most used tags
+and tags used together, and the most edited notes. --range limits the activity
+to recent days, such as 30d, 12w, 6m or 1y, or to the days since a date.
+
 Examples:
-  gonotes stats`,
+  gonotes stats
+  gonotes stats --detailed
+  gonotes stats --detailed --range 12w
+  gonotes stats --range 30d -o json`,
+	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		stats := storage.GetStats()
+		detailed, _ := cmd.Flags().GetBool("detailed")
+		rangeText, _ := cmd.Flags().GetString("range")
+
+		// The statistics are nested lists and maps that do not fit in rows
+		if format := outputOptions.Format; format == output.FormatTable || format == output.FormatCSV {
+			return fmt.Errorf("stats cannot be printed as %s, use json, jsonl or yaml", format)
+		}
+
+		since, err := note.ParseRange(rangeText, time.Now())
+		if err != nil {
+			return err
+		}
+		stats := storage.GetStats(since)
+		if structured() {
+			return printResult(stats)
+		}
 
 		color.Cyan("📊 Note Statistics")
 		color.Cyan("=" + strings.Repeat("=", 30))
 
-		color.White("Total Notes: %d", stats["total"])
-		color.Green("Active Notes: %d", stats["active"])
-		color.Yellow("Archived Notes: %d", stats["archived"])
-		color.Magenta("Favorite Notes: %d", stats["favorites"])
-		color.Blue("Unique Tags: %d", stats["tags"])
+		color.White("Total Notes: %d", stats.Total)
+		color.Green("Active Notes: %d", stats.Active)
+		color.Yellow("Archived Notes: %d", stats.Archived)
+		color.Magenta("Favorite Notes: %d", stats.Favorites)
+		color.Blue("Unique Tags: %d", stats.Tags)
 
-		if stats["total"] > 0 {
-			activePercent := float64(stats["active"]) / float64(stats["total"]) * 100
-			favoritePercent := float64(stats["favorites"]) / float64(stats["total"]) * 100
+		if stats.Total > 0 {
+			activePercent := float64(stats.Active) / float64(stats.Total) * 100
+			favoritePercent := float64(stats.Favorites) / float64(stats.Total) * 100
 
 			fmt.Printf("\n")
 			color.New(color.FgHiBlack).Printf("Active Rate: %.1f%%\n", activePercent)
 			color.New(color.FgHiBlack).Printf("Favorite Rate: %.1f%%\n", favoritePercent)
 		}
 
+		if detailed {
+			printDetailedStats(stats)
+		}
+
 		fmt.Println()
 		return nil
 	},
 }
 
+func printDetailedStats(stats note.Stats) {
+	fmt.Println()
+	color.Cyan("✍️  Writing")
+	fmt.Printf("   Words: %d (%.0f per note)\n", stats.Words, stats.AverageWords)
+	fmt.Printf("   Characters: %d (%.0f per note)\n", stats.Characters, stats.AverageCharacters)
+
+	fmt.Println()
+	if stats.Since != nil {
+		color.Cyan("📅 Activity since %s", stats.Since.Format(note.DateLayout))
+	} else {
+		color.Cyan("📅 Activity")
+	}
+	created, updated := 0, 0
+	for i := range stats.CreatedPerDay {
+		created += stats.CreatedPerDay[i].Count
+		updated += stats.UpdatedPerDay[i].Count
+	}
+	if created+updated == 0 {
+		fmt.Println("   No notes were created or updated.")
+	} else {
+		fmt.Printf("   Created: %s, updated: %s\n", countNotes(created), countNotes(updated))
+		fmt.Printf("   Longest streak: %s\n", describeStreak(stats.LongestStreak))
+		fmt.Printf("   Current streak: %s\n", describeStreak(stats.CurrentStreak))
+
+		weeks := make([]int, len(stats.CreatedPerWeek))
+		for i := range weeks {
+			weeks[i] = stats.CreatedPerWeek[i].Count + stats.UpdatedPerWeek[i].Count
+		}
+		if len(weeks) > heatmapWeeks {
+			weeks = weeks[len(weeks)-heatmapWeeks:]
+		}
+		fmt.Printf("   Per week: %s\n", sparkline(weeks))
+		fmt.Println()
+		for _, line := range heatmap(stats.CreatedPerDay, stats.UpdatedPerDay) {
+			fmt.Println("   " + line)
+		}
+	}
+
+	if len(stats.TagFrequency) > 0 {
+		fmt.Println()
+		color.Cyan("🏷️  Top tags")
+		for _, tag := range limit(stats.TagFrequency, 10) {
+			fmt.Printf("   %s (%d)\n", tag.Name, tag.Count)
+		}
+	}
+
+	if pairs := stats.TagPairs(); len(pairs) > 0 {
+		fmt.Println()
+		color.Cyan("🔗 Tags used together")
+		for _, pair := range limit(pairs, 5) {
+			fmt.Printf("   %s + %s (%s)\n", pair.Tags[0], pair.Tags[1], countNotes(pair.Count))
+		}
+	}
+
+	if len(stats.MostEdited) > 0 {
+		fmt.Println()
+		if stats.Since != nil {
+			color.Cyan("✏️  Most edited of the notes changed since %s", stats.Since.Format(note.DateLayout))
+		} else {
+			color.Cyan("✏️  Most edited")
+		}
+		for _, edited := range limit(stats.MostEdited, 5) {
+			fmt.Printf("   [%d] %s (%d edits in total)\n", edited.ID, edited.Title, edited.Edits)
+		}
+	}
+}
+
+// describeStreak returns "3 days (2024-05-01 to 2024-05-03)" or "none"
+func describeStreak(streak note.Streak) string {
+	switch streak.Days {
+	case 0:
+		return "none"
+	case 1:
+		return fmt.Sprintf("1 day (%s)", streak.Start)
+	}
+	return fmt.Sprintf("%d days (%s to %s)", streak.Days, streak.Start, streak.End)
+}
+
+// sparkline draws counts as a line of bars
+func sparkline(counts []int) string {
+	bars := []rune("▁▂▃▄▅▆▇█")
+	highest := 0
+	for _, count := range counts {
+		highest = max(highest, count)
+	}
+
+	var b strings.Builder
+	for _, count := range counts {
+		if highest == 0 {
+			b.WriteRune(bars[0])
+			continue
+		}
+		b.WriteRune(bars[count*(len(bars)-1)/highest])
+	}
+	return b.String()
+}
+
+// heatmap draws the activity of the last weeks as a grid of weekdays by
+// weeks, darker on busier days
+func heatmap(created, updated []note.DayCount) []string {
+	if len(created) == 0 {
+		return nil
+	}
+
+	counts := make(map[string]int)
+	highest := 0
+	for i := range created {
+		count := created[i].Count + updated[i].Count
+		counts[created[i].Date] = count
+		highest = max(highest, count)
+	}
+
+	first, _ := time.ParseInLocation(note.DateLayout, created[0].Date, time.Local)
+	last, _ := time.ParseInLocation(note.DateLayout, created[len(created)-1].Date, time.Local)
+	start := last.AddDate(0, 0, -((int(last.Weekday())+6)%7)-7*(heatmapWeeks-1))
+	if start.Before(first) {
+		start = first.AddDate(0, 0, -((int(first.Weekday()) + 6) % 7))
+	}
+
+	shades := []rune("·░▒▓█")
+	rows := make([]strings.Builder, 7)
+	for day := start; !day.After(last); day = day.AddDate(0, 0, 1) {
+		row := &rows[(int(day.Weekday())+6)%7]
+		count, inRange := counts[day.Format(note.DateLayout)]
+		switch {
+		case !inRange:
+			row.WriteRune(' ')
+		case count == 0:
+			row.WriteRune(shades[0])
+		default:
+			levels := len(shades) - 1
+			row.WriteRune(shades[(count*levels+highest-1)/highest])
+		}
+	}
+
+	lines := make([]string, 0, 8)
+	for i, name := range []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"} {
+		lines = append(lines, name+" "+rows[i].String())
+	}
+	return append(lines, fmt.Sprintf("    %s to %s, less %s more", start.Format(note.DateLayout), last.Format(note.DateLayout), string(shades)))
+}
+
+// limit returns at most the first n items
+func limit[T any](items []T, n int) []T {
+	if len(items) > n {
+		return items[:n]
+	}
+	return items
+}
+
 func init() {
+	statsCmd.Flags().BoolP("detailed", "d", false, "Show writing, activity, tag and edit statistics")
+	statsCmd.Flags().StringP("range", "r", "all", "Activity to include, such as 30d, 12w, 6m, 1y, 2024-01-31 or all")
+	statsCmd.RegisterFlagCompletionFunc("range", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"7d", "30d", "12w", "6m", "1y", "all"}, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+	})
 	rootCmd.AddCommand(statsCmd)
 }
@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/midimurphdesigns/go-lang-notes/internal/gitsync"
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+	"github.com/midimurphdesigns/go-lang-notes/internal/peer"
+	"github.com/spf13/cobra"
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync [other-dir-or-url]",
+	Short: "Synchronize notes with git or another notes store",
+	Long: `Without an argument, commit local changes to the notes git repository, pull
+the remote branch, rebase local commits onto it and push the result. When a
+note was changed both locally and on the remote, the sync stops and lists
+the conflicting notes; the notes folder is left exactly as it was. Run the
+sync again with --prefer local or --prefer remote to keep one version of
+each conflicting note. Set up the repository first with "gonotes sync setup".
+
+With an argument, sync in both directions with another notes folder (such
+as a shared one on a mounted drive) or a running gonotes server. Changes
+since the last sync with that peer are merged field by field: edits to
+different lines of a note are combined, added and removed tags on either
+side are applied, and deleted notes are deleted on the other side unless
+they were changed there. When the same field was changed differently on
+both sides the local version is kept and the other version is saved as a
+"conflict copy" note tagged "conflict". Attachment files are not copied.
+
+Examples:
+  gonotes sync
+  gonotes sync --prefer remote
+  gonotes sync /mnt/shared/notes
+  gonotes sync http://notes.local:8080 --dry-run`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 {
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			return syncPeer(args[0], dryRun)
+		}
+
+		remote, _ := cmd.Flags().GetString("remote")
+		prefer, _ := cmd.Flags().GetString("prefer")
+
+		repo, err := gitsync.Open(storage.Dir())
+		if err != nil {
+			return err
+		}
+
+		result, err := repo.Sync(gitsync.SyncOptions{Remote: remote, Prefer: prefer})
+		var conflictErr *gitsync.ConflictError
+		if errors.As(err, &conflictErr) {
+			color.Yellow("⚠️  These notes were changed both locally and on the remote:")
+			printConflicts(conflictErr.Conflicts)
+			color.Yellow("\nNothing was changed. Edit the notes, or run again with --prefer local or --prefer remote.")
+			return err
+		}
+		if err != nil {
+			return fmt.Errorf("failed to sync: %w", err)
+		}
+
+		// Pick up notes changed by the pull
+		if err := storage.Reload(); err != nil {
+			return fmt.Errorf("failed to reload notes: %w", err)
+		}
+
+		color.Green("✅ Synced %s with %s", result.Branch, remote)
+		if result.Committed {
+			fmt.Println("   Committed local changes")
+		}
+		fmt.Printf("   Pulled %d commits, pushed %d commits\n", result.Pulled, result.Pushed)
+		if len(result.Resolved) > 0 {
+			color.Yellow("   Kept the %s version of:", prefer)
+			printConflicts(result.Resolved)
+		}
+		return nil
+	},
+}
+
+// syncPeer syncs the notes with another notes folder or gonotes server
+func syncPeer(location string, dryRun bool) error {
+	other, err := peer.Open(location)
+	if err != nil {
+		return err
+	}
+
+	last := peer.LastSync(storage, location)
+	result, err := peer.Sync(storage, other, location, peer.Options{DryRun: dryRun})
+	if err != nil {
+		return fmt.Errorf("failed to sync with %s: %w", location, err)
+	}
+
+	if dryRun {
+		color.Yellow("🔍 Dry run, nothing was changed. Syncing with %s would:", location)
+	} else {
+		color.Green("✅ Synced with %s", location)
+	}
+	if last.IsZero() {
+		color.New(color.FgHiBlack).Println("   First sync with this peer")
+	}
+	fmt.Printf("   Pulled:  %d notes added or updated here\n", result.Pulled)
+	fmt.Printf("   Pushed:  %d notes added or updated there\n", result.Pushed)
+	fmt.Printf("   Deleted: %d here, %d there\n", result.DeletedLocal, result.DeletedRemote)
+
+	if len(result.Conflicts) > 0 {
+		color.Yellow("\n⚠️  %d notes were changed on both sides:", len(result.Conflicts))
+		for _, conflict := range result.Conflicts {
+			fmt.Printf("   [%d] %s (%s), other version in note %d\n",
+				conflict.ID, conflict.Title, strings.Join(conflict.Fields, ", "), conflict.CopyID)
+		}
+	}
+	return nil
+}
+
+var syncSetupCmd = &cobra.Command{
+	Use:   "setup [remote-url]",
+	Short: "Track the notes folder with git",
+	Long: `Create a git repository in the notes folder (unless it is already inside
+one), optionally set its remote, commit the current notes and turn on
+automatic commits: from then on every change made with gonotes is
+committed with a message describing it.
+
+Examples:
+  gonotes sync setup
+  gonotes sync setup git@github.com:me/notes.git
+  gonotes sync setup /mnt/backup/notes.git`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		remote, _ := cmd.Flags().GetString("remote")
+
+		repo, created, err := gitsync.Init(storage.Dir())
+		if err != nil {
+			return err
+		}
+		if created {
+			color.Green("✅ Created a git repository in %s", storage.Dir())
+		}
+
+		if len(args) > 0 {
+			if err := repo.SetRemote(remote, args[0]); err != nil {
+				return fmt.Errorf("failed to set remote: %w", err)
+			}
+			color.Green("✅ Remote %s is %s", remote, args[0])
+		}
+
+		settings := storage.Settings()
+		settings.GitAutoCommit = true
+		if err := storage.SaveSettings(settings); err != nil {
+			return fmt.Errorf("failed to save settings: %w", err)
+		}
+
+		ignored, err := repo.IgnoreMeta()
+		if err != nil {
+			return err
+		}
+		if ignored {
+			color.Green("✅ Added %s to .gitignore", note.MetaDirName)
+		}
+
+		committed, err := repo.CommitAll("Track notes with gonotes")
+		if err != nil {
+			return fmt.Errorf("failed to commit notes: %w", err)
+		}
+		if committed {
+			color.Green("✅ Committed the current notes")
+		}
+
+		color.Green("✅ Automatic commits are on (turn off with: gonotes config git_autocommit false)")
+		return nil
+	},
+}
+
+var syncStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the state of the notes git repository",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		remote, _ := cmd.Flags().GetString("remote")
+
+		repo, err := gitsync.Open(storage.Dir())
+		if err != nil {
+			return err
+		}
+
+		status, err := repo.Status(remote)
+		if err != nil {
+			return fmt.Errorf("failed to read repository status: %w", err)
+		}
+
+		color.Cyan("🔄 Sync status for %s\n", storage.Dir())
+		fmt.Printf("Branch:       %s\n", status.Branch)
+		if status.RemoteURL == "" {
+			fmt.Printf("Remote:       %s (not configured)\n", status.Remote)
+		} else {
+			fmt.Printf("Remote:       %s (%s)\n", status.Remote, status.RemoteURL)
+		}
+		fmt.Printf("Auto-commit:  %t\n", storage.Settings().GitAutoCommit)
+		fmt.Printf("Uncommitted:  %d files\n", status.Changes)
+		if status.HasUpstream {
+			fmt.Printf("Ahead/behind: %d/%d commits (as of the last sync)\n", status.Ahead, status.Behind)
+		}
+		return nil
+	},
+}
+
+// printConflicts lists conflicting files with the titles of their notes
+func printConflicts(conflicts []gitsync.Conflict) {
+	for _, conflict := range conflicts {
+		if conflict.NoteID == 0 {
+			fmt.Printf("   %s\n", conflict.Path)
+			continue
+		}
+
+		title := "(not in local notes)"
+		if n, err := storage.GetNote(conflict.NoteID); err == nil {
+			title = n.Title
+		}
+		fmt.Printf("   [%d] %s  (%s)\n", conflict.NoteID, title, conflict.Path)
+	}
+}
+
+// enableAutoCommit commits every change made by the current command
+func enableAutoCommit() {
+	repo, err := gitsync.Open(storage.Dir())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: automatic commits are on but %v\n", err)
+		return
+	}
+
+	gitsync.AutoCommit(storage, repo, func(err error) {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	})
+}
+
+func init() {
+	syncCmd.PersistentFlags().String("remote", gitsync.DefaultRemote, "Git remote to sync with")
+	syncCmd.Flags().String("prefer", "", "Resolve git conflicts by keeping the local or remote version")
+	syncCmd.Flags().Bool("dry-run", false, "Show what syncing with a peer would change")
+	syncCmd.AddCommand(syncSetupCmd)
+	syncCmd.AddCommand(syncStatusCmd)
+	rootCmd.AddCommand(syncCmd)
+}
@@ -2,23 +2,25 @@ package cmd
 
 import (
 	"fmt"
-	"strconv"
 	"strings"
 
 	"github.com/fatih/color"
+	"github.com/midimurphdesigns/go-lang-notes/internal/output"
 	"github.com/spf13/cobra"
 )
 
 var tagCmd = &cobra.Command{
 	Use:   "tag [id] [tags]",
 	Short: "Manage tags on a note",
-	Long: `Add or remove tags from a note.
-	
+	Long: `Add or remove tags from a note, given by its ID or title.
+
 Examples:
   gonotes tag 1 "go,practice,learning"    # Add tags to note
   gonotes tag 1 --remove "practice"       # Remove specific tag
+  gonotes tag "Go Slices" go              # Find the note by title
   gonotes tag --list                      # List all tags`,
-	Args: cobra.MinimumNArgs(0),
+	Args:              cobra.MinimumNArgs(0),
+	ValidArgsFunction: completeTagArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		list, _ := cmd.Flags().GetBool("list")
 		remove, _ := cmd.Flags().GetString("remove")
@@ -31,9 +33,9 @@ Examples:
 			return fmt.Errorf("tag command requires note ID and tags")
 		}
 
-		id, err := strconv.Atoi(args[0])
+		id, err := resolveID(args[0])
 		if err != nil {
-			return fmt.Errorf("invalid note ID: %s", args[0])
+			return err
 		}
 
 		if remove != "" {
@@ -47,20 +49,29 @@ Examples:
 func addTags(id int, tagsStr string) error {
 	tags := strings.Split(tagsStr, ",")
 
+	note, err := storage.GetNote(id)
+	if err != nil {
+		return fmt.Errorf("failed to get note: %w", err)
+	}
 	for _, tag := range tags {
 		tag = strings.TrimSpace(tag)
 		if tag == "" {
 			continue
 		}
 
-		note, err := storage.AddTag(id, tag)
+		note, err = storage.AddTag(id, tag)
 		if err != nil {
 			return fmt.Errorf("failed to add tag '%s': %w", tag, err)
 		}
 
-		color.Green("✅ Added tag '%s' to note '%s'", tag, note.Title)
+		if !structured() {
+			color.Green("✅ Added tag '%s' to note '%s'", tag, note.Title)
+		}
 	}
 
+	if structured() {
+		return printResult(output.NewNoteResponse(note))
+	}
 	return nil
 }
 
@@ -69,6 +80,9 @@ func removeTag(id int, tag string) error {
 	if err != nil {
 		return fmt.Errorf("failed to remove tag '%s': %w", tag, err)
 	}
+	if structured() {
+		return printResult(output.NewNoteResponse(note))
+	}
 
 	color.Green("✅ Removed tag '%s' from note '%s'", tag, note.Title)
 	return nil
@@ -77,6 +91,14 @@ func removeTag(id int, tag string) error {
 func listAllTags() error {
 	tags := storage.GetAllTags()
 
+	if structured() {
+		counts := make([]output.TagCount, len(tags))
+		for i, tag := range tags {
+			counts[i] = output.TagCount{Name: tag, Count: len(storage.GetNotesByTag(tag))}
+		}
+		return printResult(counts)
+	}
+
 	if len(tags) == 0 {
 		fmt.Println("📝 No tags found.")
 		return nil
@@ -91,8 +113,34 @@ func listAllTags() error {
 	return nil
 }
 
+// completeTagArgs completes the note ID and then the tags to add
+func completeTagArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return completeNoteIDs(cmd, args, toComplete)
+	case 1:
+		return completeTagList(cmd, args, toComplete)
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeRemoveTag completes the tags of the note given, or all tags
+func completeRemoveTag(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	s := completionStorage()
+	if s == nil || len(args) == 0 {
+		return completeTags(cmd, args, toComplete)
+	}
+
+	n := completionNote(s, args[0])
+	if n == nil {
+		return completeTags(cmd, args, toComplete)
+	}
+	return tagCompletions(s, n.Tags, ""), cobra.ShellCompDirectiveNoFileComp
+}
+
 func init() {
 	tagCmd.Flags().BoolP("list", "l", false, "List all tags")
 	tagCmd.Flags().StringP("remove", "r", "", "Remove specific tag")
+	tagCmd.RegisterFlagCompletionFunc("remove", completeRemoveTag)
 	rootCmd.AddCommand(tagCmd)
 }
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/tui"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Browse and edit notes in a full-screen terminal interface",
+	Long: `Open a full-screen terminal interface with a tag sidebar, the note list and a
+preview of the selected note.
+
+Keys:
+  j/k, arrows   move             tab       switch between tags, list and preview
+  /             search (same language as --query, e.g. "tag:go channels")
+  enter         filter by the selected tag, or scroll the preview
+  n / e         create / edit a note (ctrl+s saves, esc cancels)
+  a / f / p     archive / favorite / pin the selected note
+  d             delete the selected note
+  A             show archived notes    esc   clear the tag filter and search
+  r             reload                 q     quit
+
+Changes made to the notes folder by other commands or the web server are
+picked up while the interface is open.
+
+Examples:
+  gonotes tui
+  gonotes tui --refresh 10s`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !term.IsTerminal(int(os.Stdout.Fd())) {
+			return fmt.Errorf("tui needs a terminal")
+		}
+		refresh, _ := cmd.Flags().GetDuration("refresh")
+
+		return tui.Run(storage, tui.Options{Refresh: refresh})
+	},
+}
+
+func init() {
+	tuiCmd.Flags().Duration("refresh", 2*time.Second, "How often to check for changes made elsewhere (0 to turn off)")
+	rootCmd.AddCommand(tuiCmd)
+}
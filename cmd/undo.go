@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/fatih/color"
+	"github.com/midimurphdesigns/go-lang-notes/internal/journal"
+	"github.com/midimurphdesigns/go-lang-notes/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var undoCmd = &cobra.Command{
+	Use:   "undo [n]",
+	Short: "Undo the last changes to your notes",
+	Long: fmt.Sprintf(`Undo the last change, or the last n changes, made by gonotes or its web
+server, newest first. A bulk change is undone as a whole. Changes made since
+to the same notes must be undone first. The last %d changes are kept; see
+them with 'gonotes log'.
+
+Changes to settings and to encrypted notes cannot be undone, and the files
+of deleted attachments are not brought back.
+
+Examples:
+  gonotes undo
+  gonotes undo 3
+  gonotes redo`, journal.MaxEntries),
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		n, err := parseCount(args)
+		if err != nil {
+			return err
+		}
+		done, err := history.Undo(n)
+		return printReplayed(done, err, "↩️  Undid")
+	},
+}
+
+var redoCmd = &cobra.Command{
+	Use:   "redo [n]",
+	Short: "Redo changes that were undone",
+	Long: `Redo the last change undone, or the last n. Making another change after
+undoing drops the changes that were undone.
+
+Examples:
+  gonotes redo
+  gonotes redo 2`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		n, err := parseCount(args)
+		if err != nil {
+			return err
+		}
+		done, err := history.Redo(n)
+		return printReplayed(done, err, "↪️  Redid")
+	},
+}
+
+var logCmd = &cobra.Command{
+	Use:   "log",
+	Short: "List recent changes to your notes",
+	Long: `List the recent changes that 'gonotes undo' can undo, newest first. Changes
+that were undone and can be redone are marked.
+
+Examples:
+  gonotes log
+  gonotes log -n 5
+  gonotes log -o json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		entries, err := history.Entries()
+		if err != nil {
+			return err
+		}
+
+		// Newest first
+		for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+			entries[i], entries[j] = entries[j], entries[i]
+		}
+		if limit > 0 && len(entries) > limit {
+			entries = entries[:limit]
+		}
+
+		if structured() {
+			return printResult(output.NewOperations(entries))
+		}
+
+		if len(entries) == 0 {
+			fmt.Println("📜 No changes to undo.")
+			return nil
+		}
+
+		color.Cyan("📜 Recent changes:\n")
+		for _, entry := range entries {
+			line := fmt.Sprintf("  %4d  %s  %s", entry.ID, entry.Time.Format("2006-01-02 15:04"), entry.Message)
+			if entry.Undone {
+				color.New(color.FgHiBlack).Println(line + " (undone)")
+				continue
+			}
+			fmt.Println(line)
+		}
+		return nil
+	},
+}
+
+// parseCount reads the optional number of changes to undo or redo
+func parseCount(args []string) (int, error) {
+	if len(args) == 0 {
+		return 1, nil
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid number of changes: %s", args[0])
+	}
+	return n, nil
+}
+
+// printReplayed prints the changes undone or redone, also when a later one
+// failed
+func printReplayed(done []journal.Entry, err error, verb string) error {
+	if structured() && len(done) > 0 {
+		if printErr := printResult(output.NewOperations(done)); printErr != nil {
+			return printErr
+		}
+	} else {
+		for _, entry := range done {
+			color.Green("%s: %s", verb, entry.Message)
+		}
+	}
+	return err
+}
+
+func init() {
+	logCmd.Flags().IntP("limit", "n", 20, "Number of changes to list, 0 for all")
+	rootCmd.AddCommand(undoCmd)
+	rootCmd.AddCommand(redoCmd)
+	rootCmd.AddCommand(logCmd)
+}
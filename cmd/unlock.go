@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/midimurphdesigns/go-lang-notes/internal/vault"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// passphraseEnv unlocks encrypted notes for a single command
+const passphraseEnv = "GONOTES_PASSPHRASE"
+
+var unlockCmd = &cobra.Command{
+	Use:   "unlock",
+	Short: "Unlock encrypted notes for a while",
+	Long: `Ask for the passphrase of the encrypted notes and keep the key so the
+following commands can read them without asking again. The key is kept in a
+private file of the user's runtime directory until it expires or
+'gonotes lock' is run.
+
+Set GONOTES_PASSPHRASE to unlock a single command instead, such as in scripts.
+
+Examples:
+  gonotes unlock
+  gonotes unlock --for 2h`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !storage.EncryptionEnabled() {
+			return fmt.Errorf("no notes are encrypted (see 'gonotes encrypt')")
+		}
+		timeout, _ := cmd.Flags().GetDuration("for")
+
+		passphrase, err := readPassphrase("Passphrase: ")
+		if err != nil {
+			return err
+		}
+		key, err := storage.Unlock(passphrase)
+		if err != nil {
+			return fmt.Errorf("failed to unlock notes: %w", err)
+		}
+		if err := vault.SaveSession(storage.Dir(), key, timeout); err != nil {
+			return err
+		}
+
+		color.Green("🔓 Notes unlocked for %s.", timeout)
+		return nil
+	},
+}
+
+// unlockFromSession unlocks the storage with GONOTES_PASSPHRASE or the key
+// kept by 'gonotes unlock', if any
+func unlockFromSession() error {
+	if !storage.EncryptionEnabled() {
+		return nil
+	}
+
+	if passphrase := os.Getenv(passphraseEnv); passphrase != "" {
+		if _, err := storage.Unlock(passphrase); err != nil {
+			return fmt.Errorf("failed to unlock notes with %s: %w", passphraseEnv, err)
+		}
+		return nil
+	}
+
+	key, err := vault.LoadSession(storage.Dir())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		return nil
+	}
+	if key == nil {
+		return nil
+	}
+	if err := storage.UnlockWithKey(key); err != nil {
+		// The passphrase was changed or the key file restored since
+		vault.ClearSession(storage.Dir())
+	}
+	return nil
+}
+
+// requireUnlocked asks for the passphrase if encrypted notes are locked
+func requireUnlocked() error {
+	if !storage.IsLocked() {
+		return nil
+	}
+
+	passphrase, err := readPassphrase("Passphrase: ")
+	if err != nil {
+		return err
+	}
+	if _, err := storage.Unlock(passphrase); err != nil {
+		return fmt.Errorf("failed to unlock notes: %w", err)
+	}
+	return nil
+}
+
+// stdin is shared by the passphrase prompts so input piped to several of
+// them is not lost to buffering
+var stdin = bufio.NewReader(os.Stdin)
+
+// readPassphrase asks for a passphrase without echoing it on a terminal, or
+// reads a line from standard input otherwise
+func readPassphrase(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, prompt)
+		passphrase, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read passphrase: %w", err)
+		}
+		return string(passphrase), nil
+	}
+
+	line, err := stdin.ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func init() {
+	unlockCmd.Flags().Duration("for", vault.DefaultSessionTimeout, "How long the notes stay unlocked")
+	rootCmd.AddCommand(unlockCmd)
+}
+
+// printLockedHint mentions the encrypted notes hidden while locked
+func printLockedHint() {
+	if locked := storage.LockedNotes(); len(locked) > 0 {
+		color.Yellow("🔒 %d encrypted notes are hidden, run 'gonotes unlock' to show them.", len(locked))
+	}
+}
@@ -2,32 +2,34 @@ package cmd
 
 import (
 	"fmt"
-	"strconv"
 	"strings"
 
 	"github.com/fatih/color"
 	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+	"github.com/midimurphdesigns/go-lang-notes/internal/output"
 	"github.com/spf13/cobra"
 )
 
 var viewCmd = &cobra.Command{
 	Use:   "view [id]",
 	Short: "View a specific note",
-	Long: `View a specific note by its ID.
+	Long: `View a specific note by its ID or title. The start of a title is enough if
+only one note has it, and letters in order such as "gslc" find "Go Slices".
 	
 Examples:
   gonotes view 1
-  gonotes view 5`,
-	Args: cobra.ExactArgs(1),
+  gonotes view "Go Slices"
+  gonotes view slic`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeNoteID,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		id, err := strconv.Atoi(args[0])
+		note, err := resolveNote(args[0])
 		if err != nil {
-			return fmt.Errorf("invalid note ID: %s", args[0])
+			return err
 		}
 
-		note, err := storage.GetNote(id)
-		if err != nil {
-			return fmt.Errorf("failed to get note: %w", err)
+		if structured() {
+			return printResult(output.NewNoteResponse(note))
 		}
 
 		printNoteDetail(note)
@@ -48,6 +50,9 @@ func printNoteDetail(note *note.Note) {
 	if note.IsFavorite {
 		status += "⭐ Favorite "
 	}
+	if note.IsPinned {
+		status += "📌 Pinned "
+	}
 	if status != "" {
 		color.Yellow("Status: %s\n", status)
 	}
@@ -61,12 +66,34 @@ func printNoteDetail(note *note.Note) {
 	color.White("%s\n", note.Content)
 	color.White(strings.Repeat("-", 50) + "\n")
 
-	// Tags
+	// Notebook and tags
+	if note.Notebook != "" {
+		color.Blue("Notebook: %s\n", note.Notebook)
+	}
 	if len(note.Tags) > 0 {
 		tagStr := strings.Join(note.Tags, ", ")
 		color.Green("Tags: %s\n", tagStr)
 	}
 
+	// Properties
+	for _, name := range note.PropertyNames() {
+		color.Yellow("%s: %s\n", name, note.PropertyString(name))
+	}
+
+	// Reminder and due date
+	if note.RemindAt != nil {
+		color.Cyan("Remind: %s\n", note.RemindAt.Format("2006-01-02 15:04"))
+	}
+	if note.DueAt != nil {
+		color.Magenta("Due: %s\n", note.DueAt.Format("2006-01-02 15:04"))
+	}
+
+	// Links and attachments
+	printNoteLinks(note)
+	if len(note.Attachments) > 0 {
+		color.Blue("Attachments: %s\n", strings.Join(note.Attachments, ", "))
+	}
+
 	// Timestamps
 	color.New(color.FgHiBlack).Printf("Created: %s\n", note.CreatedAt.Format("2006-01-02 15:04:05"))
 	color.New(color.FgHiBlack).Printf("Updated: %s\n", note.UpdatedAt.Format("2006-01-02 15:04:05"))
@@ -74,6 +101,26 @@ func printNoteDetail(note *note.Note) {
 	fmt.Println()
 }
 
+func printNoteLinks(n *note.Note) {
+	var links []string
+	for _, id := range n.Links {
+		if linked, err := storage.GetNote(id); err == nil {
+			links = append(links, fmt.Sprintf("[%d] %s", linked.ID, linked.Title))
+		}
+	}
+	if len(links) > 0 {
+		color.Blue("Links: %s\n", strings.Join(links, ", "))
+	}
+
+	var backlinks []string
+	for _, linked := range storage.GetBacklinks(n.ID) {
+		backlinks = append(backlinks, fmt.Sprintf("[%d] %s", linked.ID, linked.Title))
+	}
+	if len(backlinks) > 0 {
+		color.Blue("Linked from: %s\n", strings.Join(backlinks, ", "))
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(viewCmd)
 }
@@ -0,0 +1,257 @@
+// Package backup writes a notes directory to a single .tar.gz archive and
+// restores it. Every archive ends with a manifest recording the format
+// version and the SHA-256 checksum of each file, so damaged or tampered
+// archives are detected before anything is restored.
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+)
+
+// FormatVersion is the version of the archive layout written by Create.
+// Archives with a newer version are refused by Restore.
+const FormatVersion = 1
+
+// Folders of an archive. Notes are stored as notes/<id>.json, attachments as
+// attachments/<id>/<name> and the files of the metadata folder (settings,
+// flashcard state and other indexes) under meta/.
+const (
+	notesPrefix       = "notes/"
+	attachmentsPrefix = "attachments/"
+	metaPrefix        = "meta/"
+	manifestName      = "manifest.json"
+)
+
+// Manifest describes the contents of an archive
+type Manifest struct {
+	Version   int         `json:"version"`
+	CreatedAt time.Time   `json:"created_at"`
+	Notes     int         `json:"notes"`
+	Files     []FileEntry `json:"files"`
+}
+
+// FileEntry is a file of an archive with its checksum
+type FileEntry struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Create writes every note, attachment and metadata file of storage to w as
+// a gzipped tar archive
+func Create(storage *note.Storage, w io.Writer) (*Manifest, error) {
+	gz := gzip.NewWriter(w)
+	archive := tar.NewWriter(gz)
+	now := time.Now()
+
+	manifest := &Manifest{Version: FormatVersion, CreatedAt: now}
+	add := func(name string, data []byte) error {
+		header := &tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(data)),
+			ModTime:  now,
+			Typeflag: tar.TypeReg,
+		}
+		if err := archive.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := archive.Write(data); err != nil {
+			return err
+		}
+
+		sum := sha256.Sum256(data)
+		manifest.Files = append(manifest.Files, FileEntry{Path: name, Size: int64(len(data)), SHA256: hex.EncodeToString(sum[:])})
+		return nil
+	}
+
+	// Notes are copied as saved, so encrypted notes stay encrypted and can
+	// be backed up while locked. Notes deleted while the backup runs, such as
+	// by the web server, are left out.
+	ids := storage.NoteIDs()
+	for _, id := range ids {
+		data, err := storage.NoteFile(id)
+		if errors.Is(err, os.ErrNotExist) || errors.Is(err, note.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read note %d: %w", id, err)
+		}
+		if err := add(notesPrefix+strconv.Itoa(id)+".json", data); err != nil {
+			return nil, fmt.Errorf("failed to write note %d: %w", id, err)
+		}
+		manifest.Notes++
+
+		entries, err := os.ReadDir(storage.AttachmentDir(id))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read attachments of note %d: %w", id, err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			name := entry.Name()
+			data, err := os.ReadFile(storage.AttachmentPath(id, name))
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read attachment %s of note %d: %w", name, id, err)
+			}
+			if err := add(attachmentsPrefix+strconv.Itoa(id)+"/"+name, data); err != nil {
+				return nil, fmt.Errorf("failed to write attachment %s of note %d: %w", name, id, err)
+			}
+		}
+	}
+
+	err := filepath.WalkDir(storage.MetaDir(), func(file string, entry fs.DirEntry, err error) error {
+		if os.IsNotExist(err) {
+			return filepath.SkipDir
+		}
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(storage.MetaDir(), file)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(file)
+		if errors.Is(err, os.ErrNotExist) {
+			// Such as the temporary file of a journal being saved
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return add(metaPrefix+filepath.ToSlash(rel), data)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to back up metadata: %w", err)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := archive.WriteHeader(&tar.Header{Name: manifestName, Mode: 0644, Size: int64(len(data)), ModTime: now, Typeflag: tar.TypeReg}); err != nil {
+		return nil, fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if _, err := archive.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish archive: %w", err)
+	}
+	return manifest, nil
+}
+
+// CreateFile writes a backup archive to a file. The archive is written to a
+// temporary file first so an interrupted backup never leaves a partial
+// archive under the final name.
+func CreateFile(storage *note.Storage, file string) (*Manifest, error) {
+	dir := filepath.Dir(file)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".gonotes-backup-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backup file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	manifest, err := Create(storage, tmp)
+	if err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write backup file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		return nil, fmt.Errorf("failed to write backup file: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// archiveFile is a file read from an archive
+type archiveFile struct {
+	Path string
+	Data []byte
+}
+
+// readArchive calls fn for every file of an archive except the manifest and
+// returns the manifest. Paths that could escape the notes directory are
+// rejected.
+func readArchive(r io.Reader, fn func(f archiveFile) error) (*Manifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a gzip archive: %w", err)
+	}
+	defer gz.Close()
+
+	var manifest *Manifest
+	archive := tar.NewReader(gz)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := path.Clean(header.Name)
+		if name != header.Name || path.IsAbs(name) || name == ".." || len(name) > 2 && name[:3] == "../" {
+			return nil, fmt.Errorf("invalid path in archive: %s", header.Name)
+		}
+
+		data, err := io.ReadAll(archive)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+
+		if name == manifestName {
+			manifest = &Manifest{}
+			if err := json.Unmarshal(data, manifest); err != nil {
+				return nil, fmt.Errorf("invalid manifest: %w", err)
+			}
+			continue
+		}
+
+		if fn != nil {
+			if err := fn(archiveFile{Path: name, Data: data}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("archive has no manifest, it was not written by gonotes backup")
+	}
+	return manifest, nil
+}
@@ -0,0 +1,400 @@
+package backup
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+	"github.com/midimurphdesigns/go-lang-notes/internal/vault"
+)
+
+// Mode is how a backup is restored into a notes directory that already has
+// notes
+type Mode string
+
+const (
+	// ModeMerge adds notes missing from the directory and updates notes whose
+	// backed up version is newer. Nothing is deleted.
+	ModeMerge Mode = "merge"
+	// ModeReplace makes the directory match the backup exactly, deleting
+	// notes that are not in it and restoring the metadata folder
+	ModeReplace Mode = "replace"
+)
+
+// ParseMode checks a restore mode given by name
+func ParseMode(name string) (Mode, error) {
+	switch mode := Mode(strings.ToLower(name)); mode {
+	case ModeMerge, ModeReplace:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown restore mode %q (use merge or replace)", name)
+}
+
+// Archive is a verified backup loaded into memory
+type Archive struct {
+	Manifest *Manifest
+	// notes are the note files as saved, encrypted or not
+	notes       map[int][]byte
+	attachments map[int][]archiveFile
+	meta        []archiveFile
+}
+
+// Open reads and verifies a backup archive
+func Open(file string) (*Archive, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer f.Close()
+
+	return Read(f)
+}
+
+// Read reads a backup archive and verifies it against its manifest: every
+// file must be listed with a matching size and checksum, every listed file
+// must be present and every note must be readable.
+func Read(r io.Reader) (*Archive, error) {
+	archive := &Archive{
+		notes:       make(map[int][]byte),
+		attachments: make(map[int][]archiveFile),
+	}
+	sums := make(map[string]FileEntry)
+
+	manifest, err := readArchive(r, func(f archiveFile) error {
+		if _, duplicate := sums[f.Path]; duplicate {
+			return fmt.Errorf("duplicate file in archive: %s", f.Path)
+		}
+		sum := sha256.Sum256(f.Data)
+		sums[f.Path] = FileEntry{Path: f.Path, Size: int64(len(f.Data)), SHA256: hex.EncodeToString(sum[:])}
+		return archive.add(f)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if manifest.Version > FormatVersion {
+		return nil, fmt.Errorf("backup format version %d is newer than the supported version %d, upgrade gonotes to restore it", manifest.Version, FormatVersion)
+	}
+	if manifest.Version < 1 {
+		return nil, fmt.Errorf("invalid backup format version %d", manifest.Version)
+	}
+
+	for _, entry := range manifest.Files {
+		actual, ok := sums[entry.Path]
+		if !ok {
+			return nil, fmt.Errorf("file missing from archive: %s", entry.Path)
+		}
+		if actual.Size != entry.Size || actual.SHA256 != entry.SHA256 {
+			return nil, fmt.Errorf("checksum mismatch for %s, the archive is damaged", entry.Path)
+		}
+		delete(sums, entry.Path)
+	}
+	for name := range sums {
+		return nil, fmt.Errorf("file not listed in manifest: %s", name)
+	}
+
+	if len(archive.notes) != manifest.Notes {
+		return nil, fmt.Errorf("manifest lists %d notes but the archive has %d", manifest.Notes, len(archive.notes))
+	}
+
+	archive.Manifest = manifest
+	return archive, nil
+}
+
+// add files a file of the archive under notes, attachments or metadata
+func (a *Archive) add(f archiveFile) error {
+	switch {
+	case strings.HasPrefix(f.Path, notesPrefix):
+		name := strings.TrimPrefix(f.Path, notesPrefix)
+		id, err := strconv.Atoi(strings.TrimSuffix(name, ".json"))
+		if err != nil || !strings.HasSuffix(name, ".json") {
+			return fmt.Errorf("unexpected file in archive: %s", f.Path)
+		}
+
+		// Only the ID is checked here, encrypted notes are read on restore
+		var n struct {
+			ID int `json:"id"`
+		}
+		if err := json.Unmarshal(f.Data, &n); err != nil {
+			return fmt.Errorf("invalid note %s: %w", f.Path, err)
+		}
+		if n.ID != id {
+			return fmt.Errorf("note %s has ID %d", f.Path, n.ID)
+		}
+		a.notes[id] = f.Data
+
+	case strings.HasPrefix(f.Path, attachmentsPrefix):
+		dir, name, ok := strings.Cut(strings.TrimPrefix(f.Path, attachmentsPrefix), "/")
+		id, err := strconv.Atoi(dir)
+		if !ok || err != nil || name == "" || strings.Contains(name, "/") {
+			return fmt.Errorf("unexpected file in archive: %s", f.Path)
+		}
+		a.attachments[id] = append(a.attachments[id], archiveFile{Path: name, Data: f.Data})
+
+	case strings.HasPrefix(f.Path, metaPrefix):
+		a.meta = append(a.meta, archiveFile{Path: strings.TrimPrefix(f.Path, metaPrefix), Data: f.Data})
+
+	default:
+		return fmt.Errorf("unexpected file in archive: %s", f.Path)
+	}
+	return nil
+}
+
+// Notes returns the number of notes in the archive
+func (a *Archive) Notes() int {
+	return len(a.notes)
+}
+
+// Options controls a restore
+type Options struct {
+	Mode Mode
+	// DryRun reports what a restore would change without changing anything
+	DryRun bool
+}
+
+// Result counts the changes made by a restore, or that would be made by a
+// dry run
+type Result struct {
+	Added     int
+	Updated   int
+	Unchanged int
+	// Skipped counts notes kept because the copy in the notes directory is
+	// newer than the backed up one (merge mode only)
+	Skipped int
+	Removed int
+	// Attachments counts the attachment files of added and updated notes
+	Attachments int
+	MetaFiles   int
+}
+
+// Restore restores an archive into storage. The notes it changes are
+// reported to change hooks as one change.
+func Restore(storage *note.Storage, archive *Archive, opts Options) (*Result, error) {
+	var result *Result
+	err := storage.Group(func() error {
+		var err error
+		result, err = restore(storage, archive, opts)
+		return err
+	})
+	return result, err
+}
+
+func restore(storage *note.Storage, archive *Archive, opts Options) (*Result, error) {
+	if opts.Mode == ModeReplace {
+		return replace(storage, archive, opts)
+	}
+
+	// Merging compares notes, so encrypted notes must be readable on both
+	// sides
+	if locked := storage.LockedNotes(); len(locked) > 0 {
+		return nil, fmt.Errorf("%d encrypted notes are locked, unlock them before merging a backup", len(locked))
+	}
+
+	result := &Result{}
+	ids := archive.ids()
+	notes := make(map[int]*note.Note, len(ids))
+	for _, id := range ids {
+		n, err := storage.DecodeNote(archive.notes[id])
+		if errors.Is(err, vault.ErrDecrypt) || errors.Is(err, note.ErrNoEncryption) {
+			return nil, fmt.Errorf("cannot read note %d of the backup, it was encrypted with another passphrase (restore with --mode replace)", id)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read note %d of the backup: %w", id, err)
+		}
+		notes[id] = n
+	}
+
+	var renumber []int
+
+	for _, id := range ids {
+		saved := notes[id]
+		current, err := storage.GetNote(id)
+
+		switch {
+		case err != nil:
+			result.Added++
+			result.Attachments += len(archive.attachments[id])
+			if !opts.DryRun {
+				if err := restoreNote(storage, saved, archive.attachments[id]); err != nil {
+					return result, err
+				}
+			}
+
+		case !current.CreatedAt.Equal(saved.CreatedAt):
+			// The directory has a different note under this ID, so the backed
+			// up one is added under a new ID instead of overwriting it. This
+			// happens after the other notes so it cannot take one of their IDs.
+			result.Added++
+			result.Attachments += len(archive.attachments[id])
+			renumber = append(renumber, id)
+
+		case sameNote(current, saved):
+			result.Unchanged++
+
+		case !saved.UpdatedAt.After(current.UpdatedAt):
+			result.Skipped++
+
+		default:
+			result.Updated++
+			result.Attachments += len(archive.attachments[id])
+			if !opts.DryRun {
+				if err := restoreNote(storage, saved, archive.attachments[id]); err != nil {
+					return result, err
+				}
+			}
+		}
+	}
+
+	if !opts.DryRun {
+		for _, id := range renumber {
+			if err := importNote(storage, notes[id], archive.attachments[id]); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// replace makes storage match the archive. The metadata folder, including
+// the encryption key file, is restored first, then the note files are copied
+// as saved, so encrypted notes need not be decrypted and are readable with
+// the passphrase they were backed up with.
+func replace(storage *note.Storage, archive *Archive, opts Options) (*Result, error) {
+	result := &Result{MetaFiles: len(archive.meta)}
+
+	if !opts.DryRun {
+		if err := restoreMeta(storage.MetaDir(), archive.meta); err != nil {
+			return result, err
+		}
+		if err := storage.Reload(); err != nil {
+			return result, fmt.Errorf("failed to reload notes: %w", err)
+		}
+	}
+
+	for _, id := range archive.ids() {
+		data := archive.notes[id]
+		current, err := storage.NoteFile(id)
+
+		switch {
+		case err != nil:
+			result.Added++
+		case bytes.Equal(current, data):
+			result.Unchanged++
+			continue
+		default:
+			result.Updated++
+		}
+
+		result.Attachments += len(archive.attachments[id])
+		if opts.DryRun {
+			continue
+		}
+		if err := writeAttachments(storage, id, archive.attachments[id]); err != nil {
+			return result, err
+		}
+		if err := storage.PutNoteFile(data); err != nil {
+			return result, fmt.Errorf("failed to restore note %d: %w", id, err)
+		}
+	}
+
+	for _, id := range storage.NoteIDs() {
+		if _, ok := archive.notes[id]; ok {
+			continue
+		}
+		result.Removed++
+		if !opts.DryRun {
+			if err := storage.DeleteNote(id); err != nil {
+				return result, fmt.Errorf("failed to remove note %d: %w", id, err)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// ids returns the IDs of the notes of the archive, sorted
+func (a *Archive) ids() []int {
+	ids := make([]int, 0, len(a.notes))
+	for id := range a.notes {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// restoreNote writes a backed up note and its attachments under its own ID
+func restoreNote(storage *note.Storage, n *note.Note, attachments []archiveFile) error {
+	if err := writeAttachments(storage, n.ID, attachments); err != nil {
+		return err
+	}
+	if _, err := storage.PutNote(n); err != nil {
+		return fmt.Errorf("failed to restore note %d: %w", n.ID, err)
+	}
+	return nil
+}
+
+// importNote adds a backed up note and its attachments under a new ID
+func importNote(storage *note.Storage, n *note.Note, attachments []archiveFile) error {
+	imported, err := storage.ImportNote(n)
+	if err != nil {
+		return fmt.Errorf("failed to restore note %d: %w", n.ID, err)
+	}
+	return writeAttachments(storage, imported.ID, attachments)
+}
+
+// writeAttachments writes the attachment files of a note, keeping their names
+func writeAttachments(storage *note.Storage, id int, attachments []archiveFile) error {
+	for _, f := range attachments {
+		file := storage.AttachmentPath(id, f.Path)
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return fmt.Errorf("failed to restore attachment %s of note %d: %w", f.Path, id, err)
+		}
+		if err := os.WriteFile(file, f.Data, 0644); err != nil {
+			return fmt.Errorf("failed to restore attachment %s of note %d: %w", f.Path, id, err)
+		}
+	}
+	return nil
+}
+
+// restoreMeta replaces the contents of the metadata folder with the backed up
+// files
+func restoreMeta(dir string, files []archiveFile) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to clear %s: %w", dir, err)
+	}
+
+	for _, f := range files {
+		file := filepath.Join(dir, filepath.FromSlash(f.Path))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", f.Path, err)
+		}
+		if err := os.WriteFile(file, f.Data, 0644); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", f.Path, err)
+		}
+	}
+	return nil
+}
+
+// sameNote reports whether two versions of a note are identical
+func sameNote(a, b *note.Note) bool {
+	left, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	right, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(left, right)
+}
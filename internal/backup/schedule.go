@@ -0,0 +1,169 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+)
+
+// DefaultInterval is how often scheduled backups are written
+const DefaultInterval = 24 * time.Hour
+
+// DefaultKeep is how many scheduled backups are kept
+const DefaultKeep = 7
+
+// filePrefix starts the names of scheduled backup files
+const filePrefix = "gonotes-"
+
+// FileName returns the name of a scheduled backup written at t, such as
+// gonotes-20240315-090000.tar.gz. Names sort in the order they were written.
+func FileName(t time.Time) string {
+	return filePrefix + t.Format("20060102-150405") + ".tar.gz"
+}
+
+// Scheduler periodically writes backups to a folder, deleting the oldest
+// ones so only the most recent are kept
+type Scheduler struct {
+	storage  *note.Storage
+	dir      string
+	interval time.Duration
+	keep     int
+
+	// OnBackup is called with the path and manifest of every backup written
+	OnBackup func(file string, manifest *Manifest)
+
+	// OnError is called for every error encountered while backing up
+	OnError func(err error)
+}
+
+// NewScheduler creates a scheduler writing backups of storage to dir and
+// keeping the given number of them
+func NewScheduler(storage *note.Storage, dir string, interval time.Duration, keep int) *Scheduler {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	if keep <= 0 {
+		keep = DefaultKeep
+	}
+
+	return &Scheduler{
+		storage:  storage,
+		dir:      dir,
+		interval: interval,
+		keep:     keep,
+	}
+}
+
+// Run writes a backup every interval until the context is cancelled. The
+// first backup is written after one interval, unless the latest backup in
+// the folder is older than that.
+func (s *Scheduler) Run(ctx context.Context) error {
+	wait := s.interval
+	if latest, err := s.latest(); err == nil {
+		wait = time.Until(latest.Add(s.interval))
+	} else if !os.IsNotExist(err) {
+		s.reportError(err)
+	}
+	if wait < 0 {
+		wait = 0
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-timer.C:
+			if _, err := s.Backup(now); err != nil {
+				s.reportError(err)
+			}
+			timer.Reset(s.interval)
+		}
+	}
+}
+
+// Backup writes a backup named after now and removes old backups beyond the
+// number to keep. It returns the path of the new backup.
+func (s *Scheduler) Backup(now time.Time) (string, error) {
+	file := filepath.Join(s.dir, FileName(now))
+	manifest, err := CreateFile(s.storage, file)
+	if err != nil {
+		return "", err
+	}
+	if s.OnBackup != nil {
+		s.OnBackup(file, manifest)
+	}
+
+	if err := s.rotate(); err != nil {
+		return file, fmt.Errorf("failed to remove old backups: %w", err)
+	}
+	return file, nil
+}
+
+// rotate deletes the oldest scheduled backups beyond the number to keep.
+// Other files in the folder are left alone.
+func (s *Scheduler) rotate() error {
+	files, err := s.list()
+	if err != nil {
+		return err
+	}
+
+	for len(files) > s.keep {
+		if err := os.Remove(filepath.Join(s.dir, files[0])); err != nil {
+			return err
+		}
+		files = files[1:]
+	}
+	return nil
+}
+
+// latest returns the time the most recent scheduled backup was written
+func (s *Scheduler) latest() (time.Time, error) {
+	files, err := s.list()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(files) == 0 {
+		return time.Time{}, os.ErrNotExist
+	}
+
+	info, err := os.Stat(filepath.Join(s.dir, files[len(files)-1]))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// list returns the names of the scheduled backups in the folder, oldest first
+func (s *Scheduler) list() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, ".tar.gz") {
+			continue
+		}
+		files = append(files, name)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// reportError passes err to OnError if it is set
+func (s *Scheduler) reportError(err error) {
+	if s.OnError != nil {
+		s.OnError(err)
+	}
+}
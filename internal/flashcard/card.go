@@ -0,0 +1,148 @@
+package flashcard
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+)
+
+// Kinds of cards that can be derived from a note
+const (
+	KindQA    = "qa"
+	KindCloze = "cloze"
+)
+
+// Card is a single flashcard derived from a note
+type Card struct {
+	ID     string `json:"id"`
+	NoteID int    `json:"note_id"`
+	Kind   string `json:"kind"`
+	Front  string `json:"front"`
+	Back   string `json:"back"`
+}
+
+// clozePattern matches {{c1::answer}} and {{c1::answer::hint}}
+var clozePattern = regexp.MustCompile(`\{\{c(\d+)::(.*?)(?:::(.*?))?\}\}`)
+
+// Extract derives all flashcards from a note's content. Question and answer
+// blocks are written as
+//
+//	Q: What is the zero value of a slice?
+//	A: nil
+//
+// and cloze deletions as "A {{c1::slice}} is a view into an array".
+func Extract(n *note.Note) []Card {
+	cards := extractQA(n)
+	cards = append(cards, extractCloze(n)...)
+	return cards
+}
+
+// extractQA finds Q:/A: blocks. Questions and answers may span several lines;
+// an answer ends at a blank line or the next question.
+func extractQA(n *note.Note) []Card {
+	var (
+		cards    []Card
+		question []string
+		answer   []string
+		inAnswer bool
+	)
+
+	flush := func() {
+		if len(question) > 0 && len(answer) > 0 {
+			front := strings.TrimSpace(strings.Join(question, "\n"))
+			back := strings.TrimSpace(strings.Join(answer, "\n"))
+			cards = append(cards, newCard(n.ID, KindQA, front, back))
+		}
+		question, answer, inAnswer = nil, nil, false
+	}
+
+	for _, line := range strings.Split(n.Content, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, "Q:"):
+			flush()
+			question = append(question, strings.TrimSpace(strings.TrimPrefix(trimmed, "Q:")))
+		case strings.HasPrefix(trimmed, "A:") && len(question) > 0:
+			inAnswer = true
+			answer = append(answer, strings.TrimSpace(strings.TrimPrefix(trimmed, "A:")))
+		case trimmed == "":
+			if inAnswer {
+				flush()
+			}
+		case inAnswer:
+			answer = append(answer, line)
+		case len(question) > 0:
+			question = append(question, line)
+		}
+	}
+	flush()
+
+	return cards
+}
+
+// extractCloze creates one card per cloze number in every paragraph
+func extractCloze(n *note.Note) []Card {
+	var cards []Card
+
+	for _, paragraph := range strings.Split(n.Content, "\n\n") {
+		matches := clozePattern.FindAllStringSubmatch(paragraph, -1)
+		if len(matches) == 0 {
+			continue
+		}
+
+		numbers := make(map[int]bool)
+		for _, match := range matches {
+			number, _ := strconv.Atoi(match[1])
+			numbers[number] = true
+		}
+
+		sorted := make([]int, 0, len(numbers))
+		for number := range numbers {
+			sorted = append(sorted, number)
+		}
+		sort.Ints(sorted)
+
+		back := strings.TrimSpace(clozePattern.ReplaceAllString(paragraph, "$2"))
+		for _, number := range sorted {
+			front := strings.TrimSpace(hideCloze(paragraph, number))
+			cards = append(cards, newCard(n.ID, KindCloze, front, back))
+		}
+	}
+
+	return cards
+}
+
+// hideCloze replaces deletions with the given number by a placeholder and
+// reveals all other deletions
+func hideCloze(text string, number int) string {
+	return clozePattern.ReplaceAllStringFunc(text, func(match string) string {
+		parts := clozePattern.FindStringSubmatch(match)
+		if n, _ := strconv.Atoi(parts[1]); n != number {
+			return parts[2]
+		}
+		if parts[3] != "" {
+			return "[" + parts[3] + "]"
+		}
+		return "[...]"
+	})
+}
+
+// newCard creates a card whose ID is stable as long as the note ID and the
+// card's front do not change
+func newCard(noteID int, kind, front, back string) Card {
+	sum := sha1.Sum([]byte(kind + "\x00" + front))
+	return Card{
+		ID:     fmt.Sprintf("%d-%s", noteID, hex.EncodeToString(sum[:4])),
+		NoteID: noteID,
+		Kind:   kind,
+		Front:  front,
+		Back:   back,
+	}
+}
@@ -0,0 +1,189 @@
+package flashcard
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+)
+
+// ErrNotFound is returned for cards that no note defines
+var ErrNotFound = errors.New("not found")
+
+// stateFile is the name of the review state file inside the meta directory
+const stateFile = "cards.json"
+
+// Deck combines the cards derived from notes with their persisted review state
+type Deck struct {
+	mu     sync.Mutex
+	path   string
+	cards  map[string]Card
+	states map[string]State
+}
+
+// OpenDeck loads the review state stored alongside the notes and derives the
+// cards from all active notes
+func OpenDeck(storage *note.Storage) (*Deck, error) {
+	deck := &Deck{
+		path:   filepath.Join(storage.MetaDir(), stateFile),
+		cards:  make(map[string]Card),
+		states: make(map[string]State),
+	}
+
+	data, err := os.ReadFile(deck.path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("failed to read review state: %w", err)
+	default:
+		if err := json.Unmarshal(data, &deck.states); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal review state: %w", err)
+		}
+	}
+
+	deck.Refresh(storage.GetActiveNotes())
+	return deck, nil
+}
+
+// Refresh re-derives the cards from the given notes. Review state of cards
+// that disappear is kept so it is restored if the card comes back.
+func (d *Deck) Refresh(notes []*note.Note) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.cards = make(map[string]Card)
+	for _, n := range notes {
+		for _, card := range Extract(n) {
+			d.cards[card.ID] = card
+		}
+	}
+}
+
+// Card returns the card with the given ID and its state
+func (d *Deck) Card(id string) (Card, State, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	card, exists := d.cards[id]
+	if !exists {
+		return Card{}, State{}, fmt.Errorf("card %s %w", id, ErrNotFound)
+	}
+	return card, d.state(id), nil
+}
+
+// Cards returns all cards, ordered by note and position
+func (d *Deck) Cards() []Card {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	cards := make([]Card, 0, len(d.cards))
+	for _, card := range d.cards {
+		cards = append(cards, card)
+	}
+
+	sort.Slice(cards, func(i, j int) bool {
+		if cards[i].NoteID != cards[j].NoteID {
+			return cards[i].NoteID < cards[j].NoteID
+		}
+		return cards[i].ID < cards[j].ID
+	})
+
+	return cards
+}
+
+// Due returns the cards due for review at now, most overdue first. New cards
+// come after cards that are already being learned.
+func (d *Deck) Due(now time.Time) []Card {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var due []Card
+	for id, card := range d.cards {
+		if !d.state(id).Due.After(now) {
+			due = append(due, card)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		si, sj := d.state(due[i].ID), d.state(due[j].ID)
+		if si.IsNew() != sj.IsNew() {
+			return !si.IsNew()
+		}
+		if !si.Due.Equal(sj.Due) {
+			return si.Due.Before(sj.Due)
+		}
+		return due[i].ID < due[j].ID
+	})
+
+	return due
+}
+
+// Next returns the next card to review at now, if any
+func (d *Deck) Next(now time.Time) (Card, bool) {
+	due := d.Due(now)
+	if len(due) == 0 {
+		return Card{}, false
+	}
+	return due[0], true
+}
+
+// Grade records a review of a card and persists the new state
+func (d *Deck) Grade(id string, grade int, now time.Time) (State, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, exists := d.cards[id]; !exists {
+		return State{}, fmt.Errorf("card %s %w", id, ErrNotFound)
+	}
+
+	state, err := d.state(id).Grade(grade, now)
+	if err != nil {
+		return State{}, err
+	}
+
+	// The state in memory only changes once it is saved
+	previous, reviewed := d.states[id]
+	d.states[id] = state
+	if err := d.save(); err != nil {
+		if reviewed {
+			d.states[id] = previous
+		} else {
+			delete(d.states, id)
+		}
+		return State{}, err
+	}
+
+	return state, nil
+}
+
+// state returns the stored state of a card or a new state; callers must hold d.mu
+func (d *Deck) state(id string) State {
+	if state, exists := d.states[id]; exists {
+		return state
+	}
+	return NewState()
+}
+
+// save writes the review state to disk; callers must hold d.mu
+func (d *Deck) save() error {
+	data, err := json.MarshalIndent(d.states, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal review state: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(d.path), 0755); err != nil {
+		return fmt.Errorf("failed to create meta directory: %w", err)
+	}
+
+	if err := os.WriteFile(d.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write review state: %w", err)
+	}
+
+	return nil
+}
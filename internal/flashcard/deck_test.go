@@ -0,0 +1,63 @@
+package flashcard
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+)
+
+func TestDeckGrade(t *testing.T) {
+	storage, err := note.NewStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := storage.CreateNote("Slices", "Q: Zero value of a slice?\nA: nil", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	deck, err := OpenDeck(storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cards := deck.Cards()
+	if len(cards) != 1 {
+		t.Fatalf("deck has %d cards, want 1", len(cards))
+	}
+	id := cards[0].ID
+	now := time.Date(2024, time.May, 15, 9, 0, 0, 0, time.UTC)
+
+	if _, err := deck.Grade("missing", GradeGood, now); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Grade() of a missing card error = %v, want ErrNotFound", err)
+	}
+
+	graded, err := deck.Grade(id, GradeGood, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The state is saved and read back by a new deck
+	reopened, err := OpenDeck(storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, state, err := reopened.Card(id); err != nil || state.Reviews != 1 || !state.Due.Equal(graded.Due) {
+		t.Errorf("reopened state = %+v, %v, want one review due %v", state, err, graded.Due)
+	}
+
+	// A failed save leaves the state in memory as it was
+	blocker := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	deck.path = filepath.Join(blocker, stateFile)
+	if _, err := deck.Grade(id, GradeWrong, now.AddDate(0, 0, 1)); err == nil || errors.Is(err, ErrNotFound) {
+		t.Fatalf("Grade() with an unwritable state file error = %v, want a save error", err)
+	}
+	if _, state, _ := deck.Card(id); state != graded {
+		t.Errorf("state after a failed save = %+v, want %+v", state, graded)
+	}
+}
@@ -0,0 +1,87 @@
+package flashcard
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Grades used by the SM-2 algorithm, from complete blackout to perfect recall
+const (
+	GradeBlackout  = 0
+	GradeWrong     = 1
+	GradeHard      = 2
+	GradeDifficult = 3
+	GradeGood      = 4
+	GradeEasy      = 5
+)
+
+const (
+	defaultEase = 2.5
+	minimumEase = 1.3
+)
+
+// State is the SM-2 scheduling state of a single card
+type State struct {
+	Ease         float64   `json:"ease"`
+	Interval     int       `json:"interval"`
+	Repetitions  int       `json:"repetitions"`
+	Due          time.Time `json:"due"`
+	LastReviewed time.Time `json:"last_reviewed,omitempty"`
+	Reviews      int       `json:"reviews"`
+	Lapses       int       `json:"lapses"`
+}
+
+// NewState returns the state of a card that has never been reviewed; it is
+// due immediately
+func NewState() State {
+	return State{Ease: defaultEase}
+}
+
+// IsNew reports whether the card has never been reviewed
+func (s State) IsNew() bool {
+	return s.Reviews == 0
+}
+
+// Grade applies a review graded 0-5 at now following the SM-2 algorithm and
+// returns the updated state
+func (s State) Grade(grade int, now time.Time) (State, error) {
+	if grade < GradeBlackout || grade > GradeEasy {
+		return s, fmt.Errorf("grade must be between %d and %d", GradeBlackout, GradeEasy)
+	}
+
+	if s.Ease == 0 {
+		s.Ease = defaultEase
+	}
+
+	if grade < GradeDifficult {
+		// Failed recall: start over, but keep the ease factor
+		if s.Repetitions > 0 {
+			s.Lapses++
+		}
+		s.Repetitions = 0
+		s.Interval = 1
+	} else {
+		switch s.Repetitions {
+		case 0:
+			s.Interval = 1
+		case 1:
+			s.Interval = 6
+		default:
+			s.Interval = int(math.Round(float64(s.Interval) * s.Ease))
+		}
+		s.Repetitions++
+
+		q := float64(grade)
+		s.Ease += 0.1 - (5-q)*(0.08+(5-q)*0.02)
+		if s.Ease < minimumEase {
+			s.Ease = minimumEase
+		}
+	}
+
+	s.Reviews++
+	s.LastReviewed = now
+	s.Due = now.AddDate(0, 0, s.Interval)
+
+	return s, nil
+}
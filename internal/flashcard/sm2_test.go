@@ -0,0 +1,117 @@
+package flashcard
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestGradeProgression(t *testing.T) {
+	now := time.Date(2024, time.May, 15, 9, 0, 0, 0, time.UTC)
+
+	// Perfect recalls raise the ease by 0.1 each time, and the interval goes
+	// 1, 6, then round(previous interval * ease before the review)
+	tests := []struct {
+		grade    int
+		interval int
+		ease     float64
+	}{
+		{grade: GradeEasy, interval: 1, ease: 2.6},
+		{grade: GradeEasy, interval: 6, ease: 2.7},
+		{grade: GradeEasy, interval: 16, ease: 2.8},        // round(6 * 2.7)
+		{grade: GradeGood, interval: 45, ease: 2.8},        // round(16 * 2.8)
+		{grade: GradeDifficult, interval: 126, ease: 2.66}, // round(45 * 2.8)
+	}
+
+	state := NewState()
+	for i, tt := range tests {
+		var err error
+		state, err = state.Grade(tt.grade, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state.Interval != tt.interval || math.Abs(state.Ease-tt.ease) > 1e-9 {
+			t.Errorf("review %d: interval %d, ease %.2f, want %d, %.2f", i+1, state.Interval, state.Ease, tt.interval, tt.ease)
+		}
+		if state.Repetitions != i+1 || state.Reviews != i+1 {
+			t.Errorf("review %d: repetitions %d, reviews %d", i+1, state.Repetitions, state.Reviews)
+		}
+		if want := now.AddDate(0, 0, tt.interval); !state.Due.Equal(want) || !state.LastReviewed.Equal(now) {
+			t.Errorf("review %d: due %v, want %v", i+1, state.Due, want)
+		}
+	}
+}
+
+func TestGradeLapse(t *testing.T) {
+	now := time.Date(2024, time.May, 15, 9, 0, 0, 0, time.UTC)
+	learned := State{Ease: 2.4, Interval: 20, Repetitions: 4, Reviews: 4}
+
+	for _, grade := range []int{GradeBlackout, GradeWrong, GradeHard} {
+		state, err := learned.Grade(grade, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state.Repetitions != 0 || state.Interval != 1 || state.Lapses != 1 || state.Reviews != 5 {
+			t.Errorf("grade %d: repetitions %d, interval %d, lapses %d, reviews %d", grade, state.Repetitions, state.Interval, state.Lapses, state.Reviews)
+		}
+		if state.Ease != learned.Ease {
+			t.Errorf("grade %d: ease %.2f, want it kept at %.2f", grade, state.Ease, learned.Ease)
+		}
+		if want := now.AddDate(0, 0, 1); !state.Due.Equal(want) {
+			t.Errorf("grade %d: due %v, want %v", grade, state.Due, want)
+		}
+
+		// Relearning starts over at 1 and 6 days
+		state, _ = state.Grade(GradeGood, now)
+		state, _ = state.Grade(GradeGood, now)
+		if state.Interval != 6 {
+			t.Errorf("grade %d: interval after relearning %d, want 6", grade, state.Interval)
+		}
+	}
+
+	// Failing a new card is not a lapse
+	state, err := NewState().Grade(GradeWrong, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Lapses != 0 || state.Ease != defaultEase {
+		t.Errorf("new card failed: lapses %d, ease %.2f", state.Lapses, state.Ease)
+	}
+}
+
+func TestGradeMinimumEase(t *testing.T) {
+	now := time.Date(2024, time.May, 15, 9, 0, 0, 0, time.UTC)
+
+	// Each barely passing recall lowers the ease by 0.14 down to 1.3
+	state := NewState()
+	for i := 0; i < 20; i++ {
+		var err error
+		state, err = state.Grade(GradeDifficult, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state.Ease < minimumEase {
+			t.Fatalf("review %d: ease %.2f below the minimum", i+1, state.Ease)
+		}
+	}
+	if state.Ease != minimumEase {
+		t.Errorf("ease %.2f, want %.2f", state.Ease, minimumEase)
+	}
+
+	// A state saved without an ease starts from the default
+	state, err := State{}.Grade(GradeEasy, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(state.Ease-2.6) > 1e-9 {
+		t.Errorf("ease from a zero state %.2f, want 2.60", state.Ease)
+	}
+}
+
+func TestGradeInvalid(t *testing.T) {
+	for _, grade := range []int{-1, 6} {
+		if _, err := NewState().Grade(grade, time.Now()); err == nil {
+			t.Errorf("Grade(%d) = nil error, want an error", grade)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+// Package gitsync keeps a notes directory in a git repository: it commits
+// changes as they are saved and synchronizes with a remote by pulling,
+// rebasing and pushing. It runs the git command line tool, so git must be
+// installed.
+package gitsync
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+)
+
+// ErrNotRepository is returned by Open when the notes directory is not inside
+// a git repository
+var ErrNotRepository = errors.New("notes directory is not in a git repository (run gonotes sync setup)")
+
+// Repo is the git repository holding a notes directory. The notes directory
+// may be the root of the repository or a folder inside it; commits only ever
+// include files below the notes directory.
+type Repo struct {
+	dir string
+	env []string
+}
+
+// Open returns the repository holding dir
+func Open(dir string) (*Repo, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("git is not installed: %w", err)
+	}
+
+	r := &Repo{dir: dir}
+	inside, err := r.git("rev-parse", "--is-inside-work-tree")
+	if err != nil || inside != "true" {
+		return nil, ErrNotRepository
+	}
+
+	r.env = identityEnv(r)
+	return r, nil
+}
+
+// Init returns the repository holding dir, creating a repository in dir if
+// there is none yet
+func Init(dir string) (*Repo, bool, error) {
+	r, err := Open(dir)
+	if err == nil {
+		return r, false, nil
+	}
+	if !errors.Is(err, ErrNotRepository) {
+		return nil, false, err
+	}
+
+	if _, err := (&Repo{dir: dir}).git("init", "--quiet"); err != nil {
+		return nil, false, fmt.Errorf("failed to create repository: %w", err)
+	}
+
+	r, err = Open(dir)
+	return r, true, err
+}
+
+// identityEnv returns a fallback author for commits when git has no user
+// configured, so committing never fails on a fresh machine
+func identityEnv(r *Repo) []string {
+	if email, _ := r.git("config", "user.email"); email != "" {
+		return nil
+	}
+	return []string{
+		"GIT_AUTHOR_NAME=gonotes", "GIT_AUTHOR_EMAIL=gonotes@localhost",
+		"GIT_COMMITTER_NAME=gonotes", "GIT_COMMITTER_EMAIL=gonotes@localhost",
+	}
+}
+
+// git runs a git command in the notes directory and returns its trimmed
+// output. Errors include what git printed on stderr.
+func (r *Repo) git(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.dir
+	// Never wait for credentials or an editor, there is nobody to answer
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_EDITOR=true")
+	cmd.Env = append(cmd.Env, r.env...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = strings.TrimSpace(stdout.String())
+		}
+		if message == "" {
+			message = err.Error()
+		}
+		return "", fmt.Errorf("git %s: %s", args[0], message)
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// notesPaths are the git arguments limiting a command to the notes
+// directory, leaving out its meta directory. The meta directory holds state
+// of this copy of the notes, such as the undo journal, review state and sync
+// peers, which would conflict between machines and keep the contents of
+// deleted notes in the history.
+var notesPaths = []string{"--", ".", ":(exclude)" + note.MetaDirName}
+
+// Changes returns the number of files below the notes directory with
+// uncommitted changes
+func (r *Repo) Changes() (int, error) {
+	status, err := r.git(append([]string{"status", "--porcelain", "--untracked-files=all"}, notesPaths...)...)
+	if err != nil || status == "" {
+		return 0, err
+	}
+	return len(strings.Split(status, "\n")), nil
+}
+
+// CommitAll commits every change below the notes directory, including new
+// and deleted files, and reports whether there was anything to commit.
+// Changes elsewhere in the repository and in the meta directory are left
+// alone.
+func (r *Repo) CommitAll(message string) (bool, error) {
+	if err := r.untrackMeta(); err != nil {
+		return false, err
+	}
+
+	// Adding with the exclude fails if the meta directory is in .gitignore,
+	// so it is taken out again instead
+	if _, err := r.git("add", "--all", "--", "."); err != nil {
+		return false, err
+	}
+	if _, err := r.git("reset", "--quiet", "--", note.MetaDirName); err != nil {
+		return false, err
+	}
+
+	// diff --quiet fails when there are staged changes
+	if _, err := r.git(append([]string{"diff", "--cached", "--quiet"}, notesPaths...)...); err == nil {
+		return false, nil
+	}
+
+	if _, err := r.git(append([]string{"commit", "--quiet", "--no-verify", "-m", message}, notesPaths...)...); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// untrackMeta removes the meta directory from the repository, keeping its
+// files, if an earlier version of gonotes committed it. This waits while
+// other changes are staged, since they would end up in the same commit.
+func (r *Repo) untrackMeta() error {
+	tracked, err := r.git("ls-files", "--", note.MetaDirName)
+	if err != nil || tracked == "" {
+		return err
+	}
+	if staged, err := r.git("diff", "--cached", "--name-only"); err != nil || staged != "" {
+		return err
+	}
+
+	if _, err := r.git("rm", "-r", "--cached", "--quiet", "--", note.MetaDirName); err != nil {
+		return fmt.Errorf("failed to stop tracking %s: %w", note.MetaDirName, err)
+	}
+	if _, err := r.git("commit", "--quiet", "--no-verify", "-m", "Stop tracking "+note.MetaDirName); err != nil {
+		return fmt.Errorf("failed to stop tracking %s: %w", note.MetaDirName, err)
+	}
+	return nil
+}
+
+// IgnoreMeta adds the meta directory to the .gitignore file of the notes
+// directory, so git commands run by hand leave it out as well, and reports
+// whether the file changed
+func (r *Repo) IgnoreMeta() (bool, error) {
+	path := filepath.Join(r.dir, ".gitignore")
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, fmt.Errorf("failed to read .gitignore: %w", err)
+	}
+
+	entry := "/" + note.MetaDirName + "/"
+	for _, line := range strings.Split(string(data), "\n") {
+		switch strings.TrimSpace(line) {
+		case entry, note.MetaDirName, note.MetaDirName + "/", "/" + note.MetaDirName:
+			return false, nil
+		}
+	}
+
+	if len(data) > 0 && !bytes.HasSuffix(data, []byte("\n")) {
+		data = append(data, '\n')
+	}
+	data = append(data, entry+"\n"...)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return false, fmt.Errorf("failed to write .gitignore: %w", err)
+	}
+	return true, nil
+}
+
+// Branch returns the name of the current branch
+func (r *Repo) Branch() (string, error) {
+	branch, err := r.git("symbolic-ref", "--short", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("not on a branch: %w", err)
+	}
+	return branch, nil
+}
+
+// RemoteURL returns the URL of a remote, or "" if it is not configured
+func (r *Repo) RemoteURL(remote string) string {
+	url, err := r.git("remote", "get-url", remote)
+	if err != nil {
+		return ""
+	}
+	return url
+}
+
+// SetRemote adds a remote or changes its URL
+func (r *Repo) SetRemote(remote, url string) error {
+	if r.RemoteURL(remote) == "" {
+		_, err := r.git("remote", "add", remote, url)
+		return err
+	}
+	_, err := r.git("remote", "set-url", remote, url)
+	return err
+}
+
+// AutoCommit commits every change saved through storage, using the change
+// description as commit message. Commit errors are passed to onError and
+// never undo the change itself.
+func AutoCommit(storage *note.Storage, repo *Repo, onError func(err error)) {
+	storage.OnChange(func(change note.Change) {
+		if _, err := repo.CommitAll(change.Message()); err != nil && onError != nil {
+			onError(fmt.Errorf("failed to commit %q: %w", change.Message(), err))
+		}
+	})
+}
@@ -0,0 +1,313 @@
+package gitsync
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+)
+
+// DefaultRemote is the remote synchronized with when none is given
+const DefaultRemote = "origin"
+
+// Sides of a conflict to keep when resolving it
+const (
+	PreferLocal  = "local"
+	PreferRemote = "remote"
+)
+
+// SyncOptions controls a sync
+type SyncOptions struct {
+	Remote string
+	// Prefer resolves conflicts by keeping the local or the remote version of
+	// each conflicting file as a whole. When empty, a conflict stops the sync
+	// and leaves the notes directory as it was.
+	Prefer string
+}
+
+// SyncResult reports what a sync did
+type SyncResult struct {
+	Branch string
+	// Committed is true when uncommitted local changes were committed first
+	Committed bool
+	Pulled    int
+	Pushed    int
+	// Resolved lists the conflicts resolved with SyncOptions.Prefer
+	Resolved []Conflict
+}
+
+// Conflict is a file changed both locally and on the remote
+type Conflict struct {
+	Path string `json:"path"`
+	// NoteID is the note the file belongs to, or 0 for other files
+	NoteID int `json:"note_id,omitempty"`
+}
+
+// ConflictError is returned when a sync stops because of conflicts
+type ConflictError struct {
+	Conflicts []Conflict
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("sync stopped by conflicts in %d files", len(e.Conflicts))
+}
+
+// Sync commits local changes, pulls the branch from the remote, rebases the
+// local commits onto it and pushes the result. If the rebase runs into
+// conflicts and no side is preferred, it is aborted so the notes directory
+// keeps its local state, and a *ConflictError lists the conflicting files.
+func (r *Repo) Sync(opts SyncOptions) (*SyncResult, error) {
+	remote := opts.Remote
+	if remote == "" {
+		remote = DefaultRemote
+	}
+	if opts.Prefer != "" && opts.Prefer != PreferLocal && opts.Prefer != PreferRemote {
+		return nil, fmt.Errorf("unknown side %q (use %s or %s)", opts.Prefer, PreferLocal, PreferRemote)
+	}
+	if r.RemoteURL(remote) == "" {
+		return nil, fmt.Errorf("no remote %q configured (run gonotes sync setup <url>)", remote)
+	}
+	if r.rebasing() {
+		return nil, fmt.Errorf("a rebase is in progress in the repository, finish or abort it first")
+	}
+
+	branch, err := r.Branch()
+	if err != nil {
+		return nil, err
+	}
+	result := &SyncResult{Branch: branch}
+
+	result.Committed, err = r.CommitAll("Sync local changes")
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit local changes: %w", err)
+	}
+
+	if _, err := r.git("fetch", "--quiet", remote); err != nil {
+		return nil, fmt.Errorf("failed to fetch: %w", err)
+	}
+
+	upstream := remote + "/" + branch
+	if _, err := r.git("rev-parse", "--verify", "--quiet", "refs/remotes/"+upstream); err != nil {
+		// The remote does not have the branch yet, so everything is pushed
+		upstream = ""
+	}
+
+	if _, err := r.git("rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		// Nothing was committed locally yet, so the branch is taken as is
+		if upstream != "" {
+			if result.Pulled, err = r.count(upstream, ""); err != nil {
+				return nil, err
+			}
+			if _, err := r.git("merge", "--quiet", "--ff-only", upstream); err != nil {
+				return nil, fmt.Errorf("failed to pull: %w", err)
+			}
+		}
+		return result, nil
+	}
+
+	if upstream != "" {
+		result.Pulled, err = r.count(upstream, "HEAD")
+		if err != nil {
+			return nil, err
+		}
+		if result.Pulled > 0 {
+			if result.Resolved, err = r.rebase(upstream, opts.Prefer); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	result.Pushed, err = r.count("HEAD", upstream)
+	if err != nil {
+		return nil, err
+	}
+	if result.Pushed > 0 {
+		if _, err := r.git("push", "--quiet", remote, "HEAD:refs/heads/"+branch); err != nil {
+			return nil, fmt.Errorf("failed to push: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+// count returns the number of commits reachable from ref but not from
+// exclude; an empty exclude counts all commits of ref
+func (r *Repo) count(ref, exclude string) (int, error) {
+	args := []string{"rev-list", "--count", ref}
+	if exclude != "" {
+		args = append(args, "^"+exclude)
+	}
+
+	out, err := r.git(args...)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(out)
+}
+
+// rebase rebases the local commits onto upstream, resolving conflicts in
+// favor of prefer or aborting on the first conflict if prefer is empty
+func (r *Repo) rebase(upstream, prefer string) ([]Conflict, error) {
+	_, err := r.git("rebase", "--quiet", upstream)
+
+	var resolved []Conflict
+	for err != nil {
+		conflicts, listErr := r.conflicts()
+		if listErr != nil || len(conflicts) == 0 {
+			if !r.rebasing() {
+				return nil, fmt.Errorf("failed to rebase: %w", err)
+			}
+			// A resolved commit can end up empty, which some versions of git
+			// refuse to commit; it is dropped. Anything else stops the sync.
+			if len(resolved) > 0 && listErr == nil && r.nothingStaged() {
+				_, err = r.git("rebase", "--skip")
+				continue
+			}
+			r.git("rebase", "--abort")
+			if listErr != nil {
+				return nil, listErr
+			}
+			return nil, fmt.Errorf("failed to rebase: %w", err)
+		}
+
+		if prefer == "" {
+			if _, abortErr := r.git("rebase", "--abort"); abortErr != nil {
+				return nil, fmt.Errorf("failed to abort rebase after conflicts: %w", abortErr)
+			}
+			return nil, &ConflictError{Conflicts: conflicts}
+		}
+
+		// While rebasing, "ours" is the upstream and "theirs" the local commit
+		side := "--theirs"
+		if prefer == PreferRemote {
+			side = "--ours"
+		}
+		for _, conflict := range conflicts {
+			if _, err := r.git("checkout", side, "--", conflict.Path); err != nil {
+				// The file was deleted on the preferred side
+				if _, err := r.git("rm", "--quiet", "--", conflict.Path); err != nil {
+					r.git("rebase", "--abort")
+					return nil, fmt.Errorf("failed to resolve %s: %w", conflict.Path, err)
+				}
+				continue
+			}
+			if _, err := r.git("add", "--", conflict.Path); err != nil {
+				r.git("rebase", "--abort")
+				return nil, fmt.Errorf("failed to resolve %s: %w", conflict.Path, err)
+			}
+		}
+		resolved = append(resolved, conflicts...)
+
+		_, err = r.git("rebase", "--continue")
+	}
+
+	return resolved, nil
+}
+
+// conflicts lists the unmerged files, with paths relative to the notes
+// directory. Conflicts in other files of the repository are an error, as
+// they are not for gonotes to resolve.
+func (r *Repo) conflicts() ([]Conflict, error) {
+	prefix, err := r.git("rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, err
+	}
+	// Paths are printed relative to the root of the repository
+	out, err := r.git("diff", "--name-only", "--diff-filter=U")
+	if err != nil || out == "" {
+		return nil, err
+	}
+
+	var conflicts []Conflict
+	for _, path := range strings.Split(out, "\n") {
+		if !strings.HasPrefix(path, prefix) {
+			return nil, fmt.Errorf("%s outside the notes directory has conflicts, resolve them with git", path)
+		}
+		path = strings.TrimPrefix(path, prefix)
+		conflicts = append(conflicts, Conflict{Path: path, NoteID: noteID(path)})
+	}
+	return conflicts, nil
+}
+
+// nothingStaged reports whether the index matches HEAD
+func (r *Repo) nothingStaged() bool {
+	_, err := r.git("diff", "--cached", "--quiet")
+	return err == nil
+}
+
+// rebasing reports whether a rebase is in progress
+func (r *Repo) rebasing() bool {
+	for _, name := range []string{"rebase-merge", "rebase-apply"} {
+		path, err := r.git("rev-parse", "--git-path", name)
+		if err != nil {
+			continue
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(r.dir, path)
+		}
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// noteID returns the note a file of the notes directory belongs to: notes
+// are stored as <id>.json and attachments below attachments/<id>/
+func noteID(path string) int {
+	parts := strings.Split(filepath.ToSlash(path), "/")
+	switch {
+	case len(parts) == 1 && strings.HasSuffix(parts[0], ".json"):
+		id, _ := strconv.Atoi(strings.TrimSuffix(parts[0], ".json"))
+		return id
+	case len(parts) > 2 && parts[0] == note.AttachmentsDirName:
+		id, _ := strconv.Atoi(parts[1])
+		return id
+	}
+	return 0
+}
+
+// Status describes the state of the repository
+type Status struct {
+	Branch    string
+	Remote    string
+	RemoteURL string
+	// Changes is the number of uncommitted files below the notes directory
+	Changes int
+	// Ahead and Behind compare the branch with the remote as of the last
+	// fetch; HasUpstream is false if the remote does not have the branch
+	Ahead       int
+	Behind      int
+	HasUpstream bool
+}
+
+// Status returns the state of the repository compared to a remote
+func (r *Repo) Status(remote string) (*Status, error) {
+	if remote == "" {
+		remote = DefaultRemote
+	}
+
+	status := &Status{Remote: remote, RemoteURL: r.RemoteURL(remote)}
+	status.Branch, _ = r.Branch()
+
+	var err error
+	if status.Changes, err = r.Changes(); err != nil {
+		return nil, err
+	}
+
+	upstream := "refs/remotes/" + remote + "/" + status.Branch
+	if _, err := r.git("rev-parse", "--verify", "--quiet", upstream); err == nil && status.Branch != "" {
+		status.HasUpstream = true
+		if status.Ahead, err = r.count("HEAD", upstream); err != nil {
+			return nil, err
+		}
+		if status.Behind, err = r.count(upstream, "HEAD"); err != nil {
+			return nil, err
+		}
+	}
+
+	return status, nil
+}
@@ -0,0 +1,325 @@
+package gitsync
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+)
+
+// machine is a notes directory in its own clone, as on one computer
+type machine struct {
+	storage *note.Storage
+	repo    *Repo
+}
+
+// newRemote returns the path of a new bare repository, skipping the test if
+// git is not installed. Git is run without the user's configuration.
+func newRemote(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	config := filepath.Join(t.TempDir(), "gitconfig")
+	if err := os.WriteFile(config, []byte("[init]\n\tdefaultBranch = main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", config)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	remote := t.TempDir()
+	if out, err := exec.Command("git", "init", "--quiet", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("git init --bare: %v: %s", err, out)
+	}
+	return remote
+}
+
+// newMachine returns a notes directory set up to sync with remote and to
+// commit every change
+func newMachine(t *testing.T, remote string) *machine {
+	t.Helper()
+	dir := t.TempDir()
+	storage, err := note.NewStorage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, created, err := Init(dir)
+	if err != nil || !created {
+		t.Fatalf("Init() = %v, %v", created, err)
+	}
+	if _, err := repo.IgnoreMeta(); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SetRemote(DefaultRemote, remote); err != nil {
+		t.Fatal(err)
+	}
+	AutoCommit(storage, repo, func(err error) {
+		t.Errorf("auto-commit: %v", err)
+	})
+	return &machine{storage: storage, repo: repo}
+}
+
+// sync syncs the machine and reloads its notes
+func (m *machine) sync(t *testing.T, prefer string) *SyncResult {
+	t.Helper()
+	result, err := m.repo.Sync(SyncOptions{Prefer: prefer})
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if err := m.storage.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+// content returns the content of a note
+func (m *machine) content(t *testing.T, id int) string {
+	t.Helper()
+	n, err := m.storage.GetNote(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n.Content
+}
+
+// head returns the commit checked out in the machine
+func (m *machine) head(t *testing.T) string {
+	t.Helper()
+	head, err := m.repo.git("rev-parse", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return head
+}
+
+// conflicted returns two machines sharing note 1, each with its own
+// unsynced edit of it; the first one's edit is pushed already
+func conflicted(t *testing.T) (*machine, *machine) {
+	t.Helper()
+	remote := newRemote(t)
+	a, b := newMachine(t, remote), newMachine(t, remote)
+
+	if _, err := a.storage.CreateNote("Plan", "first draft", nil); err != nil {
+		t.Fatal(err)
+	}
+	a.sync(t, "")
+	b.sync(t, "")
+
+	if _, err := a.storage.UpdateNote(1, "Plan", "remote edit", nil); err != nil {
+		t.Fatal(err)
+	}
+	a.sync(t, "")
+
+	if _, err := b.storage.UpdateNote(1, "Plan", "local edit", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.storage.AddTag(1, "later"); err != nil {
+		t.Fatal(err)
+	}
+	return a, b
+}
+
+func TestAutoCommit(t *testing.T) {
+	m := newMachine(t, newRemote(t))
+
+	if _, err := m.storage.CreateNote("Go", "Slices", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.storage.AddTag(1, "lang"); err != nil {
+		t.Fatal(err)
+	}
+
+	log, err := m.repo.git("log", "--format=%s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Tag note 1 \"Go\" with lang\nCreate note 1 \"Go\""
+	if log != want {
+		t.Errorf("commits = %q, want %q", log, want)
+	}
+
+	if tracked, _ := m.repo.git("ls-files", "--", note.MetaDirName); tracked != "" {
+		t.Errorf("the meta directory is committed: %s", tracked)
+	}
+	if changes, err := m.repo.Changes(); err != nil || changes != 0 {
+		t.Errorf("Changes() = %d, %v, want nothing left to commit", changes, err)
+	}
+}
+
+func TestSync(t *testing.T) {
+	remote := newRemote(t)
+	a, b := newMachine(t, remote), newMachine(t, remote)
+
+	if _, err := a.storage.CreateNote("Go", "Slices", nil); err != nil {
+		t.Fatal(err)
+	}
+	if result := a.sync(t, ""); result.Branch != "main" || result.Pushed != 1 || result.Pulled != 0 {
+		t.Errorf("first push = %+v, want one commit pushed to main", result)
+	}
+
+	// A new clone takes the branch as it is
+	if result := b.sync(t, ""); result.Pulled != 1 || result.Pushed != 0 {
+		t.Errorf("first pull = %+v, want one commit pulled", result)
+	}
+	if got := b.content(t, 1); got != "Slices" {
+		t.Fatalf("pulled content = %q", got)
+	}
+
+	// Changes to different notes on both sides are rebased and pushed
+	if _, err := b.storage.UpdateNote(1, "Go", "Slices and maps", nil); err != nil {
+		t.Fatal(err)
+	}
+	b.sync(t, "")
+	if _, err := a.storage.CreateNote("Rust", "Ownership", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// A file written without the storage is committed by the sync
+	if err := os.WriteFile(filepath.Join(a.storage.Dir(), "README.md"), []byte("My notes\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result := a.sync(t, "")
+	if !result.Committed || result.Pulled != 1 || result.Pushed != 2 || len(result.Resolved) != 0 {
+		t.Errorf("sync after changes on both sides = %+v, want 1 pulled and 2 pushed", result)
+	}
+	if got := a.content(t, 1); got != "Slices and maps" {
+		t.Errorf("content after rebase = %q", got)
+	}
+	if message, _ := a.repo.git("log", "-1", "--format=%s"); message != "Sync local changes" {
+		t.Errorf("last commit = %q, want %q", message, "Sync local changes")
+	}
+
+	if result := b.sync(t, ""); result.Pulled != 2 {
+		t.Errorf("second pull = %+v, want 2 commits pulled", result)
+	}
+	if got := b.content(t, 2); got != "Ownership" {
+		t.Errorf("content of the pulled note = %q", got)
+	}
+	if a.head(t) != b.head(t) {
+		t.Error("the machines are on different commits after syncing")
+	}
+
+	status, err := b.repo.Status("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.HasUpstream || status.Ahead != 0 || status.Behind != 0 || status.Changes != 0 {
+		t.Errorf("Status() = %+v, want in sync", status)
+	}
+}
+
+func TestSyncConflict(t *testing.T) {
+	_, b := conflicted(t)
+	head := b.head(t)
+	file, err := os.ReadFile(filepath.Join(b.storage.Dir(), "1.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = b.repo.Sync(SyncOptions{})
+	var conflictErr *ConflictError
+	if !errors.As(err, &conflictErr) {
+		t.Fatalf("Sync() error = %v, want a ConflictError", err)
+	}
+	want := []Conflict{{Path: "1.json", NoteID: 1}}
+	if !reflect.DeepEqual(conflictErr.Conflicts, want) {
+		t.Errorf("conflicts = %v, want %v", conflictErr.Conflicts, want)
+	}
+
+	// The rebase is aborted and the notes directory left as it was
+	if b.repo.rebasing() {
+		t.Error("a rebase is still in progress")
+	}
+	if b.head(t) != head {
+		t.Error("HEAD moved although the sync stopped")
+	}
+	after, err := os.ReadFile(filepath.Join(b.storage.Dir(), "1.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(file) {
+		t.Error("the conflicting note file changed although the sync stopped")
+	}
+	if changes, err := b.repo.Changes(); err != nil || changes != 0 {
+		t.Errorf("Changes() = %d, %v, want a clean tree", changes, err)
+	}
+}
+
+func TestSyncPrefer(t *testing.T) {
+	tests := []struct {
+		prefer string
+		// backend is the rebase backend of git; with apply, a commit that
+		// ends up empty after resolving fails to continue and is skipped
+		backend string
+		want    string
+	}{
+		{prefer: PreferLocal, backend: "merge", want: "local edit"},
+		{prefer: PreferRemote, backend: "merge", want: "remote edit"},
+		{prefer: PreferLocal, backend: "apply", want: "local edit"},
+		{prefer: PreferRemote, backend: "apply", want: "remote edit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefer+" with "+tt.backend, func(t *testing.T) {
+			a, b := conflicted(t)
+			if _, err := b.repo.git("config", "rebase.backend", tt.backend); err != nil {
+				t.Fatal(err)
+			}
+
+			result := b.sync(t, tt.prefer)
+			if len(result.Resolved) == 0 || result.Resolved[0] != (Conflict{Path: "1.json", NoteID: 1}) {
+				t.Errorf("resolved = %v, want note 1", result.Resolved)
+			}
+			if b.repo.rebasing() {
+				t.Fatal("a rebase is still in progress")
+			}
+			if got := b.content(t, 1); got != tt.want {
+				t.Errorf("content = %q, want %q", got, tt.want)
+			}
+			if changes, err := b.repo.Changes(); err != nil || changes != 0 {
+				t.Errorf("Changes() = %d, %v, want a clean tree", changes, err)
+			}
+
+			// The result reaches the other machine
+			a.sync(t, "")
+			if got := a.content(t, 1); got != tt.want {
+				t.Errorf("content on the other machine = %q, want %q", got, tt.want)
+			}
+			if a.head(t) != b.head(t) {
+				t.Error("the machines are on different commits after syncing")
+			}
+		})
+	}
+}
+
+func TestSyncErrors(t *testing.T) {
+	m := newMachine(t, newRemote(t))
+
+	if _, err := m.repo.Sync(SyncOptions{Prefer: "theirs"}); err == nil {
+		t.Error("Sync() with an unknown side = nil error")
+	}
+	if _, err := m.repo.Sync(SyncOptions{Remote: "backup"}); err == nil {
+		t.Error("Sync() with an unknown remote = nil error")
+	}
+}
+
+func TestNoteID(t *testing.T) {
+	tests := map[string]int{
+		"12.json":                 12,
+		"attachments/7/photo.png": 7,
+		"attachments/7":           0,
+		"README.md":               0,
+		"sub/3.json":              0,
+	}
+	for path, want := range tests {
+		if got := noteID(path); got != want {
+			t.Errorf("noteID(%q) = %d, want %d", path, got, want)
+		}
+	}
+}
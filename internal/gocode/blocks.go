@@ -0,0 +1,59 @@
+package gocode
+
+import (
+	"strings"
+)
+
+// Block is a fenced Go code block inside a note
+type Block struct {
+	// Index is the 1-based position of the block among the note's Go blocks
+	Index int
+	// Line is the 1-based line of the note on which the code starts
+	Line int
+	// Start and End are the byte offsets of the code within the note content
+	Start int
+	End   int
+	Code  string
+}
+
+// Extract returns the fenced ```go (or ```golang) code blocks in content
+func Extract(content string) []Block {
+	var (
+		blocks  []Block
+		inBlock bool
+		isGo    bool
+		fence   string
+		start   int
+		line    int
+		offset  int
+	)
+
+	for lineNumber, text := range strings.SplitAfter(content, "\n") {
+		trimmed := strings.TrimSpace(text)
+
+		switch {
+		case !inBlock && strings.HasPrefix(trimmed, "```"):
+			inBlock = true
+			fence = trimmed[:strings.LastIndex(trimmed, "`")+1]
+			lang := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(trimmed, fence)))
+			isGo = lang == "go" || lang == "golang"
+			start = offset + len(text)
+			line = lineNumber + 2
+		case inBlock && trimmed == fence:
+			inBlock = false
+			if isGo {
+				blocks = append(blocks, Block{
+					Index: len(blocks) + 1,
+					Line:  line,
+					Start: start,
+					End:   offset,
+					Code:  content[start:offset],
+				})
+			}
+		}
+
+		offset += len(text)
+	}
+
+	return blocks
+}
@@ -0,0 +1,146 @@
+package gocode
+
+import (
+	"errors"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// Kinds of problems reported by Checker
+const (
+	ProblemSyntax = "syntax"
+	ProblemFormat = "format"
+	ProblemType   = "type"
+)
+
+// Problem is an issue found in a Go code block
+type Problem struct {
+	Block int
+	// Line is the 1-based line in the note the problem was found on
+	Line    int
+	Kind    string
+	Message string
+}
+
+// Checker checks Go code blocks for syntax errors, formatting and, when
+// TypeCheck is set, type errors such as unused variables or undefined names.
+// A Checker caches imported packages, so reuse it across blocks.
+type Checker struct {
+	TypeCheck bool
+
+	fset     *token.FileSet
+	importer types.Importer
+}
+
+// NewChecker creates a checker with type checking enabled
+func NewChecker() *Checker {
+	fset := token.NewFileSet()
+	return &Checker{
+		TypeCheck: true,
+		fset:      fset,
+		importer:  importer.ForCompiler(fset, "source", nil),
+	}
+}
+
+// Check checks every Go block in a note's content
+func (c *Checker) Check(content string) []Problem {
+	var problems []Problem
+	for _, block := range Extract(content) {
+		problems = append(problems, c.CheckBlock(block)...)
+	}
+	return problems
+}
+
+// CheckBlock checks a single block
+func (c *Checker) CheckBlock(block Block) []Problem {
+	formatted, err := format.Source([]byte(block.Code))
+	if err != nil {
+		return syntaxProblems(block, err)
+	}
+
+	var problems []Problem
+	if !IsFormatted(block.Code, string(formatted)) {
+		problems = append(problems, Problem{
+			Block:   block.Index,
+			Line:    block.Line,
+			Kind:    ProblemFormat,
+			Message: "code is not gofmt-formatted",
+		})
+	}
+
+	if c.TypeCheck {
+		problems = append(problems, c.typeCheck(block)...)
+	}
+
+	return problems
+}
+
+// typeCheck type checks the block as a main package
+func (c *Checker) typeCheck(block Block) []Problem {
+	program, err := NewProgram(block.Code)
+	if err != nil {
+		return nil
+	}
+
+	file, err := parser.ParseFile(c.fset, "main.go", program.Source, 0)
+	if err != nil {
+		return nil
+	}
+
+	var problems []Problem
+	config := types.Config{
+		Importer: c.importer,
+		Error: func(err error) {
+			var typeErr types.Error
+			if !errors.As(err, &typeErr) {
+				return
+			}
+			line := c.fset.Position(typeErr.Pos).Line - program.LineOffset
+			problems = append(problems, Problem{
+				Block:   block.Index,
+				Line:    block.Line + line - 1,
+				Kind:    ProblemType,
+				Message: typeErr.Msg,
+			})
+		},
+	}
+	config.Check("main", c.fset, []*ast.File{file}, nil)
+
+	return problems
+}
+
+// IsFormatted reports whether code equals its gofmt-formatted version,
+// ignoring leading and trailing blank lines
+func IsFormatted(code, formatted string) bool {
+	return strings.Trim(code, "\n") == strings.Trim(formatted, "\n")
+}
+
+// syntaxProblems converts a parse error into problems
+func syntaxProblems(block Block, err error) []Problem {
+	var list scanner.ErrorList
+	if !errors.As(err, &list) {
+		return []Problem{{
+			Block:   block.Index,
+			Line:    block.Line,
+			Kind:    ProblemSyntax,
+			Message: err.Error(),
+		}}
+	}
+
+	problems := make([]Problem, 0, len(list))
+	for _, e := range list {
+		problems = append(problems, Problem{
+			Block:   block.Index,
+			Line:    block.Line + e.Pos.Line - 1,
+			Kind:    ProblemSyntax,
+			Message: e.Msg,
+		})
+	}
+	return problems
+}
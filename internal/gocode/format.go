@@ -0,0 +1,41 @@
+package gocode
+
+import (
+	"go/format"
+	"strings"
+)
+
+// FormatContent gofmt-formats every Go block in content. Blocks with syntax
+// errors are left unchanged and reported as problems.
+func FormatContent(content string) (string, []Problem) {
+	blocks := Extract(content)
+	if len(blocks) == 0 {
+		return content, nil
+	}
+
+	var (
+		problems []Problem
+		result   strings.Builder
+		last     int
+	)
+
+	for _, block := range blocks {
+		formatted, err := format.Source([]byte(block.Code))
+		if err != nil {
+			problems = append(problems, syntaxProblems(block, err)...)
+			continue
+		}
+
+		code := string(formatted)
+		if !strings.HasSuffix(code, "\n") {
+			code += "\n"
+		}
+
+		result.WriteString(content[last:block.Start])
+		result.WriteString(code)
+		last = block.End
+	}
+	result.WriteString(content[last:])
+
+	return result.String(), problems
+}
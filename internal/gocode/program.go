@@ -0,0 +1,135 @@
+package gocode
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// stdImports maps commonly used package names to their import paths so that
+// snippets which leave out their imports can still be built
+var stdImports = map[string]string{
+	"atomic":   "sync/atomic",
+	"bufio":    "bufio",
+	"bytes":    "bytes",
+	"context":  "context",
+	"errors":   "errors",
+	"filepath": "path/filepath",
+	"fmt":      "fmt",
+	"http":     "net/http",
+	"io":       "io",
+	"json":     "encoding/json",
+	"log":      "log",
+	"maps":     "maps",
+	"math":     "math",
+	"os":       "os",
+	"rand":     "math/rand",
+	"reflect":  "reflect",
+	"regexp":   "regexp",
+	"runtime":  "runtime",
+	"slices":   "slices",
+	"sort":     "sort",
+	"strconv":  "strconv",
+	"strings":  "strings",
+	"sync":     "sync",
+	"time":     "time",
+	"unicode":  "unicode",
+	"utf8":     "unicode/utf8",
+}
+
+// Program is a snippet turned into a complete main package
+type Program struct {
+	Source string
+	// LineOffset is the number of lines added in front of the snippet, used to
+	// map positions in Source back to the snippet
+	LineOffset int
+}
+
+// NewProgram wraps a snippet in a main package if needed. Snippets may be a
+// complete file, a list of declarations or a list of statements; imports of
+// common standard library packages are added when they are missing.
+func NewProgram(code string) (Program, error) {
+	fset := token.NewFileSet()
+
+	// A complete file is used as is
+	if _, err := parser.ParseFile(fset, "main.go", code, parser.PackageClauseOnly); err == nil {
+		return Program{Source: code}, nil
+	}
+
+	// Declarations only need a package clause, and a main function if they
+	// do not declare one
+	header := "package main\n\n"
+	if file, err := parser.ParseFile(fset, "main.go", header+code, 0); err == nil {
+		source := code
+		if !hasMain(file) {
+			source += "\n\nfunc main() {}\n"
+		}
+		return addImports(fset, header, source)
+	}
+
+	// Anything else is treated as the body of main
+	header = "package main\n\nfunc main() {\n"
+	source := code + "\n}\n"
+	if _, err := parser.ParseFile(fset, "main.go", header+source, 0); err != nil {
+		return Program{}, err
+	}
+	return addImports(fset, header, source)
+}
+
+// hasMain reports whether the file declares a main function
+func hasMain(file *ast.File) bool {
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == "main" {
+			return true
+		}
+	}
+	return false
+}
+
+// addImports adds import declarations for unresolved package references in
+// the snippet. The imports go on a single line after the package clause so
+// that the snippet's line numbers shift by a known amount.
+func addImports(fset *token.FileSet, header, code string) (Program, error) {
+	file, err := parser.ParseFile(fset, "main.go", header+code, 0)
+	if err != nil {
+		return Program{}, err
+	}
+
+	declared := make(map[string]bool)
+	for _, obj := range file.Scope.Objects {
+		declared[obj.Name] = true
+	}
+
+	needed := make(map[string]bool)
+	ast.Inspect(file, func(node ast.Node) bool {
+		selector, ok := node.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		ident, ok := selector.X.(*ast.Ident)
+		if ok && ident.Obj == nil && !declared[ident.Name] {
+			if path, known := stdImports[ident.Name]; known {
+				needed[path] = true
+			}
+		}
+		return true
+	})
+
+	var paths []string
+	for path := range needed {
+		paths = append(paths, strconv.Quote(path))
+	}
+	sort.Strings(paths)
+
+	if len(paths) > 0 {
+		header = strings.Replace(header, "package main\n", "package main; import ("+strings.Join(paths, "; ")+")\n", 1)
+	}
+
+	return Program{
+		Source:     header + code,
+		LineOffset: strings.Count(header, "\n"),
+	}, nil
+}
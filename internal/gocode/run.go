@@ -0,0 +1,103 @@
+package gocode
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// DefaultTimeout bounds how long building and running a snippet may take
+const DefaultTimeout = 30 * time.Second
+
+// goMod is the module file used for the temporary module snippets run in
+const goMod = "module snippet\n\ngo 1.21\n"
+
+// Result describes the outcome of running a snippet
+type Result struct {
+	Output   string
+	ExitCode int
+	TimedOut bool
+	Duration time.Duration
+}
+
+// Success reports whether the snippet built and exited successfully
+func (r Result) Success() bool {
+	return r.ExitCode == 0 && !r.TimedOut
+}
+
+// Run builds and runs a snippet in an isolated temporary module. Build errors
+// and a non-zero exit are reported through the result rather than as an error;
+// the error is only set if the snippet could not be run at all.
+func Run(ctx context.Context, code string, timeout time.Duration) (Result, error) {
+	program, err := NewProgram(code)
+	if err != nil {
+		return Result{Output: err.Error(), ExitCode: 1}, nil
+	}
+
+	dir, err := os.MkdirTemp("", "gonotes-run-")
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to create temp module: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod), 0644); err != nil {
+		return Result{}, fmt.Errorf("failed to write go.mod: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(program.Source), 0644); err != nil {
+		return Result{}, fmt.Errorf("failed to write main.go: %w", err)
+	}
+
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+
+	// Build and run in separate steps so that the timeout kills the snippet
+	// itself rather than only the go command
+	binary := filepath.Join(dir, "snippet")
+	build := exec.CommandContext(ctx, "go", "build", "-o", binary, ".")
+	build.Dir = dir
+	// Keep the snippet out of any surrounding workspace and away from the network
+	build.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod", "GOPROXY=off")
+
+	result, err := runCommand(ctx, build)
+	if err == nil && result.Success() {
+		run := exec.CommandContext(ctx, binary)
+		run.Dir = dir
+		result, err = runCommand(ctx, run)
+	}
+
+	result.Duration = time.Since(start)
+	return result, err
+}
+
+// runCommand runs cmd, collecting its combined output and exit status
+func runCommand(ctx context.Context, cmd *exec.Cmd) (Result, error) {
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err := cmd.Run()
+	result := Result{Output: output.String()}
+
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		result.TimedOut = true
+		result.ExitCode = -1
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		return result, fmt.Errorf("failed to run %s: %w", filepath.Base(cmd.Path), err)
+	}
+
+	return result, nil
+}
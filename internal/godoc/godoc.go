@@ -0,0 +1,338 @@
+package godoc
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/doc"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Example is a runnable example attached to a symbol. Its code includes the
+// expected output comment, if any.
+type Example struct {
+	Name string
+	Code string
+}
+
+// Doc is the documentation of a package or one of its symbols
+type Doc struct {
+	ImportPath string
+	Package    string
+	// Symbol is empty for package documentation, and "Type.Method" for methods
+	Symbol    string
+	Signature string
+	// Text is the doc comment rendered as Markdown
+	Text     string
+	Examples []Example
+	// Index lists the exported symbols when documenting a whole package
+	Index []string
+}
+
+// Title returns a title for a note about the documented package or symbol
+func (d *Doc) Title() string {
+	if d.Symbol == "" {
+		return "package " + d.ImportPath
+	}
+	return d.Package + "." + d.Symbol
+}
+
+// Mention returns the text that identifies the documented package or symbol
+// when it is mentioned in other notes
+func (d *Doc) Mention() string {
+	if d.Symbol == "" {
+		return d.ImportPath
+	}
+	return d.Package + "." + d.Symbol
+}
+
+// MentionPattern matches the mention of the documented package or symbol as
+// a whole, so "sync" is not found in "async" or "golang.org/x/sync", nor
+// "sync.Once" in "sync.OnceFunc"
+func (d *Doc) MentionPattern() *regexp.Regexp {
+	return regexp.MustCompile(`(?:^|[^\w./])` + regexp.QuoteMeta(d.Mention()) + `(?:\W|$)`)
+}
+
+// Markdown renders the documentation as note content
+func (d *Doc) Markdown() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "`import %q`\n\n", d.ImportPath)
+
+	if d.Signature != "" {
+		fmt.Fprintf(&b, "```go\n%s\n```\n\n", d.Signature)
+	}
+
+	if d.Text != "" {
+		b.WriteString(strings.TrimSpace(d.Text))
+		b.WriteString("\n\n")
+	}
+
+	if len(d.Index) > 0 {
+		b.WriteString("## Index\n\n")
+		for _, entry := range d.Index {
+			fmt.Fprintf(&b, "- `%s`\n", entry)
+		}
+		b.WriteString("\n")
+	}
+
+	for _, example := range d.Examples {
+		if example.Name == "" {
+			b.WriteString("## Example\n\n")
+		} else {
+			fmt.Fprintf(&b, "## Example (%s)\n\n", example.Name)
+		}
+		fmt.Fprintf(&b, "```go\n%s\n```\n\n", example.Code)
+	}
+
+	return strings.TrimSpace(b.String())
+}
+
+// Load reads the documentation of "package" or "package.Symbol", where the
+// symbol may be a function, type, method ("Type.Method"), constant or variable.
+// Sources are read from GOROOT, the current module or the module cache only.
+func Load(target string) (*Doc, error) {
+	importPath, symbol, dir, err := resolve(target)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	pkg, err := parsePackage(fset, dir, importPath)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Doc{
+		ImportPath: importPath,
+		Package:    pkg.Name,
+		Symbol:     symbol,
+	}
+
+	if symbol == "" {
+		d.Text = renderText(pkg, pkg.Doc)
+		d.Examples = renderExamples(fset, pkg.Examples)
+		d.Index = packageIndex(pkg)
+		return d, nil
+	}
+
+	if err := findSymbol(fset, pkg, d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// resolve splits a target into import path and symbol. Package paths may
+// contain dots (e.g. gopkg.in/yaml.v3), so every split point of the last path
+// element is tried, preferring the longest package path.
+func resolve(target string) (string, string, string, error) {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return "", "", "", fmt.Errorf("no package given")
+	}
+
+	if dir, err := Locate(target); err == nil {
+		return target, "", dir, nil
+	}
+
+	slash := strings.LastIndex(target, "/")
+	base := target[slash+1:]
+
+	var lastErr error
+	for i := strings.LastIndex(base, "."); i > 0; i = strings.LastIndex(base[:i], ".") {
+		importPath := target[:slash+1] + base[:i]
+		symbol := base[i+1:]
+
+		dir, err := Locate(importPath)
+		if err == nil {
+			return importPath, symbol, dir, nil
+		}
+		lastErr = err
+	}
+
+	if lastErr == nil {
+		_, lastErr = Locate(target)
+	}
+	return "", "", "", lastErr
+}
+
+// parsePackage parses the files of a package directory, including tests for
+// their examples, honouring build constraints
+func parsePackage(fset *token.FileSet, dir, importPath string) (*doc.Package, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read package directory: %w", err)
+	}
+
+	var files []*ast.File
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") {
+			continue
+		}
+
+		if match, err := build.Default.MatchFile(dir, name); err != nil || !match {
+			continue
+		}
+
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+
+		// Skip commands and other packages sharing the directory
+		if files != nil && !samePackage(files[0].Name.Name, file.Name.Name) {
+			continue
+		}
+		files = append(files, file)
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no Go files found for package %s", importPath)
+	}
+
+	pkg, err := doc.NewFromFiles(fset, files, importPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read documentation: %w", err)
+	}
+	return pkg, nil
+}
+
+// samePackage reports whether b is package a or its external test package
+func samePackage(a, b string) bool {
+	return strings.TrimSuffix(a, "_test") == strings.TrimSuffix(b, "_test")
+}
+
+// findSymbol fills in the documentation of d.Symbol
+func findSymbol(fset *token.FileSet, pkg *doc.Package, d *Doc) error {
+	typeName, methodName, isMethod := strings.Cut(d.Symbol, ".")
+
+	for _, t := range pkg.Types {
+		if t.Name != typeName {
+			continue
+		}
+
+		if !isMethod {
+			d.Signature = printNode(fset, t.Decl)
+			d.Text = renderText(pkg, t.Doc)
+			d.Examples = renderExamples(fset, t.Examples)
+			return nil
+		}
+
+		for _, m := range t.Methods {
+			if m.Name == methodName {
+				setFunc(fset, pkg, d, m)
+				return nil
+			}
+		}
+		return fmt.Errorf("type %s.%s has no method %s", pkg.Name, typeName, methodName)
+	}
+
+	if isMethod {
+		return fmt.Errorf("type %s not found in package %s", typeName, pkg.ImportPath)
+	}
+
+	funcs := append([]*doc.Func(nil), pkg.Funcs...)
+	values := append(append([]*doc.Value(nil), pkg.Consts...), pkg.Vars...)
+	for _, t := range pkg.Types {
+		// Constructors and typed constants are grouped with their type
+		funcs = append(funcs, t.Funcs...)
+		values = append(append(values, t.Consts...), t.Vars...)
+	}
+
+	for _, f := range funcs {
+		if f.Name == d.Symbol {
+			setFunc(fset, pkg, d, f)
+			return nil
+		}
+	}
+
+	for _, v := range values {
+		for _, name := range v.Names {
+			if name == d.Symbol {
+				d.Signature = printNode(fset, v.Decl)
+				d.Text = renderText(pkg, v.Doc)
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("symbol %s not found in package %s", d.Symbol, pkg.ImportPath)
+}
+
+// setFunc fills in d from a function or method
+func setFunc(fset *token.FileSet, pkg *doc.Package, d *Doc, f *doc.Func) {
+	d.Signature = printNode(fset, f.Decl)
+	d.Text = renderText(pkg, f.Doc)
+	d.Examples = renderExamples(fset, f.Examples)
+}
+
+// packageIndex lists the exported functions and types of a package
+func packageIndex(pkg *doc.Package) []string {
+	var index []string
+	for _, f := range pkg.Funcs {
+		index = append(index, "func "+f.Name)
+	}
+	for _, t := range pkg.Types {
+		index = append(index, "type "+t.Name)
+	}
+	return index
+}
+
+// renderText converts a doc comment to Markdown
+func renderText(pkg *doc.Package, text string) string {
+	if text == "" {
+		return ""
+	}
+
+	p := pkg.Printer()
+	p.HeadingLevel = 3
+	return string(p.Markdown(pkg.Parser().Parse(text)))
+}
+
+// renderExamples prints the code of examples
+func renderExamples(fset *token.FileSet, examples []*doc.Example) []Example {
+	var rendered []Example
+	for _, example := range examples {
+		code := printNode(fset, &printer.CommentedNode{Node: example.Code, Comments: example.Comments})
+		if _, isBlock := example.Code.(*ast.BlockStmt); isBlock {
+			code = unwrapBlock(code)
+		}
+
+		rendered = append(rendered, Example{
+			Name: example.Suffix,
+			Code: code,
+		})
+	}
+	return rendered
+}
+
+// unwrapBlock removes the braces around a printed block and unindents it
+func unwrapBlock(code string) string {
+	code = strings.TrimSpace(code)
+	code = strings.TrimPrefix(code, "{")
+	code = strings.TrimSuffix(code, "}")
+
+	lines := strings.Split(strings.Trim(code, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(line, "\t")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// printNode formats an AST node as Go source
+func printNode(fset *token.FileSet, node interface{}) string {
+	var buf bytes.Buffer
+	config := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+	if err := config.Fprint(&buf, fset, node); err != nil {
+		return ""
+	}
+	return buf.String()
+}
@@ -0,0 +1,41 @@
+package godoc
+
+import "testing"
+
+func TestMentionPattern(t *testing.T) {
+	pkg := &Doc{ImportPath: "sync", Package: "sync"}
+	once := &Doc{ImportPath: "sync", Package: "sync", Symbol: "Once"}
+	io := &Doc{ImportPath: "io", Package: "io"}
+	builder := &Doc{ImportPath: "strings", Package: "strings", Symbol: "Builder.WriteString"}
+
+	tests := []struct {
+		doc  *Doc
+		text string
+		want bool
+	}{
+		{doc: pkg, text: "sync", want: true},
+		{doc: pkg, text: "Use the sync package.", want: true},
+		{doc: pkg, text: "see sync.Once", want: true},
+		{doc: pkg, text: "an async function", want: false},
+		{doc: pkg, text: "syncing files", want: false},
+		{doc: pkg, text: "golang.org/x/sync/errgroup", want: false},
+		{doc: once, text: "`sync.Once` runs a function once", want: true},
+		{doc: once, text: "sync.Once.", want: true},
+		{doc: once, text: "use sync.OnceFunc instead", want: false},
+		{doc: once, text: "xsync.Once", want: false},
+		{doc: io, text: "read it with io.Reader", want: true},
+		{doc: io, text: "a function of the ratio", want: false},
+		{doc: io, text: "radio", want: false},
+		{doc: builder, text: "call strings.Builder.WriteString(s)", want: true},
+		{doc: builder, text: "strings.Builder.WriteStrings", want: false},
+		{doc: builder, text: "strings.BuilderXWriteString", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.doc.Mention()+" in "+tt.text, func(t *testing.T) {
+			if got := tt.doc.MentionPattern().MatchString(tt.text); got != tt.want {
+				t.Errorf("MentionPattern().MatchString(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
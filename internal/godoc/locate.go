@@ -0,0 +1,190 @@
+package godoc
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// goEnv returns the value of a go environment variable, preferring the
+// process environment and falling back to asking the go command
+func goEnv(key string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+
+	out, err := exec.Command("go", "env", key).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// Locate finds the source directory of an import path without touching the
+// network. It looks in GOROOT, then in the module of the current directory,
+// then in the module cache, picking the newest cached version.
+func Locate(importPath string) (string, error) {
+	goroot := goEnv("GOROOT")
+	if goroot == "" {
+		goroot = runtime.GOROOT()
+	}
+	if goroot != "" {
+		dir := filepath.Join(goroot, "src", filepath.FromSlash(importPath))
+		if isDir(dir) {
+			return dir, nil
+		}
+	}
+
+	if dir, ok := locateInCurrentModule(importPath); ok {
+		return dir, nil
+	}
+
+	modCache := goEnv("GOMODCACHE")
+	if modCache == "" {
+		return "", fmt.Errorf("package %s not found in GOROOT and no module cache available", importPath)
+	}
+
+	// Try the longest module path prefix first, e.g. for
+	// github.com/spf13/cobra/doc try .../cobra/doc@v* then .../cobra@v*
+	parts := strings.Split(importPath, "/")
+	for i := len(parts); i > 0; i-- {
+		modulePath := strings.Join(parts[:i], "/")
+		moduleDir, ok := newestModuleVersion(modCache, modulePath)
+		if !ok {
+			continue
+		}
+
+		dir := filepath.Join(moduleDir, filepath.FromSlash(strings.Join(parts[i:], "/")))
+		if isDir(dir) {
+			return dir, nil
+		}
+	}
+
+	return "", fmt.Errorf("package %s not found in GOROOT, the current module or the module cache", importPath)
+}
+
+// locateInCurrentModule looks for the package inside the module containing
+// the working directory
+func locateInCurrentModule(importPath string) (string, bool) {
+	gomod := goEnv("GOMOD")
+	if gomod == "" || gomod == os.DevNull {
+		return "", false
+	}
+
+	file, err := os.Open(gomod)
+	if err != nil {
+		return "", false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "module ") {
+			continue
+		}
+
+		modulePath := strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module ")), `"`)
+		if importPath != modulePath && !strings.HasPrefix(importPath, modulePath+"/") {
+			return "", false
+		}
+
+		rel := strings.TrimPrefix(strings.TrimPrefix(importPath, modulePath), "/")
+		dir := filepath.Join(filepath.Dir(gomod), filepath.FromSlash(rel))
+		return dir, isDir(dir)
+	}
+
+	return "", false
+}
+
+// newestModuleVersion returns the directory of the newest cached version of
+// a module
+func newestModuleVersion(modCache, modulePath string) (string, bool) {
+	escaped := escapePath(modulePath)
+	parent := filepath.Join(modCache, filepath.FromSlash(filepath.Dir(escaped)))
+	prefix := filepath.Base(escaped) + "@"
+
+	entries, err := os.ReadDir(parent)
+	if err != nil {
+		return "", false
+	}
+
+	var versions []string
+	for _, entry := range entries {
+		if entry.IsDir() && strings.HasPrefix(entry.Name(), prefix) {
+			versions = append(versions, strings.TrimPrefix(entry.Name(), prefix))
+		}
+	}
+	if len(versions) == 0 {
+		return "", false
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return compareVersions(versions[i], versions[j]) < 0
+	})
+
+	return filepath.Join(parent, prefix+versions[len(versions)-1]), true
+}
+
+// escapePath applies the module cache's case encoding, where upper case
+// letters are written as '!' followed by the lower case letter
+func escapePath(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		if unicode.IsUpper(r) {
+			b.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// compareVersions compares two semantic versions like v1.2.3 or
+// v0.0.0-20230905200255-921286631fa9, ordering releases after pre-releases
+func compareVersions(a, b string) int {
+	aCore, aPre, _ := strings.Cut(strings.TrimPrefix(a, "v"), "-")
+	bCore, bPre, _ := strings.Cut(strings.TrimPrefix(b, "v"), "-")
+
+	aParts := strings.Split(strings.TrimSuffix(aCore, "+incompatible"), ".")
+	bParts := strings.Split(strings.TrimSuffix(bCore, "+incompatible"), ".")
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var x, y int
+		if i < len(aParts) {
+			x, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			y, _ = strconv.Atoi(bParts[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	default:
+		return strings.Compare(aPre, bPre)
+	}
+}
+
+// isDir reports whether path is an existing directory
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,212 @@
+package importer
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+)
+
+// enexDateLayout is the format of dates in ENEX files
+const enexDateLayout = "20060102T150405Z"
+
+// enexNote is a <note> element of an ENEX file
+type enexNote struct {
+	Title     string         `xml:"title"`
+	Content   string         `xml:"content"`
+	Created   string         `xml:"created"`
+	Updated   string         `xml:"updated"`
+	Tags      []string       `xml:"tag"`
+	SourceURL string         `xml:"note-attributes>source-url"`
+	Resources []enexResource `xml:"resource"`
+}
+
+// enexResource is an embedded file of an ENEX note
+type enexResource struct {
+	Data struct {
+		Encoding string `xml:"encoding,attr"`
+		Value    string `xml:",chardata"`
+	} `xml:"data"`
+	Mime     string `xml:"mime"`
+	FileName string `xml:"resource-attributes>file-name"`
+}
+
+// ReadENEX reads an Evernote export. Notes are decoded one at a time, so
+// large exports are not loaded into memory at once. ENML content is converted
+// to Markdown and embedded resources become attachments. Progress is reported
+// after every note with an unknown total.
+func ReadENEX(r io.Reader, progress Progress) (*Batch, error) {
+	decoder := xml.NewDecoder(r)
+	// ENEX files are UTF-8, but some tools declare other charsets
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	batch := &Batch{}
+	count := 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if count == 0 {
+				return nil, fmt.Errorf("invalid ENEX file: %w", err)
+			}
+			// Keep the notes read so far
+			batch.AddError(fmt.Sprintf("after note %d", count), fmt.Errorf("invalid ENEX file: %w", err))
+			break
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "note" {
+			continue
+		}
+
+		count++
+		var en enexNote
+		if err := decoder.DecodeElement(&en, &start); err != nil {
+			batch.AddError(fmt.Sprintf("note %d", count), fmt.Errorf("invalid ENEX file: %w", err))
+			break
+		}
+
+		source := fmt.Sprintf("note %d", count)
+		if title := strings.TrimSpace(en.Title); title != "" {
+			source = fmt.Sprintf("note %d (%s)", count, title)
+		}
+
+		item, err := convertENEXNote(&en, source)
+		if err != nil {
+			batch.AddError(source, err)
+		} else {
+			batch.Items = append(batch.Items, item)
+		}
+
+		if progress != nil {
+			progress(count, 0)
+		}
+	}
+
+	return batch, nil
+}
+
+// convertENEXNote converts a decoded ENEX note into an item
+func convertENEXNote(en *enexNote, source string) (*Item, error) {
+	item := &Item{Source: source}
+
+	media := make(map[string]enmlMedia)
+	names := make(map[string]bool)
+	for i, resource := range en.Resources {
+		if resource.Data.Encoding != "" && resource.Data.Encoding != "base64" {
+			return nil, fmt.Errorf("unsupported resource encoding %q", resource.Data.Encoding)
+		}
+		data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(resource.Data.Value), ""))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode resource %d: %w", i+1, err)
+		}
+
+		name := strings.TrimSpace(resource.FileName)
+		if name == "" {
+			name = fmt.Sprintf("attachment-%d%s", i+1, mimeExtension(resource.Mime))
+		}
+		name = uniqueAttachmentName(name, names)
+
+		sum := md5.Sum(data)
+		media[hex.EncodeToString(sum[:])] = enmlMedia{Name: name, Mime: resource.Mime}
+		item.Attachments = append(item.Attachments, Attachment{Name: name, Data: data})
+	}
+
+	content, err := convertENML(en.Content, media)
+	if err != nil {
+		return nil, err
+	}
+	if url := strings.TrimSpace(en.SourceURL); url != "" {
+		content = strings.TrimSpace(content + "\n\nSource: <" + url + ">")
+	}
+
+	title := strings.TrimSpace(en.Title)
+	if title == "" {
+		title = "Untitled"
+	}
+	if content == "" {
+		return nil, fmt.Errorf("note has no content")
+	}
+
+	n := &note.Note{Title: title, Content: content}
+	for _, tag := range en.Tags {
+		n.AddTag(tagName(tag))
+	}
+
+	n.CreatedAt = parseENEXDate(en.Created, time.Now())
+	n.UpdatedAt = parseENEXDate(en.Updated, n.CreatedAt)
+	if n.UpdatedAt.Before(n.CreatedAt) {
+		n.UpdatedAt = n.CreatedAt
+	}
+
+	item.Note = n
+	return item, nil
+}
+
+// parseENEXDate parses an ENEX timestamp, returning fallback if it is missing
+// or invalid
+func parseENEXDate(value string, fallback time.Time) time.Time {
+	t, err := time.Parse(enexDateLayout, strings.TrimSpace(value))
+	if err != nil {
+		return fallback
+	}
+	return t.Local()
+}
+
+// commonExtensions are preferred over the first match of the mime package,
+// which may be an unusual one such as .jfif
+var commonExtensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"application/pdf": ".pdf",
+	"text/plain":      ".txt",
+	"audio/mpeg":      ".mp3",
+}
+
+// mimeExtension returns a file extension for a MIME type, or nothing if the
+// type is unknown
+func mimeExtension(mimeType string) string {
+	if ext, ok := commonExtensions[mimeType]; ok {
+		return ext
+	}
+	extensions, err := mime.ExtensionsByType(mimeType)
+	if err != nil || len(extensions) == 0 {
+		return ""
+	}
+	return extensions[0]
+}
+
+// tagName turns a tag of another application, which may contain spaces, into
+// a single word tag
+func tagName(tag string) string {
+	return strings.Join(strings.Fields(tag), "-")
+}
+
+// uniqueAttachmentName makes name usable in a Markdown link and unique among
+// the names already used by the item, and records it as used
+func uniqueAttachmentName(name string, used map[string]bool) string {
+	name = strings.NewReplacer("(", "_", ")", "_").Replace(strings.Join(strings.Fields(name), "-"))
+	ext := ""
+	if dot := strings.LastIndex(name, "."); dot > 0 {
+		ext = name[dot:]
+	}
+	base := strings.TrimSuffix(name, ext)
+
+	for i := 1; used[name]; i++ {
+		name = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+	used[name] = true
+	return name
+}
@@ -0,0 +1,408 @@
+package importer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// enmlNode is an element or text node of an ENML document
+type enmlNode struct {
+	Name     string
+	Attrs    map[string]string
+	Text     string
+	Children []*enmlNode
+}
+
+// blockElements are rendered as separate Markdown blocks
+var blockElements = map[string]bool{
+	"en-note": true, "div": true, "p": true, "pre": true, "blockquote": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"ul": true, "ol": true, "table": true, "hr": true, "center": true,
+	"section": true, "article": true, "header": true, "footer": true,
+}
+
+// voidElements have no content and are often written without a closing tag,
+// such as <br> or <hr>
+var voidElements = make(map[string]bool)
+
+func init() {
+	for _, name := range xml.HTMLAutoClose {
+		voidElements[name] = true
+	}
+}
+
+var spacePattern = regexp.MustCompile(`[ \t\r\n\f]+`)
+
+// enmlMedia describes an <en-media> resource for the converter
+type enmlMedia struct {
+	Name string
+	Mime string
+}
+
+// parseENML builds a tree from ENML, the XHTML dialect Evernote stores note
+// content in. Parsing is lenient so that sloppy HTML still converts: raw
+// tokens are read so the decoder does not reject unbalanced tags, and the
+// tree is built with its own stack.
+func parseENML(content string) (*enmlNode, error) {
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+
+	root := &enmlNode{Name: "en-note"}
+	stack := []*enmlNode{root}
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid note content: %w", err)
+		}
+
+		parent := stack[len(stack)-1]
+		switch t := token.(type) {
+		case xml.StartElement:
+			name := strings.ToLower(t.Name.Local)
+			if name == "en-note" && len(stack) == 1 {
+				continue
+			}
+			node := &enmlNode{Name: name, Attrs: make(map[string]string)}
+			for _, attr := range t.Attr {
+				node.Attrs[strings.ToLower(attr.Name.Local)] = attr.Value
+			}
+			parent.Children = append(parent.Children, node)
+			if !voidElements[name] {
+				stack = append(stack, node)
+			}
+		case xml.EndElement:
+			name := strings.ToLower(t.Name.Local)
+			// Close up to the matching element, ignoring stray end tags
+			for i := len(stack) - 1; i > 0; i-- {
+				if stack[i].Name == name {
+					stack = stack[:i]
+					break
+				}
+			}
+		case xml.CharData:
+			parent.Children = append(parent.Children, &enmlNode{Text: string(t)})
+		}
+	}
+
+	return root, nil
+}
+
+// enmlConverter renders an ENML tree as Markdown
+type enmlConverter struct {
+	// media maps resource hashes to the attachment they are stored as
+	media map[string]enmlMedia
+}
+
+// convertENML converts ENML note content to Markdown
+func convertENML(content string, media map[string]enmlMedia) (string, error) {
+	root, err := parseENML(content)
+	if err != nil {
+		return "", err
+	}
+
+	c := &enmlConverter{media: media}
+
+	// Evernote puts every checklist item in its own div; keep them in one list
+	var b strings.Builder
+	previousTask := false
+	for i, block := range c.blocks(root.Children) {
+		task := strings.HasPrefix(block, "- [ ] ") || strings.HasPrefix(block, "- [x] ")
+		switch {
+		case i == 0:
+		case task && previousTask:
+			b.WriteString("\n")
+		default:
+			b.WriteString("\n\n")
+		}
+		b.WriteString(block)
+		previousTask = task
+	}
+	return b.String(), nil
+}
+
+// blocks renders a list of nodes as Markdown blocks, grouping inline nodes
+// into paragraphs
+func (c *enmlConverter) blocks(nodes []*enmlNode) []string {
+	var (
+		blocks []string
+		inline strings.Builder
+	)
+
+	flush := func() {
+		text := strings.TrimSpace(inline.String())
+		inline.Reset()
+		if text == "" {
+			return
+		}
+		// A paragraph starting with a checkbox is a task list item
+		if strings.HasPrefix(text, "[ ] ") || strings.HasPrefix(text, "[x] ") {
+			text = "- " + text
+		}
+		blocks = append(blocks, text)
+	}
+
+	for _, node := range nodes {
+		if node.Name == "" || !blockElements[node.Name] {
+			inline.WriteString(c.inline(node))
+			continue
+		}
+		flush()
+		blocks = append(blocks, c.block(node)...)
+	}
+	flush()
+
+	return blocks
+}
+
+// block renders a block element
+func (c *enmlConverter) block(node *enmlNode) []string {
+	switch node.Name {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		text := strings.TrimSpace(c.inlineChildren(node))
+		if text == "" {
+			return nil
+		}
+		level := int(node.Name[1] - '0')
+		return []string{strings.Repeat("#", level) + " " + strings.ReplaceAll(text, "\n", " ")}
+	case "hr":
+		return []string{"---"}
+	case "pre":
+		return []string{fence(plainText(node))}
+	case "ul", "ol":
+		if lines := c.list(node, ""); len(lines) > 0 {
+			return []string{strings.Join(lines, "\n")}
+		}
+		return nil
+	case "table":
+		if table := c.table(node); table != "" {
+			return []string{table}
+		}
+		return nil
+	case "blockquote":
+		var quoted []string
+		for _, block := range c.blocks(node.Children) {
+			quoted = append(quoted, "> "+strings.ReplaceAll(block, "\n", "\n> "))
+		}
+		if len(quoted) == 0 {
+			return nil
+		}
+		return []string{strings.Join(quoted, "\n>\n")}
+	case "div":
+		// Evernote code blocks are divs with a special style, one div per line
+		if strings.Contains(node.Attrs["style"], "-en-codeblock") {
+			return []string{fence(plainText(node))}
+		}
+	}
+
+	return c.blocks(node.Children)
+}
+
+// inline renders an inline node
+func (c *enmlConverter) inline(node *enmlNode) string {
+	switch node.Name {
+	case "":
+		return spacePattern.ReplaceAllString(strings.ReplaceAll(node.Text, "\u00a0", " "), " ")
+	case "br":
+		return "\n"
+	case "b", "strong":
+		return wrapInline(c.inlineChildren(node), "**")
+	case "i", "em":
+		return wrapInline(c.inlineChildren(node), "*")
+	case "s", "strike", "del":
+		return wrapInline(c.inlineChildren(node), "~~")
+	case "code", "tt", "kbd":
+		return wrapInline(plainText(node), "`")
+	case "a":
+		text := strings.TrimSpace(c.inlineChildren(node))
+		href := node.Attrs["href"]
+		switch {
+		case href == "":
+			return text
+		case strings.HasPrefix(href, "evernote:"):
+			// Links to other notes use the note title as text
+			if text != "" && !strings.ContainsAny(text, "[]|#") {
+				return "[[" + text + "]]"
+			}
+			return text
+		case text == "":
+			return "<" + href + ">"
+		default:
+			return "[" + text + "](" + href + ")"
+		}
+	case "img":
+		return "![" + node.Attrs["alt"] + "](" + node.Attrs["src"] + ")"
+	case "en-media":
+		media, ok := c.media[node.Attrs["hash"]]
+		if !ok {
+			return ""
+		}
+		link := "[" + media.Name + "](attachment:" + media.Name + ")"
+		if strings.HasPrefix(media.Mime, "image/") {
+			return "!" + link
+		}
+		return link
+	case "en-todo":
+		if node.Attrs["checked"] == "true" {
+			return "[x] "
+		}
+		return "[ ] "
+	case "en-crypt":
+		return "*(encrypted content was not imported)*"
+	case "script", "style", "head", "title":
+		return ""
+	}
+
+	return c.inlineChildren(node)
+}
+
+// inlineChildren renders the children of a node as inline text. Block
+// children are separated by line breaks.
+func (c *enmlConverter) inlineChildren(node *enmlNode) string {
+	var b strings.Builder
+	for _, child := range node.Children {
+		if child.Name != "" && blockElements[child.Name] {
+			b.WriteString("\n" + strings.Join(c.block(child), "\n") + "\n")
+			continue
+		}
+		b.WriteString(c.inline(child))
+	}
+	return b.String()
+}
+
+// list renders a <ul> or <ol> with nested lists indented
+func (c *enmlConverter) list(node *enmlNode, indent string) []string {
+	var lines []string
+	number := 0
+
+	for _, item := range node.Children {
+		if item.Name != "li" {
+			continue
+		}
+		number++
+
+		marker := "- "
+		if node.Name == "ol" {
+			marker = fmt.Sprintf("%d. ", number)
+		}
+
+		var (
+			text   strings.Builder
+			nested []string
+		)
+		for _, child := range item.Children {
+			if child.Name == "ul" || child.Name == "ol" {
+				nested = append(nested, c.list(child, indent+strings.Repeat(" ", len(marker)))...)
+				continue
+			}
+			if child.Name != "" && blockElements[child.Name] {
+				text.WriteString(" " + strings.Join(c.block(child), " "))
+				continue
+			}
+			text.WriteString(c.inline(child))
+		}
+
+		line := strings.Join(strings.Fields(text.String()), " ")
+		lines = append(lines, indent+marker+line)
+		lines = append(lines, nested...)
+	}
+
+	return lines
+}
+
+// table renders a table with the first row as header
+func (c *enmlConverter) table(node *enmlNode) string {
+	var rows [][]string
+	var collect func(n *enmlNode)
+	collect = func(n *enmlNode) {
+		for _, child := range n.Children {
+			switch child.Name {
+			case "tr":
+				var row []string
+				for _, cell := range child.Children {
+					if cell.Name == "td" || cell.Name == "th" {
+						text := strings.Join(strings.Fields(c.inlineChildren(cell)), " ")
+						row = append(row, strings.ReplaceAll(text, "|", "\\|"))
+					}
+				}
+				rows = append(rows, row)
+			case "thead", "tbody", "tfoot":
+				collect(child)
+			}
+		}
+	}
+	collect(node)
+
+	if len(rows) == 0 {
+		return ""
+	}
+
+	columns := 0
+	for _, row := range rows {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+
+	var lines []string
+	for i, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		lines = append(lines, "| "+strings.Join(row, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat(" --- |", columns))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// plainText returns the text of a node keeping whitespace, with line breaks
+// for <br> and block elements
+func plainText(node *enmlNode) string {
+	var b strings.Builder
+	var walk func(n *enmlNode)
+	walk = func(n *enmlNode) {
+		switch {
+		case n.Name == "":
+			b.WriteString(strings.ReplaceAll(n.Text, "\u00a0", " "))
+			return
+		case n.Name == "br":
+			b.WriteString("\n")
+			return
+		}
+		for _, child := range n.Children {
+			walk(child)
+		}
+		if blockElements[n.Name] && !strings.HasSuffix(b.String(), "\n") {
+			b.WriteString("\n")
+		}
+	}
+	for _, child := range node.Children {
+		walk(child)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// fence wraps code in a fenced code block
+func fence(code string) string {
+	return "```\n" + strings.Trim(code, "\n") + "\n```"
+}
+
+// wrapInline surrounds text with an emphasis marker, keeping surrounding
+// whitespace outside so the Markdown stays valid
+func wrapInline(text, marker string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	start := text[:strings.Index(text, trimmed)]
+	end := text[len(start)+len(trimmed):]
+	return start + marker + trimmed + marker + end
+}
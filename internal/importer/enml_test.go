@@ -0,0 +1,197 @@
+package importer
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConvertENML(t *testing.T) {
+	media := map[string]enmlMedia{
+		"abc": {Name: "photo.png", Mime: "image/png"},
+		"def": {Name: "report.pdf", Mime: "application/pdf"},
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "paragraphs",
+			content: `<en-note><div>First   line</div><div>Second<br/>line</div></en-note>`,
+			want:    "First line\n\nSecond\nline",
+		},
+		{
+			name:    "inline styles",
+			content: `<en-note><p><b>bold</b> <i>italic </i><s>gone</s> <code>x := 1</code></p></en-note>`,
+			want:    "**bold** *italic* ~~gone~~ `x := 1`",
+		},
+		{
+			name:    "headings and rule",
+			content: `<en-note><h1>Title</h1><h3>Sub</h3><hr/><p>Text</p></en-note>`,
+			want:    "# Title\n\n### Sub\n\n---\n\nText",
+		},
+		{
+			name:    "links",
+			content: `<en-note><p><a href="https://go.dev">Go</a> <a href="https://x.org"></a> <a href="evernote:///view/1/s1/abc/">Other note</a></p></en-note>`,
+			want:    "[Go](https://go.dev) <https://x.org> [[Other note]]",
+		},
+		{
+			name:    "nested lists",
+			content: `<en-note><ul><li>One<ul><li>Nested</li></ul></li><li>Two</li></ul><ol><li>First</li><li>Second</li></ol></en-note>`,
+			want:    "- One\n  - Nested\n- Two\n\n1. First\n2. Second",
+		},
+		{
+			name:    "checklist",
+			content: `<en-note><div><en-todo checked="true"/>Done</div><div><en-todo/>Open</div><div>After</div></en-note>`,
+			want:    "- [x] Done\n- [ ] Open\n\nAfter",
+		},
+		{
+			name:    "table",
+			content: `<en-note><table><tr><th>Name</th><th>Value</th></tr><tr><td>a|b</td></tr></table></en-note>`,
+			want:    "| Name | Value |\n| --- | --- |\n| a\\|b |  |",
+		},
+		{
+			name:    "code block",
+			content: `<en-note><div style="box-sizing: border-box; -en-codeblock: true;"><div>func main() {</div><div>  fmt.Println()</div><div>}</div></div></en-note>`,
+			want:    "```\nfunc main() {\n  fmt.Println()\n}\n```",
+		},
+		{
+			name: "pre",
+			content: `<en-note><pre>a  b
+  c</pre></en-note>`,
+			want: "```\na  b\n  c\n```",
+		},
+		{
+			name:    "blockquote",
+			content: `<en-note><blockquote><p>One</p><p>Two</p></blockquote></en-note>`,
+			want:    "> One\n>\n> Two",
+		},
+		{
+			name:    "media",
+			content: `<en-note><div><en-media hash="abc" type="image/png"/></div><div><en-media hash="def" type="application/pdf"/></div><div><en-media hash="zzz"/>x</div></en-note>`,
+			want:    "![photo.png](attachment:photo.png)\n\n[report.pdf](attachment:report.pdf)\n\nx",
+		},
+		{
+			name:    "html entities and stray tags",
+			content: `<en-note><p>Fish &amp; chips&nbsp;&mdash; <b>tasty</p></span><p>Next</p></en-note>`,
+			want:    "Fish & chips — **tasty**\n\nNext",
+		},
+		{
+			name:    "unclosed void elements",
+			content: `<en-note><div>One<br>two</div><hr><div>Three</div></en-note>`,
+			want:    "One\ntwo\n\n---\n\nThree",
+		},
+		{
+			name:    "encrypted and scripts",
+			content: `<en-note><div><en-crypt>c2VjcmV0</en-crypt></div><script>alert(1)</script></en-note>`,
+			want:    "*(encrypted content was not imported)*",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := convertENML(tt.content, media)
+			if err != nil {
+				t.Fatalf("convertENML() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("convertENML() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadENEX(t *testing.T) {
+	image := []byte("not really a png")
+	sum := md5.Sum(image)
+	hash := hex.EncodeToString(sum[:])
+
+	enex := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE en-export SYSTEM "http://xml.evernote.com/pub/evernote-export3.dtd">
+<en-export>
+  <note>
+    <title>Trip</title>
+    <content><![CDATA[<?xml version="1.0" encoding="UTF-8"?><en-note><div>Packing</div><div><en-media hash="` + hash + `" type="image/png"/></div></en-note>]]></content>
+    <created>20240102T030405Z</created>
+    <updated>20240101T000000Z</updated>
+    <tag>Travel</tag>
+    <note-attributes><source-url>https://example.com</source-url></note-attributes>
+    <resource>
+      <data encoding="base64">` + base64.StdEncoding.EncodeToString(image) + `</data>
+      <mime>image/png</mime>
+    </resource>
+  </note>
+  <note>
+    <title></title>
+    <content><![CDATA[<en-note><div>No title</div></en-note>]]></content>
+  </note>
+  <note>
+    <title>Empty</title>
+    <content><![CDATA[<en-note><div> </div></en-note>]]></content>
+  </note>
+</en-export>`
+
+	var calls []int
+	batch, err := ReadENEX(strings.NewReader(enex), func(done, total int) {
+		calls = append(calls, done)
+	})
+	if err != nil {
+		t.Fatalf("ReadENEX() error = %v", err)
+	}
+
+	if len(batch.Items) != 2 {
+		t.Fatalf("ReadENEX() read %d items, want 2", len(batch.Items))
+	}
+	if !reflect.DeepEqual(calls, []int{1, 2, 3}) {
+		t.Errorf("progress calls = %v, want [1 2 3]", calls)
+	}
+
+	trip := batch.Items[0].Note
+	if trip.Title != "Trip" {
+		t.Errorf("title = %q, want %q", trip.Title, "Trip")
+	}
+	wantContent := "Packing\n\n![attachment-1.png](attachment:attachment-1.png)\n\nSource: <https://example.com>"
+	if trip.Content != wantContent {
+		t.Errorf("content = %q, want %q", trip.Content, wantContent)
+	}
+	created := time.Date(2024, time.January, 2, 3, 4, 5, 0, time.UTC)
+	if !trip.CreatedAt.Equal(created) || !trip.UpdatedAt.Equal(created) {
+		t.Errorf("dates = %v, %v, want %v for both", trip.CreatedAt, trip.UpdatedAt, created)
+	}
+	if len(trip.Tags) != 1 {
+		t.Errorf("tags = %v, want one tag", trip.Tags)
+	}
+	attachments := batch.Items[0].Attachments
+	if len(attachments) != 1 || attachments[0].Name != "attachment-1.png" || string(attachments[0].Data) != string(image) {
+		t.Errorf("attachments = %v, want attachment-1.png", attachments)
+	}
+
+	if title := batch.Items[1].Note.Title; title != "Untitled" {
+		t.Errorf("title = %q, want %q", title, "Untitled")
+	}
+
+	if len(batch.Errors) != 1 || batch.Errors[0].Source != "note 3 (Empty)" {
+		t.Errorf("errors = %v, want one for note 3 (Empty)", batch.Errors)
+	}
+}
+
+func TestReadENEXInvalid(t *testing.T) {
+	if _, err := ReadENEX(strings.NewReader("<en-export"), nil); err == nil {
+		t.Error("ReadENEX() = nil error for a broken file, want an error")
+	}
+
+	// A file cut off after the first note keeps the notes read so far
+	batch, err := ReadENEX(strings.NewReader(`<en-export><note><title>A</title><content><![CDATA[<en-note>a</en-note>]]></content></note><note>`), nil)
+	if err != nil {
+		t.Fatalf("ReadENEX() error = %v", err)
+	}
+	if len(batch.Items) != 1 || len(batch.Errors) != 1 {
+		t.Errorf("ReadENEX() = %d items and %d errors, want 1 and 1", len(batch.Items), len(batch.Errors))
+	}
+}
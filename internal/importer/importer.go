@@ -0,0 +1,268 @@
+package importer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+)
+
+// Item is a note read from an import source, before it is written
+type Item struct {
+	// Source identifies where the item came from, e.g. a relative file path
+	Source string
+	Note   *note.Note
+	// Aliases are extra names other items may use to link to this one, such
+	// as a file name or frontmatter aliases
+	Aliases []string
+	// Attachments are files referenced from the content as attachment:<name>
+	Attachments []Attachment
+}
+
+// Attachment is a file belonging to an item
+type Attachment struct {
+	Name string
+	Data []byte
+}
+
+// ItemError records an item that could not be read or written
+type ItemError struct {
+	Source string
+	Err    error
+}
+
+func (e ItemError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Source, e.Err)
+}
+
+// Duplicate is an item whose title matches an existing note or an earlier item
+type Duplicate struct {
+	Item *Item
+	// Existing is the note already in storage, or nil if the duplicate is
+	// another item of the same import
+	Existing *note.Note
+	// Other is the earlier item with the same title, if any
+	Other *Item
+}
+
+// Batch is a set of items read from a source, ready to be reviewed and written
+type Batch struct {
+	Items  []*Item
+	Errors []ItemError
+}
+
+// AddError records an item that could not be read
+func (b *Batch) AddError(source string, err error) {
+	b.Errors = append(b.Errors, ItemError{Source: source, Err: err})
+}
+
+// Duplicates finds items whose title matches an existing note or another item
+func (b *Batch) Duplicates(existing []*note.Note) []Duplicate {
+	byTitle := make(map[string]*note.Note)
+	for _, n := range existing {
+		byTitle[titleKey(n.Title)] = n
+	}
+
+	var duplicates []Duplicate
+	seen := make(map[string]*Item)
+	for _, item := range b.Items {
+		key := titleKey(item.Note.Title)
+		switch {
+		case byTitle[key] != nil:
+			duplicates = append(duplicates, Duplicate{Item: item, Existing: byTitle[key]})
+		case seen[key] != nil:
+			duplicates = append(duplicates, Duplicate{Item: item, Other: seen[key]})
+		default:
+			seen[key] = item
+		}
+	}
+
+	return duplicates
+}
+
+// Without returns a copy of the batch without the given items
+func (b *Batch) Without(duplicates []Duplicate) *Batch {
+	skip := make(map[*Item]bool)
+	for _, d := range duplicates {
+		skip[d.Item] = true
+	}
+
+	filtered := &Batch{Errors: b.Errors}
+	for _, item := range b.Items {
+		if !skip[item] {
+			filtered.Items = append(filtered.Items, item)
+		}
+	}
+	return filtered
+}
+
+// Summary describes a batch for review before it is written
+type Summary struct {
+	Notes     int
+	Tags      []string
+	Notebooks []string
+	// UnresolvedLinks are wikilink targets matching no item or existing note
+	UnresolvedLinks []string
+}
+
+// Summarize describes what writing the batch would do
+func (b *Batch) Summarize(existing []*note.Note) Summary {
+	tags := make(map[string]bool)
+	notebooks := make(map[string]bool)
+	for _, item := range b.Items {
+		for _, tag := range item.Note.Tags {
+			tags[tag] = true
+		}
+		if item.Note.Notebook != "" {
+			notebooks[item.Note.Notebook] = true
+		}
+	}
+
+	names := make(map[string]bool)
+	for _, n := range existing {
+		names[titleKey(n.Title)] = true
+	}
+	for _, item := range b.Items {
+		for _, name := range itemNames(item) {
+			names[titleKey(name)] = true
+		}
+	}
+
+	unresolved := make(map[string]bool)
+	for _, item := range b.Items {
+		for _, link := range findWikilinks(item.Note.Content) {
+			if !names[titleKey(link.Target)] {
+				unresolved[link.Target] = true
+			}
+		}
+	}
+
+	return Summary{
+		Notes:           len(b.Items),
+		Tags:            sortedKeys(tags),
+		Notebooks:       sortedKeys(notebooks),
+		UnresolvedLinks: sortedKeys(unresolved),
+	}
+}
+
+// Result reports the outcome of writing a batch
+type Result struct {
+	Imported []*note.Note
+	Errors   []ItemError
+}
+
+// Progress is called after every item read or written, with the number of
+// items handled so far and the total, or 0 if the total is not known yet
+type Progress func(done, total int)
+
+// Write imports every item of the batch into storage. Items that fail are
+// collected in the result instead of aborting the import. Once all items are
+// written, [[wikilinks]] between them and to existing notes are converted to
+// note links. The import is reported to change hooks as one change.
+func Write(storage *note.Storage, batch *Batch, progress Progress) Result {
+	var result Result
+	storage.Group(func() error {
+		result = write(storage, batch, progress)
+		return nil
+	})
+	return result
+}
+
+func write(storage *note.Storage, batch *Batch, progress Progress) Result {
+	result := Result{Errors: append([]ItemError(nil), batch.Errors...)}
+
+	existing := storage.GetAllNotes()
+
+	written := make(map[*Item]*note.Note)
+	for i, item := range batch.Items {
+		imported, err := storage.ImportNote(item.Note)
+		if err == nil && len(item.Attachments) > 0 {
+			imported, err = writeAttachments(storage, imported, item.Attachments)
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, ItemError{Source: item.Source, Err: err})
+		}
+		if imported != nil {
+			written[item] = imported
+			result.Imported = append(result.Imported, imported)
+		}
+
+		if progress != nil {
+			progress(i+1, len(batch.Items))
+		}
+	}
+
+	// Resolve links by title, preferring the notes of this import
+	ids := make(map[string]int)
+	for _, n := range existing {
+		ids[titleKey(n.Title)] = n.ID
+	}
+	for _, item := range batch.Items {
+		if imported, ok := written[item]; ok {
+			for _, name := range itemNames(item) {
+				ids[titleKey(name)] = imported.ID
+			}
+		}
+	}
+
+	for i, imported := range result.Imported {
+		linked, changed := resolveWikilinks(imported, ids)
+		if !changed {
+			continue
+		}
+
+		saved, err := storage.PutNote(linked)
+		if err != nil {
+			result.Errors = append(result.Errors, ItemError{Source: imported.Title, Err: fmt.Errorf("failed to save links: %w", err)})
+			continue
+		}
+		result.Imported[i] = saved
+	}
+
+	return result
+}
+
+// writeAttachments stores the attachments of an imported note and records
+// them on the note, updating references if a file had to be renamed
+func writeAttachments(storage *note.Storage, imported *note.Note, attachments []Attachment) (*note.Note, error) {
+	updated := imported.Clone()
+	for _, attachment := range attachments {
+		name, err := storage.SaveAttachment(imported.ID, attachment.Name, attachment.Data)
+		if err != nil {
+			return imported, fmt.Errorf("failed to save attachment %s: %w", attachment.Name, err)
+		}
+
+		if name != attachment.Name {
+			updated.Content = strings.ReplaceAll(updated.Content,
+				"("+note.AttachmentPrefix+attachment.Name+")", "("+note.AttachmentPrefix+name+")")
+		}
+		updated.Attachments = append(updated.Attachments, name)
+	}
+
+	saved, err := storage.PutNote(updated)
+	if err != nil {
+		return imported, fmt.Errorf("failed to record attachments: %w", err)
+	}
+	return saved, nil
+}
+
+// itemNames returns the title and aliases of an item
+func itemNames(item *Item) []string {
+	return append([]string{item.Note.Title}, item.Aliases...)
+}
+
+// titleKey normalizes a title for comparisons
+func titleKey(title string) string {
+	return strings.ToLower(strings.TrimSpace(title))
+}
+
+// sortedKeys returns the keys of a set in order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
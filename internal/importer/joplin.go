@@ -0,0 +1,357 @@
+package importer
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+)
+
+// Joplin item types, from the type_ property
+const (
+	joplinNote     = "1"
+	joplinFolder   = "2"
+	joplinResource = "4"
+	joplinTag      = "5"
+	joplinNoteTag  = "6"
+)
+
+// joplinLinkPattern matches Markdown links and images pointing to Joplin
+// items, e.g. ![photo](:/0123456789abcdef0123456789abcdef)
+var joplinLinkPattern = regexp.MustCompile(`(!?)\[([^\]]*)\]\(:/([0-9a-fA-F]{32})\)`)
+
+// joplinPropertyPattern matches a metadata line of a Joplin item
+var joplinPropertyPattern = regexp.MustCompile(`^([a-z_]+): ?(.*)$`)
+
+// joplinItem is a single item of a Joplin export: a note, folder, tag, etc.
+type joplinItem struct {
+	Source string
+	Title  string
+	Body   string
+	Props  map[string]string
+}
+
+// joplinExport holds the items and resource files of a Joplin export
+type joplinExport struct {
+	items []*joplinItem
+	// resources maps resource IDs to a function reading the file
+	resources map[string]func() ([]byte, error)
+	// errors are the files that could not be parsed
+	errors []ItemError
+}
+
+// ReadJoplin reads a Joplin export, either a RAW export directory or a JEX
+// archive. Folders become notebooks, tags and dates are kept, resources
+// become attachments and links between notes become note links. Encrypted
+// items cannot be read and are reported as errors.
+func ReadJoplin(path string, progress Progress) (*Batch, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var export *joplinExport
+	if info.IsDir() {
+		export, err = readJoplinDir(path)
+	} else {
+		export, err = readJoplinArchive(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(export.items) == 0 {
+		return nil, fmt.Errorf("%s is not a Joplin export", path)
+	}
+
+	batch := &Batch{Errors: export.errors}
+	folders := make(map[string]*joplinItem)
+	resources := make(map[string]*joplinItem)
+	titles := make(map[string]string)
+	tags := make(map[string]string)
+	noteTags := make(map[string][]string)
+	var notes []*joplinItem
+
+	for _, item := range export.items {
+		if item.Props["encryption_applied"] == "1" {
+			batch.AddError(item.Source, fmt.Errorf("item is encrypted, disable encryption in Joplin before exporting"))
+			continue
+		}
+
+		id := item.Props["id"]
+		switch item.Props["type_"] {
+		case joplinNote:
+			notes = append(notes, item)
+			titles[id] = item.Title
+		case joplinFolder:
+			folders[id] = item
+		case joplinResource:
+			resources[id] = item
+		case joplinTag:
+			tags[id] = item.Title
+		case joplinNoteTag:
+			noteID := item.Props["note_id"]
+			noteTags[noteID] = append(noteTags[noteID], item.Props["tag_id"])
+		}
+	}
+
+	for i, item := range notes {
+		n := &note.Note{
+			Title:     item.Title,
+			Content:   strings.TrimSpace(item.Body),
+			Notebook:  joplinNotebook(item.Props["parent_id"], folders),
+			CreatedAt: joplinTime(item.Props, "created_time", time.Now()),
+		}
+		n.UpdatedAt = joplinTime(item.Props, "updated_time", n.CreatedAt)
+		if n.UpdatedAt.Before(n.CreatedAt) {
+			n.UpdatedAt = n.CreatedAt
+		}
+		if n.Title == "" {
+			n.Title = "Untitled"
+		}
+
+		for _, tagID := range noteTags[item.Props["id"]] {
+			if tag, ok := tags[tagID]; ok {
+				n.AddTag(tagName(tag))
+			}
+		}
+
+		// Open to-dos keep their due date
+		if item.Props["is_todo"] == "1" && item.Props["todo_completed"] == "0" {
+			if ms, err := strconv.ParseInt(item.Props["todo_due"], 10, 64); err == nil && ms > 0 {
+				due := time.UnixMilli(ms)
+				n.DueAt = &due
+			}
+		}
+
+		converted := &Item{Source: item.Source, Note: n}
+		names := make(map[string]bool)
+		attached := make(map[string]string)
+		n.Content = joplinLinkPattern.ReplaceAllStringFunc(n.Content, func(link string) string {
+			match := joplinLinkPattern.FindStringSubmatch(link)
+			bang, text, id := match[1], match[2], strings.ToLower(match[3])
+
+			if title, ok := titles[id]; ok {
+				// Let the wikilink resolver link notes once they have IDs
+				if title == "" || strings.ContainsAny(title, "[]|#") {
+					return text
+				}
+				return "[[" + title + "|" + text + "]]"
+			}
+
+			resource, ok := resources[id]
+			if !ok {
+				return link
+			}
+
+			name, ok := attached[id]
+			if !ok {
+				data, err := export.readResource(id)
+				if err != nil {
+					batch.AddError(item.Source, fmt.Errorf("failed to read resource %s: %w", id, err))
+					return link
+				}
+				name = uniqueAttachmentName(joplinResourceName(resource), names)
+				attached[id] = name
+				converted.Attachments = append(converted.Attachments, Attachment{Name: name, Data: data})
+			}
+			return bang + "[" + text + "](" + note.AttachmentPrefix + name + ")"
+		})
+
+		if n.Content == "" {
+			batch.AddError(item.Source, fmt.Errorf("note has no content"))
+		} else {
+			batch.Items = append(batch.Items, converted)
+		}
+
+		if progress != nil {
+			progress(i+1, len(notes))
+		}
+	}
+
+	return batch, nil
+}
+
+// readJoplinDir reads a RAW export directory
+func readJoplinDir(dir string) (*joplinExport, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	export := &joplinExport{resources: make(map[string]func() ([]byte, error))}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".md" {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", entry.Name(), err)
+		}
+		export.addItem(entry.Name(), data)
+	}
+
+	resourceDir := filepath.Join(dir, "resources")
+	resourceEntries, err := os.ReadDir(resourceDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read resources: %w", err)
+	}
+	for _, entry := range resourceEntries {
+		file := filepath.Join(resourceDir, entry.Name())
+		export.resources[joplinResourceID(entry.Name())] = func() ([]byte, error) {
+			return os.ReadFile(file)
+		}
+	}
+
+	return export, nil
+}
+
+// readJoplinArchive reads a JEX export, a tar archive of a RAW export
+func readJoplinArchive(file string) (*joplinExport, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	export := &joplinExport{resources: make(map[string]func() ([]byte, error))}
+	archive := tar.NewReader(f)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid JEX archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := path.Clean(strings.TrimPrefix(header.Name, "./"))
+		dir, base := path.Split(name)
+		if dir != "" && dir != "resources/" {
+			continue
+		}
+		if dir == "" && path.Ext(base) != ".md" {
+			continue
+		}
+
+		data, err := io.ReadAll(archive)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+
+		if dir == "resources/" {
+			export.resources[joplinResourceID(base)] = func() ([]byte, error) {
+				return data, nil
+			}
+			continue
+		}
+		export.addItem(base, data)
+	}
+
+	return export, nil
+}
+
+// addItem parses an item file and adds it to the export
+func (e *joplinExport) addItem(source string, data []byte) {
+	item, err := parseJoplinItem(source, string(data))
+	if err != nil {
+		e.errors = append(e.errors, ItemError{Source: source, Err: fmt.Errorf("not a Joplin item: %w", err)})
+		return
+	}
+	e.items = append(e.items, item)
+}
+
+// readResource returns the contents of a resource file
+func (e *joplinExport) readResource(id string) ([]byte, error) {
+	read, ok := e.resources[id]
+	if !ok {
+		return nil, fmt.Errorf("file is missing from the export")
+	}
+	return read()
+}
+
+// parseJoplinItem parses the serialized form of a Joplin item: the title on
+// the first line, the body, and metadata as "key: value" lines at the end
+func parseJoplinItem(source, text string) (*joplinItem, error) {
+	text = strings.TrimRight(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	head, meta := "", text
+	if split := strings.LastIndex(text, "\n\n"); split != -1 {
+		head, meta = text[:split], text[split+2:]
+	}
+
+	item := &joplinItem{Source: source, Props: make(map[string]string)}
+	for _, line := range strings.Split(meta, "\n") {
+		match := joplinPropertyPattern.FindStringSubmatch(line)
+		if match == nil {
+			return nil, fmt.Errorf("invalid metadata line %q", line)
+		}
+		item.Props[match[1]] = strings.ReplaceAll(match[2], `\n`, "\n")
+	}
+	if item.Props["type_"] == "" {
+		return nil, fmt.Errorf("missing type_")
+	}
+
+	title, body, _ := strings.Cut(head, "\n")
+	item.Title = strings.TrimSpace(title)
+	item.Body = strings.TrimSpace(body)
+	return item, nil
+}
+
+// joplinNotebook returns the path of a folder and its parents
+func joplinNotebook(id string, folders map[string]*joplinItem) string {
+	var parts []string
+	seen := make(map[string]bool)
+	for id != "" && !seen[id] {
+		folder, ok := folders[id]
+		if !ok {
+			break
+		}
+		seen[id] = true
+		parts = append([]string{strings.ReplaceAll(folder.Title, "/", "-")}, parts...)
+		id = folder.Props["parent_id"]
+	}
+	return strings.Join(parts, "/")
+}
+
+// joplinTime returns a time property, preferring the user_ variant which
+// holds the time as edited by the user
+func joplinTime(props map[string]string, key string, fallback time.Time) time.Time {
+	for _, k := range []string{"user_" + key, key} {
+		if t, err := time.Parse(time.RFC3339Nano, props[k]); err == nil {
+			return t.Local()
+		}
+	}
+	return fallback
+}
+
+// joplinResourceName returns the file name of a resource
+func joplinResourceName(resource *joplinItem) string {
+	for _, name := range []string{resource.Props["filename"], resource.Title} {
+		if strings.TrimSpace(name) != "" {
+			return name
+		}
+	}
+
+	name := resource.Props["id"]
+	if ext := resource.Props["file_extension"]; ext != "" {
+		name += "." + ext
+	}
+	return name
+}
+
+// joplinResourceID returns the resource ID of a file in the resources folder
+func joplinResourceID(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, path.Ext(name)))
+}
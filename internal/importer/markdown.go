@@ -0,0 +1,244 @@
+package importer
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+	"gopkg.in/yaml.v3"
+)
+
+// Ways of mapping the folders of a Markdown tree onto notes
+const (
+	FoldersNotebook = "notebook"
+	FoldersTag      = "tag"
+	FoldersNone     = "none"
+)
+
+// hashtagPattern matches #tags and nested #tags/like/this. Headings are not
+// matched because they are followed by a space.
+var hashtagPattern = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_][\p{L}\p{N}_/-]*)`)
+
+// dateLayouts are the formats accepted for frontmatter dates given as strings
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// MarkdownOptions control how a Markdown tree is read
+type MarkdownOptions struct {
+	// Folders is one of FoldersNotebook, FoldersTag or FoldersNone
+	Folders string
+}
+
+// ReadMarkdown reads every Markdown file below dir, such as a plain notes
+// folder or an Obsidian vault. YAML frontmatter provides the title, tags,
+// aliases and dates; #hashtags in the text are added to the tags, and file
+// modification times are used when no dates are given.
+func ReadMarkdown(dir string, opts MarkdownOptions) (*Batch, error) {
+	switch opts.Folders {
+	case "":
+		opts.Folders = FoldersNotebook
+	case FoldersNotebook, FoldersTag, FoldersNone:
+	default:
+		return nil, fmt.Errorf("invalid folder mapping %q (use %s, %s or %s)", opts.Folders, FoldersNotebook, FoldersTag, FoldersNone)
+	}
+
+	batch := &Batch{}
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			batch.AddError(path, err)
+			return nil
+		}
+
+		// Skip hidden folders such as .obsidian, .git and .trash
+		if entry.IsDir() {
+			if path != dir && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		ext := strings.ToLower(filepath.Ext(path))
+		if ext != ".md" && ext != ".markdown" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			rel = path
+		}
+		rel = filepath.ToSlash(rel)
+
+		item, err := readMarkdownFile(path, rel, opts)
+		if err != nil {
+			batch.AddError(rel, err)
+			return nil
+		}
+		batch.Items = append(batch.Items, item)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	return batch, nil
+}
+
+// readMarkdownFile converts a single Markdown file into an item
+func readMarkdownFile(path, rel string, opts MarkdownOptions) (*Item, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	frontmatter, body, err := splitFrontmatter(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	stem := strings.TrimSuffix(filepath.Base(rel), filepath.Ext(rel))
+	n := &note.Note{
+		Title:     stem,
+		Content:   strings.TrimSpace(body),
+		CreatedAt: info.ModTime(),
+		UpdatedAt: info.ModTime(),
+	}
+	if n.Content == "" {
+		return nil, fmt.Errorf("note has no content")
+	}
+
+	item := &Item{Source: rel, Note: n}
+
+	if title, ok := frontmatter["title"].(string); ok && strings.TrimSpace(title) != "" {
+		n.Title = strings.TrimSpace(title)
+		// Other notes may still link to the file name
+		item.Aliases = append(item.Aliases, stem)
+	}
+
+	for _, key := range []string{"tags", "tag"} {
+		for _, tags := range stringList(frontmatter[key]) {
+			for _, tag := range strings.Fields(tags) {
+				n.AddTag(strings.TrimPrefix(tag, "#"))
+			}
+		}
+	}
+
+	for _, key := range []string{"aliases", "alias"} {
+		item.Aliases = append(item.Aliases, stringList(frontmatter[key])...)
+	}
+
+	for _, key := range []string{"created", "created_at", "date"} {
+		if t, ok := parseDate(frontmatter[key]); ok {
+			n.CreatedAt = t
+			break
+		}
+	}
+	for _, key := range []string{"updated", "updated_at", "modified"} {
+		if t, ok := parseDate(frontmatter[key]); ok {
+			n.UpdatedAt = t
+			break
+		}
+	}
+	if n.UpdatedAt.Before(n.CreatedAt) {
+		n.UpdatedAt = n.CreatedAt
+	}
+
+	eachOutsideCode(n.Content, func(text string) {
+		for _, match := range hashtagPattern.FindAllStringSubmatch(text, -1) {
+			if strings.Trim(match[1], "0123456789") != "" {
+				n.AddTag(match[1])
+			}
+		}
+	})
+
+	if folder := filepath.ToSlash(filepath.Dir(rel)); folder != "." {
+		switch opts.Folders {
+		case FoldersNotebook:
+			n.Notebook = folder
+		case FoldersTag:
+			n.AddTag(folder)
+		}
+	}
+
+	return item, nil
+}
+
+// splitFrontmatter separates YAML frontmatter delimited by --- lines from the
+// rest of a Markdown document
+func splitFrontmatter(content string) (map[string]interface{}, string, error) {
+	content = strings.TrimPrefix(content, "\ufeff")
+	normalized := strings.ReplaceAll(content, "\r\n", "\n")
+	if !strings.HasPrefix(normalized, "---\n") {
+		return nil, content, nil
+	}
+
+	rest := normalized[len("---\n"):]
+	end := strings.Index(rest, "\n---")
+	if end == -1 {
+		return nil, content, nil
+	}
+
+	var frontmatter map[string]interface{}
+	if err := yaml.Unmarshal([]byte(rest[:end]), &frontmatter); err != nil {
+		return nil, "", fmt.Errorf("invalid frontmatter: %w", err)
+	}
+
+	body := rest[end+len("\n---"):]
+	if newline := strings.Index(body, "\n"); newline != -1 {
+		body = body[newline+1:]
+	} else {
+		body = ""
+	}
+
+	return frontmatter, body, nil
+}
+
+// stringList converts a frontmatter value given as a list or as a comma
+// separated string into a list of strings
+func stringList(value interface{}) []string {
+	var list []string
+	switch v := value.(type) {
+	case string:
+		for _, part := range strings.Split(v, ",") {
+			if strings.TrimSpace(part) != "" {
+				list = append(list, strings.TrimSpace(part))
+			}
+		}
+	case []interface{}:
+		for _, element := range v {
+			if s, ok := element.(string); ok && strings.TrimSpace(s) != "" {
+				list = append(list, strings.TrimSpace(s))
+			}
+		}
+	}
+	return list
+}
+
+// parseDate converts a frontmatter date value
+func parseDate(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		for _, layout := range dateLayouts {
+			if t, err := time.ParseInLocation(layout, strings.TrimSpace(v), time.Local); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
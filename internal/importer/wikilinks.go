@@ -0,0 +1,144 @@
+package importer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+)
+
+// wikilinkPattern matches [[Target]], [[Target|Alias]] and [[Target#Heading]].
+// Embeds such as ![[image.png]] match too and are skipped with isEmbed.
+var wikilinkPattern = regexp.MustCompile(`\[\[([^\[\]|#]+)(#[^\[\]|]*)?(\|[^\[\]]*)?\]\]`)
+
+// wikilink is a parsed [[wikilink]]
+type wikilink struct {
+	Target  string
+	Heading string
+	Alias   string
+}
+
+// Text returns the text a wikilink is displayed as
+func (l wikilink) Text() string {
+	switch {
+	case l.Alias != "":
+		return l.Alias
+	case l.Heading != "":
+		return l.Target + "#" + l.Heading
+	default:
+		return l.Target
+	}
+}
+
+// parseWikilink parses the submatches of wikilinkPattern in text, given as
+// index pairs
+func parseWikilink(text string, match []int) wikilink {
+	group := func(i int) string {
+		if match[2*i] < 0 {
+			return ""
+		}
+		return text[match[2*i]:match[2*i+1]]
+	}
+	return wikilink{
+		Target:  strings.TrimSpace(group(1)),
+		Heading: strings.TrimSpace(strings.TrimPrefix(group(2), "#")),
+		Alias:   strings.TrimSpace(strings.TrimPrefix(group(3), "|")),
+	}
+}
+
+// isEmbed reports whether the match of wikilinkPattern at start is an embed
+func isEmbed(text string, start int) bool {
+	return start > 0 && text[start-1] == '!'
+}
+
+// findWikilinks returns the wikilinks outside code in content
+func findWikilinks(content string) []wikilink {
+	var links []wikilink
+	eachOutsideCode(content, func(text string) {
+		for _, match := range wikilinkPattern.FindAllStringSubmatchIndex(text, -1) {
+			if !isEmbed(text, match[0]) {
+				links = append(links, parseWikilink(text, match))
+			}
+		}
+	})
+	return links
+}
+
+// resolveWikilinks converts the wikilinks of a note whose target is in ids to
+// Markdown note links and records them as links of the note. Unresolved
+// wikilinks are left as they are. It reports whether anything changed.
+func resolveWikilinks(n *note.Note, ids map[string]int) (*note.Note, bool) {
+	linked := n.Clone()
+	changed := false
+
+	linked.Content = mapOutsideCode(n.Content, func(text string) string {
+		var result strings.Builder
+		last := 0
+		for _, match := range wikilinkPattern.FindAllStringSubmatchIndex(text, -1) {
+			if isEmbed(text, match[0]) {
+				continue
+			}
+			link := parseWikilink(text, match)
+			id, ok := ids[titleKey(link.Target)]
+			if !ok {
+				continue
+			}
+
+			changed = true
+			linked.AddLink(id)
+			result.WriteString(text[last:match[0]])
+			fmt.Fprintf(&result, "[%s](%s)", link.Text(), note.LinkTarget(id))
+			last = match[1]
+		}
+		result.WriteString(text[last:])
+		return result.String()
+	})
+
+	return linked, changed
+}
+
+// eachOutsideCode calls fn for every part of Markdown content that is not in
+// a fenced code block
+func eachOutsideCode(content string, fn func(text string)) {
+	mapOutsideCode(content, func(text string) string {
+		fn(text)
+		return text
+	})
+}
+
+// mapOutsideCode replaces every part of Markdown content that is not in a
+// fenced code block with the result of fn
+func mapOutsideCode(content string, fn func(text string) string) string {
+	var (
+		result  strings.Builder
+		pending strings.Builder
+		inCode  bool
+	)
+
+	flush := func() {
+		result.WriteString(fn(pending.String()))
+		pending.Reset()
+	}
+
+	for _, line := range strings.SplitAfter(content, "\n") {
+		isFence := strings.HasPrefix(strings.TrimSpace(line), "```")
+
+		switch {
+		case inCode:
+			result.WriteString(line)
+			if isFence {
+				inCode = false
+			}
+		case isFence:
+			flush()
+			result.WriteString(line)
+			inCode = true
+		default:
+			pending.WriteString(line)
+		}
+	}
+	flush()
+
+	return result.String()
+}
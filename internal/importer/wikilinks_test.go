@@ -0,0 +1,48 @@
+package importer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+)
+
+func TestFindWikilinks(t *testing.T) {
+	content := "[[A]][[B|Bee]] and ![[image.png]] then [[C#Usage]]\n" +
+		"```\n[[In code]]\n```\n" +
+		"[[D]]"
+
+	want := []wikilink{
+		{Target: "A"},
+		{Target: "B", Alias: "Bee"},
+		{Target: "C", Heading: "Usage"},
+		{Target: "D"},
+	}
+	if got := findWikilinks(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("findWikilinks() = %+v, want %+v", got, want)
+	}
+}
+
+func TestResolveWikilinks(t *testing.T) {
+	n := &note.Note{ID: 1, Title: "Index", Content: "[[Go]][[rust|Rust]], ![[Go]] and [[Missing]]"}
+	ids := map[string]int{"go": 2, "rust": 3}
+
+	linked, changed := resolveWikilinks(n, ids)
+	if !changed {
+		t.Fatal("resolveWikilinks() changed = false")
+	}
+	want := "[Go](note:2)[Rust](note:3), ![[Go]] and [[Missing]]"
+	if linked.Content != want {
+		t.Errorf("content = %q, want %q", linked.Content, want)
+	}
+	if !reflect.DeepEqual(linked.Links, []int{2, 3}) {
+		t.Errorf("links = %v, want [2 3]", linked.Links)
+	}
+	if n.Content == linked.Content {
+		t.Error("resolveWikilinks() modified the original note")
+	}
+
+	if _, changed := resolveWikilinks(&note.Note{Content: "[[Missing]]"}, ids); changed {
+		t.Error("resolveWikilinks() without known targets changed = true")
+	}
+}
@@ -0,0 +1,324 @@
+// Package journal records the changes made to the notes so they can be
+// undone and redone, also by later processes.
+package journal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+)
+
+// fileName is the name of the journal file inside the meta directory
+const fileName = "journal.json"
+
+// MaxEntries is the number of operations the journal keeps; older ones can
+// no longer be undone
+const MaxEntries = 100
+
+var (
+	// ErrNothingToUndo is returned by Undo when every operation in the
+	// journal is undone already
+	ErrNothingToUndo = errors.New("nothing to undo")
+	// ErrNothingToRedo is returned by Redo when no operation was undone, or
+	// a change was made after undoing
+	ErrNothingToRedo = errors.New("nothing to redo")
+)
+
+// Entry is one operation in the journal, such as tagging a note or a bulk
+// change of several notes
+type Entry struct {
+	// ID numbers the operations in the order they were made
+	ID      int       `json:"id"`
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+	// Notes holds every note the operation changed, before and after
+	Notes []Version `json:"notes"`
+	// Undone is set once the operation is undone, until it is redone
+	Undone bool `json:"undone,omitempty"`
+}
+
+// Version is a note before and after an operation; nil means the note did
+// not exist
+type Version struct {
+	ID     int        `json:"id"`
+	Before *note.Note `json:"before"`
+	After  *note.Note `json:"after"`
+}
+
+// NoteIDs returns the IDs of the notes the operation changed
+func (e Entry) NoteIDs() []int {
+	ids := make([]int, len(e.Notes))
+	for i, version := range e.Notes {
+		ids[i] = version.ID
+	}
+	return ids
+}
+
+// Journal keeps the last operations made to a notes directory in a file
+// inside its meta directory
+type Journal struct {
+	mu      sync.Mutex
+	path    string
+	storage *note.Storage
+}
+
+// Open records every change saved through storage from now on, except
+// changes to settings. Grouped changes, such as an import, are one entry.
+// Encrypted notes are left out, and encrypting a note removes the operations
+// on it, so their contents never end up in the journal file in plain text.
+// Errors saving the journal are passed to onError and never undo the change
+// itself.
+func Open(storage *note.Storage, onError func(err error)) *Journal {
+	j := &Journal{
+		path:    filepath.Join(storage.MetaDir(), fileName),
+		storage: storage,
+	}
+	storage.OnChange(func(change note.Change) {
+		if err := j.record(change); err != nil && onError != nil {
+			onError(fmt.Errorf("failed to record %q: %w", change.Message(), err))
+		}
+	})
+	return j
+}
+
+// Entries returns the operations in the journal, oldest first
+func (j *Journal) Entries() ([]Entry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.load()
+}
+
+// Undo undoes the last n operations that are not undone yet, newest first,
+// and returns them. It stops at the first operation that cannot be undone,
+// such as one whose notes changed since in a way the journal did not record.
+func (j *Journal) Undo(n int) ([]Entry, error) {
+	return j.replay(n, true)
+}
+
+// Redo redoes the last n operations undone, in the order they were made,
+// and returns them
+func (j *Journal) Redo(n int) ([]Entry, error) {
+	return j.replay(n, false)
+}
+
+func (j *Journal) replay(n int, undo bool) ([]Entry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entries, err := j.load()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Entry
+	for len(done) < n {
+		index := next(entries, undo)
+		if index < 0 {
+			break
+		}
+		entry := &entries[index]
+
+		if undo {
+			err = j.storage.Revert(note.ActionUndo, entry.Message, revisions(entry.Notes, true))
+		} else {
+			err = j.storage.Revert(note.ActionRedo, entry.Message, revisions(entry.Notes, false))
+		}
+		if err != nil {
+			verb := "redo"
+			if undo {
+				verb = "undo"
+			}
+			err = fmt.Errorf("failed to %s '%s': %w", verb, entry.Message, err)
+			break
+		}
+
+		entry.Undone = undo
+		done = append(done, *entry)
+	}
+
+	if len(done) > 0 {
+		if saveErr := j.save(entries); saveErr != nil && err == nil {
+			err = saveErr
+		}
+	}
+	switch {
+	case err != nil:
+		return done, err
+	case len(done) == 0 && undo:
+		return nil, ErrNothingToUndo
+	case len(done) == 0:
+		return nil, ErrNothingToRedo
+	}
+	return done, nil
+}
+
+// next returns the index of the entry to undo or redo next, or -1
+func next(entries []Entry, undo bool) int {
+	if undo {
+		for i := len(entries) - 1; i >= 0; i-- {
+			if !entries[i].Undone {
+				return i
+			}
+		}
+		return -1
+	}
+
+	for i, entry := range entries {
+		if entry.Undone {
+			return i
+		}
+	}
+	return -1
+}
+
+// revisions turns the versions of an entry into the revisions undoing it,
+// last note first, or redoing it
+func revisions(versions []Version, undo bool) []note.Revision {
+	result := make([]note.Revision, len(versions))
+	for i, version := range versions {
+		if undo {
+			result[len(versions)-1-i] = note.Revision{ID: version.ID, From: version.After, To: version.Before}
+		} else {
+			result[i] = note.Revision{ID: version.ID, From: version.Before, To: version.After}
+		}
+	}
+	return result
+}
+
+// record adds a change to the journal as a new entry
+func (j *Journal) record(change note.Change) error {
+	switch change.Action {
+	case note.ActionUndo, note.ActionRedo, note.ActionSettings:
+		return nil
+	}
+
+	// A note changed several times in a group, such as imported and then
+	// linked, is one version from the first state to the last
+	var versions []Version
+	index := make(map[int]int)
+	encrypted := make(map[int]bool)
+	for _, c := range append([]note.Change{change}, change.Others...) {
+		if c.ID == 0 {
+			continue
+		}
+		if (c.Before != nil && c.Before.Encrypted) || (c.After != nil && c.After.Encrypted) {
+			encrypted[c.ID] = true
+		}
+		if i, ok := index[c.ID]; ok {
+			versions[i].After = c.After
+			continue
+		}
+		index[c.ID] = len(versions)
+		versions = append(versions, Version{ID: c.ID, Before: c.Before, After: c.After})
+	}
+	// Notes added and deleted again in the group are left out, and so are
+	// encrypted notes, whose versions must not be kept in plain text
+	kept := versions[:0]
+	for _, version := range versions {
+		if (version.Before != nil || version.After != nil) && !encrypted[version.ID] {
+			kept = append(kept, version)
+		}
+	}
+	versions = kept
+	if len(versions) == 0 && len(encrypted) == 0 {
+		return nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entries, err := j.load()
+	if err != nil {
+		return err
+	}
+
+	// Earlier operations on encrypted notes are dropped, so their earlier
+	// versions no longer appear in the journal either
+	entries, forgotten := forget(entries, encrypted)
+	if len(versions) == 0 {
+		if !forgotten {
+			return nil
+		}
+		return j.save(entries)
+	}
+
+	id := 1
+	if len(entries) > 0 {
+		id = entries[len(entries)-1].ID + 1
+	}
+	// A new operation replaces the ones that were undone
+	for len(entries) > 0 && entries[len(entries)-1].Undone {
+		entries = entries[:len(entries)-1]
+	}
+	entries = append(entries, Entry{ID: id, Time: time.Now(), Message: change.Message(), Notes: versions})
+	if len(entries) > MaxEntries {
+		entries = entries[len(entries)-MaxEntries:]
+	}
+	return j.save(entries)
+}
+
+// forget removes the operations on the given notes and reports whether any
+// was removed
+func forget(entries []Entry, ids map[int]bool) ([]Entry, bool) {
+	if len(ids) == 0 {
+		return entries, false
+	}
+
+	kept := entries[:0]
+	for _, entry := range entries {
+		mentioned := false
+		for _, version := range entry.Notes {
+			mentioned = mentioned || ids[version.ID]
+		}
+		if !mentioned {
+			kept = append(kept, entry)
+		}
+	}
+	return kept, len(kept) != len(entries)
+}
+
+// load reads the journal file; callers must hold j.mu
+func (j *Journal) load() ([]Entry, error) {
+	data, err := os.ReadFile(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal journal: %w", err)
+	}
+	return entries, nil
+}
+
+// save writes the journal file; callers must hold j.mu
+func (j *Journal) save(entries []Entry) error {
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to marshal journal: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(j.path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(j.path), err)
+	}
+
+	// Write to a temporary file first, so other processes never read half a
+	// journal
+	tmp := j.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	if err := os.Rename(tmp, j.path); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+package journal
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+)
+
+// open returns a journal of a new notes directory
+func open(t *testing.T) (*note.Storage, *Journal) {
+	t.Helper()
+	storage, err := note.NewStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	j := Open(storage, func(err error) {
+		t.Errorf("journal error: %v", err)
+	})
+	return storage, j
+}
+
+// titles returns the titles of the notes, with their tags, sorted
+func titles(storage *note.Storage) []string {
+	var result []string
+	for _, n := range storage.GetAllNotes() {
+		title := n.Title
+		for _, tag := range n.Tags {
+			title += " #" + tag
+		}
+		if n.IsArchived {
+			title += " (archived)"
+		}
+		result = append(result, title)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func TestUndoRedoRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(t *testing.T, storage *note.Storage)
+		want   []string
+	}{
+		{
+			name: "create",
+			change: func(t *testing.T, storage *note.Storage) {
+				if _, err := storage.CreateNote("New", "text", nil); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: []string{"Go", "New", "Rust"},
+		},
+		{
+			name: "tag",
+			change: func(t *testing.T, storage *note.Storage) {
+				if _, err := storage.AddTag(1, "lang"); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: []string{"Go #lang", "Rust"},
+		},
+		{
+			name: "delete",
+			change: func(t *testing.T, storage *note.Storage) {
+				if err := storage.DeleteNote(2); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: []string{"Go"},
+		},
+		{
+			name: "bulk",
+			change: func(t *testing.T, storage *note.Storage) {
+				if _, err := storage.ApplyBatch([]int{1, 2}, note.BatchOperation{Name: note.BatchArchive}); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: []string{"Go (archived)", "Rust (archived)"},
+		},
+		{
+			name: "group",
+			change: func(t *testing.T, storage *note.Storage) {
+				err := storage.Group(func() error {
+					n, err := storage.ImportNote(&note.Note{Title: "Imported", Content: "text"})
+					if err != nil {
+						return err
+					}
+					if _, err := storage.AddTag(n.ID, "new"); err != nil {
+						return err
+					}
+					return storage.DeleteNote(1)
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: []string{"Imported #new", "Rust"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage, j := open(t)
+			for _, title := range []string{"Go", "Rust"} {
+				if _, err := storage.CreateNote(title, "text", nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+			before := titles(storage)
+
+			tt.change(t, storage)
+			if got := titles(storage); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("after the change: %v, want %v", got, tt.want)
+			}
+			entries, err := j.Entries()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 3 {
+				t.Fatalf("journal has %d entries, want 3", len(entries))
+			}
+
+			if _, err := j.Undo(1); err != nil {
+				t.Fatalf("Undo: %v", err)
+			}
+			if got := titles(storage); !reflect.DeepEqual(got, before) {
+				t.Errorf("after undo: %v, want %v", got, before)
+			}
+
+			if _, err := j.Redo(1); err != nil {
+				t.Fatalf("Redo: %v", err)
+			}
+			if got := titles(storage); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("after redo: %v, want %v", got, tt.want)
+			}
+			if _, err := j.Redo(1); !errors.Is(err, ErrNothingToRedo) {
+				t.Errorf("second Redo error = %v, want ErrNothingToRedo", err)
+			}
+		})
+	}
+}
+
+func TestUndoSeveral(t *testing.T) {
+	storage, j := open(t)
+	if _, err := storage.CreateNote("Go", "text", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := storage.AddTag(1, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := storage.AddTag(1, "b"); err != nil {
+		t.Fatal(err)
+	}
+
+	done, err := j.Undo(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 2 || done[0].ID != 3 || done[1].ID != 2 {
+		t.Errorf("Undo(2) undid %v, want entries 3 and 2", done)
+	}
+	if got := titles(storage); !reflect.DeepEqual(got, []string{"Go"}) {
+		t.Errorf("after undo: %v", got)
+	}
+
+	// A new change drops the operations that were undone
+	if _, err := storage.AddTag(1, "c"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := j.Redo(1); !errors.Is(err, ErrNothingToRedo) {
+		t.Errorf("Redo after a new change error = %v, want ErrNothingToRedo", err)
+	}
+
+	if _, err := j.Undo(5); err != nil {
+		t.Fatal(err)
+	}
+	if notes := storage.GetAllNotes(); len(notes) != 0 {
+		t.Errorf("%d notes left after undoing everything", len(notes))
+	}
+	if _, err := j.Undo(1); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("Undo with nothing left error = %v, want ErrNothingToUndo", err)
+	}
+}
+
+func TestUndoConflict(t *testing.T) {
+	storage, j := open(t)
+	if _, err := storage.CreateNote("Go", "text", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := storage.AddTag(1, "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A change the journal does not see, such as another copy of the notes
+	// directory writing the file
+	other, err := note.NewStorage(storage.Dir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.UpdateNote(1, "Go", "edited elsewhere", []string{"a"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := j.Undo(1); !errors.Is(err, note.ErrConflict) {
+		t.Fatalf("Undo error = %v, want ErrConflict", err)
+	}
+	n, err := storage.GetNote(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.Content != "edited elsewhere" {
+		t.Errorf("content = %q, the conflicting undo changed the note", n.Content)
+	}
+}
+
+func TestGroupWithEncryptedNote(t *testing.T) {
+	storage, j := open(t)
+	for _, title := range []string{"Go", "Rust"} {
+		if _, err := storage.CreateNote(title, "text", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := storage.SetupEncryption("secret"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := storage.EncryptNote(2); err != nil {
+		t.Fatal(err)
+	}
+
+	err := storage.Group(func() error {
+		for id := 1; id <= 2; id++ {
+			if _, err := storage.AddTag(id, "lang"); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The group is kept for the plain note, and nothing mentions the
+	// encrypted one
+	entries, err := j.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := entries[len(entries)-1]
+	if !strings.HasPrefix(last.Message, "Tag") || !reflect.DeepEqual(last.NoteIDs(), []int{1}) {
+		t.Fatalf("last entry = %q on notes %v, want the tags on note 1", last.Message, last.NoteIDs())
+	}
+	for _, entry := range entries {
+		for _, id := range entry.NoteIDs() {
+			if id == 2 {
+				t.Errorf("entry %d %q mentions the encrypted note", entry.ID, entry.Message)
+			}
+		}
+	}
+
+	if _, err := j.Undo(1); err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	if got := titles(storage); !reflect.DeepEqual(got, []string{"Go", "Rust #lang"}) {
+		t.Errorf("after undo: %v, want the tag removed from Go only", got)
+	}
+}
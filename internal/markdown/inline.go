@@ -0,0 +1,291 @@
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	autolinkPattern = regexp.MustCompile(`^<((?:https?|mailto|ftp):[^\s<>]+)>`)
+	bareURLPattern  = regexp.MustCompile(`^https?://[^\s<]*[^\s<.,:;"')\]*_~]`)
+	unsafeScheme    = regexp.MustCompile(`(?i)^\s*(javascript|vbscript|data):`)
+)
+
+// inline renders the inline content of a block
+func (r *Renderer) inline(text string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(text); {
+		c := text[i]
+		rest := text[i:]
+
+		switch {
+		case c == '\\' && i+1 < len(text) && strings.IndexByte("\\`*_{}[]()#+-.!|~<>\"'", text[i+1]) >= 0:
+			b.WriteString(html.EscapeString(text[i+1 : i+2]))
+			i += 2
+			continue
+
+		case c == '`':
+			if rendered, n := codeSpan(rest); n > 0 {
+				b.WriteString(rendered)
+				i += n
+				continue
+			}
+
+		case c == '!' && strings.HasPrefix(rest, "!["):
+			if label, dest, title, n := parseLink(rest[1:]); n > 0 {
+				b.WriteString(r.image(label, dest, title))
+				i += n + 1
+				continue
+			}
+
+		case c == '[':
+			if label, dest, title, n := parseLink(rest); n > 0 {
+				b.WriteString(r.link(r.inline(label), dest, title))
+				i += n
+				continue
+			}
+
+		case c == '<':
+			if match := autolinkPattern.FindStringSubmatch(rest); match != nil {
+				b.WriteString(r.link(html.EscapeString(match[1]), match[1], ""))
+				i += len(match[0])
+				continue
+			}
+
+		case c == 'h' && (i == 0 || !isWordByte(text[i-1])):
+			if url := bareURLPattern.FindString(rest); url != "" {
+				b.WriteString(r.link(html.EscapeString(url), url, ""))
+				i += len(url)
+				continue
+			}
+
+		case c == '*' || c == '_' || c == '~':
+			if rendered, n := r.emphasis(text, i); n > 0 {
+				b.WriteString(rendered)
+				i += n
+				continue
+			}
+		}
+
+		// Copy the next character, escaping HTML
+		_, size := utf8.DecodeRuneInString(rest)
+		b.WriteString(html.EscapeString(rest[:size]))
+		i += size
+	}
+
+	return b.String()
+}
+
+// codeSpan renders a code span starting at the beginning of text and returns
+// the number of bytes it used, or 0 if there is no closing backtick run
+func codeSpan(text string) (string, int) {
+	run := len(text) - len(strings.TrimLeft(text, "`"))
+	fence := text[:run]
+
+	for j := run; j < len(text); {
+		k := strings.Index(text[j:], fence)
+		if k == -1 {
+			return "", 0
+		}
+		start := j + k
+		end := start + run
+		// The closing run must be exactly as long as the opening one
+		if end < len(text) && text[end] == '`' {
+			j = end + len(text[end:]) - len(strings.TrimLeft(text[end:], "`"))
+			continue
+		}
+
+		code := strings.ReplaceAll(text[run:start], "\n", " ")
+		if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.TrimSpace(code) != "" {
+			code = code[1 : len(code)-1]
+		}
+		return "<code>" + html.EscapeString(code) + "</code>", end
+	}
+	return "", 0
+}
+
+// parseLink parses [label](dest "title") at the start of text and returns
+// the number of bytes it used, or 0 if text does not start with a link
+func parseLink(text string) (label, dest, title string, n int) {
+	depth := 0
+	closing := -1
+	for j := 0; j < len(text) && closing == -1; j++ {
+		switch text[j] {
+		case '\\':
+			j++
+		case '`':
+			if _, skip := codeSpan(text[j:]); skip > 0 {
+				j += skip - 1
+			}
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				closing = j
+			}
+		}
+	}
+	if closing == -1 || closing+1 >= len(text) || text[closing+1] != '(' {
+		return "", "", "", 0
+	}
+
+	rest := text[closing+2:]
+	end := -1
+	parens := 0
+	inAngle := strings.HasPrefix(rest, "<")
+	for j := 0; j < len(rest) && end == -1; j++ {
+		switch {
+		case rest[j] == '\\':
+			j++
+		case inAngle && rest[j] == '>':
+			inAngle = false
+		case inAngle:
+		case rest[j] == '(':
+			parens++
+		case rest[j] == ')':
+			if parens == 0 {
+				end = j
+			}
+			parens--
+		case rest[j] == '\n':
+			return "", "", "", 0
+		}
+	}
+	if end == -1 {
+		return "", "", "", 0
+	}
+
+	inner := strings.TrimSpace(rest[:end])
+	if strings.HasPrefix(inner, "<") {
+		if close := strings.Index(inner, ">"); close != -1 {
+			dest, inner = inner[1:close], strings.TrimSpace(inner[close+1:])
+		}
+	} else if space := strings.IndexAny(inner, " \t"); space != -1 {
+		dest, inner = inner[:space], strings.TrimSpace(inner[space:])
+	} else {
+		dest, inner = inner, ""
+	}
+
+	if len(inner) >= 2 && strings.ContainsRune(`"'(`, rune(inner[0])) {
+		title = inner[1 : len(inner)-1]
+	}
+
+	return text[1:closing], dest, title, closing + 2 + end + 1
+}
+
+// link renders a link, with the label already rendered as HTML
+func (r *Renderer) link(label, dest, title string) string {
+	href, ok := r.destination(dest)
+	if !ok {
+		return label
+	}
+
+	attrs := ` href="` + html.EscapeString(href) + `"`
+	if title != "" {
+		attrs += ` title="` + html.EscapeString(title) + `"`
+	}
+	return "<a" + attrs + ">" + label + "</a>"
+}
+
+// image renders an image, using the label as alternative text
+func (r *Renderer) image(label, dest, title string) string {
+	src, ok := r.destination(dest)
+	if !ok {
+		return html.EscapeString(label)
+	}
+
+	attrs := ` src="` + html.EscapeString(src) + `" alt="` + html.EscapeString(plainLabel(label)) + `"`
+	if title != "" {
+		attrs += ` title="` + html.EscapeString(title) + `"`
+	}
+	return "<img" + attrs + ">"
+}
+
+// destination applies the link hook and neutralizes unsafe schemes
+func (r *Renderer) destination(dest string) (string, bool) {
+	if r.Link != nil {
+		var ok bool
+		if dest, ok = r.Link(dest); !ok {
+			return "", false
+		}
+	}
+	if unsafeScheme.MatchString(dest) {
+		return "#", true
+	}
+	return dest, true
+}
+
+// emphasis renders *em*, **strong**, _em_, __strong__ or ~~strikethrough~~
+// starting at text[i] and returns the number of bytes used, or 0
+func (r *Renderer) emphasis(text string, i int) (string, int) {
+	c := text[i]
+	run := 1
+	for i+run < len(text) && text[i+run] == c {
+		run++
+	}
+
+	var marker, tag string
+	switch {
+	case c == '~' && run == 2:
+		marker, tag = "~~", "del"
+	case c != '~' && run >= 2:
+		marker, tag = string([]byte{c, c}), "strong"
+	case c != '~':
+		marker, tag = string(c), "em"
+	default:
+		return "", 0
+	}
+
+	// Underscores inside words are literal, as in snake_case
+	if c == '_' && i > 0 && isWordByte(text[i-1]) {
+		return "", 0
+	}
+
+	start := i + len(marker)
+	if start >= len(text) || text[start] == ' ' {
+		return "", 0
+	}
+
+	for j := start + 1; j <= len(text)-len(marker); j++ {
+		if text[j] == '`' {
+			if _, skip := codeSpan(text[j:]); skip > 0 {
+				j += skip - 1
+				continue
+			}
+		}
+		if !strings.HasPrefix(text[j:], marker) || text[j-1] == ' ' {
+			continue
+		}
+		// A single marker must not be part of a double one
+		after := j + len(marker)
+		if len(marker) == 1 && after < len(text) && text[after] == c {
+			j++
+			continue
+		}
+		if c == '_' && after < len(text) && isWordByte(text[after]) {
+			continue
+		}
+		return "<" + tag + ">" + r.inline(text[start:j]) + "</" + tag + ">", after - i
+	}
+
+	return "", 0
+}
+
+// plainLabel strips Markdown emphasis characters from a label for alt text
+func plainLabel(label string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune("*_`~", r) {
+			return -1
+		}
+		return r
+	}, label)
+}
+
+func isWordByte(c byte) bool {
+	return c >= utf8.RuneSelf || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c))
+}
@@ -0,0 +1,332 @@
+// Package markdown renders the Markdown used in notes to HTML. It covers the
+// CommonMark constructs notes use in practice plus the GitHub extensions for
+// tables, task lists, strikethrough and bare URLs. Raw HTML in notes is
+// escaped rather than passed through, so the output is safe to publish.
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Renderer converts Markdown to HTML
+type Renderer struct {
+	// Link rewrites link and image destinations, e.g. to turn note:12 into a
+	// page URL. Returning false renders the link text without a link.
+	Link func(dest string) (string, bool)
+}
+
+// Render converts Markdown to HTML with the default renderer
+func Render(source string) string {
+	return (&Renderer{}).Render(source)
+}
+
+// Render converts Markdown to HTML
+func (r *Renderer) Render(source string) string {
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+	source = strings.ReplaceAll(source, "\t", "    ")
+
+	var b strings.Builder
+	r.blocks(&b, strings.Split(source, "\n"), false)
+	return b.String()
+}
+
+var (
+	headingPattern   = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ ]+(.*?))?(?:[ ]+#+)?[ ]*$`)
+	fencePattern     = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})[ ]*([^`\\s]*)")
+	rulePattern      = regexp.MustCompile(`^ {0,3}((\*[ ]*){3,}|(-[ ]*){3,}|(_[ ]*){3,})$`)
+	quotePattern     = regexp.MustCompile(`^ {0,3}> ?`)
+	listPattern      = regexp.MustCompile(`^( *)([-*+]|\d{1,9}[.)])( +|$)`)
+	tableRulePattern = regexp.MustCompile(`^ *\|? *:?-+:? *(\| *:?-+:? *)*\|? *$`)
+)
+
+// blocks renders a sequence of lines as block elements. In tight lists,
+// paragraphs are rendered without <p> tags.
+func (r *Renderer) blocks(b *strings.Builder, lines []string, tight bool) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+
+		switch {
+		case strings.TrimSpace(line) == "":
+			i++
+
+		case fencePattern.MatchString(line):
+			i = r.fencedCode(b, lines, i)
+
+		case headingPattern.MatchString(line):
+			match := headingPattern.FindStringSubmatch(line)
+			level := string(rune('0' + len(match[1])))
+			text := strings.TrimSpace(match[2])
+			b.WriteString("<h" + level + ` id="` + Slug(text) + `">` + r.inline(text) + "</h" + level + ">\n")
+			i++
+
+		case rulePattern.MatchString(line):
+			b.WriteString("<hr>\n")
+			i++
+
+		case quotePattern.MatchString(line):
+			var quoted []string
+			for ; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+				quoted = append(quoted, quotePattern.ReplaceAllString(lines[i], ""))
+			}
+			b.WriteString("<blockquote>\n")
+			r.blocks(b, quoted, false)
+			b.WriteString("</blockquote>\n")
+
+		case listPattern.MatchString(line):
+			i = r.list(b, lines, i)
+
+		case i+1 < len(lines) && strings.Contains(line, "|") && tableRulePattern.MatchString(lines[i+1]) && strings.Contains(lines[i+1], "-"):
+			i = r.table(b, lines, i)
+
+		default:
+			i = r.paragraph(b, lines, i, tight)
+		}
+	}
+}
+
+// startsBlock reports whether a line interrupts a paragraph
+func startsBlock(line string) bool {
+	return fencePattern.MatchString(line) || headingPattern.MatchString(line) ||
+		rulePattern.MatchString(line) || quotePattern.MatchString(line) ||
+		listPattern.MatchString(line)
+}
+
+func (r *Renderer) paragraph(b *strings.Builder, lines []string, i int, tight bool) int {
+	var text []string
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if strings.TrimSpace(line) == "" || (len(text) > 0 && startsBlock(line)) {
+			break
+		}
+		text = append(text, line)
+	}
+
+	// Trailing double spaces or a backslash make a hard line break
+	var rendered []string
+	for j, line := range text {
+		trimmed := strings.TrimLeft(line, " ")
+		hardBreak := j < len(text)-1 && (strings.HasSuffix(trimmed, "  ") || strings.HasSuffix(trimmed, "\\"))
+		trimmed = strings.TrimRight(trimmed, " ")
+		if hardBreak {
+			rendered = append(rendered, r.inline(strings.TrimSuffix(trimmed, "\\"))+"<br>")
+		} else {
+			rendered = append(rendered, r.inline(trimmed))
+		}
+	}
+
+	content := strings.Join(rendered, "\n")
+	if tight {
+		b.WriteString(content + "\n")
+	} else {
+		b.WriteString("<p>" + content + "</p>\n")
+	}
+	return i
+}
+
+func (r *Renderer) fencedCode(b *strings.Builder, lines []string, i int) int {
+	match := fencePattern.FindStringSubmatch(lines[i])
+	indent, fence, lang := len(match[1]), match[2], match[3]
+
+	var code []string
+	for i++; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+			i++
+			break
+		}
+		line := lines[i]
+		for n := 0; n < indent && strings.HasPrefix(line, " "); n++ {
+			line = line[1:]
+		}
+		code = append(code, line)
+	}
+
+	b.WriteString("<pre><code")
+	if lang != "" {
+		b.WriteString(` class="language-` + html.EscapeString(lang) + `"`)
+	}
+	b.WriteString(">")
+	for _, line := range code {
+		b.WriteString(html.EscapeString(line) + "\n")
+	}
+	b.WriteString("</code></pre>\n")
+	return i
+}
+
+// list renders a list and returns the index of the first line after it
+func (r *Renderer) list(b *strings.Builder, lines []string, i int) int {
+	first := listPattern.FindStringSubmatch(lines[i])
+	indent := len(first[1])
+	ordered := !strings.ContainsAny(first[2], "-*+")
+	delimiter := first[2][len(first[2])-1:]
+
+	type item struct {
+		lines []string
+	}
+	var (
+		items       []*item
+		tight       = true
+		pendingGap  = false
+		contentFrom = 0
+	)
+
+scan:
+	for i < len(lines) {
+		line := lines[i]
+
+		if strings.TrimSpace(line) == "" {
+			pendingGap = true
+			i++
+			continue
+		}
+
+		match := listPattern.FindStringSubmatch(line)
+		lineIndent := len(line) - len(strings.TrimLeft(line, " "))
+		isSibling := match != nil && len(match[1]) == indent &&
+			(!ordered == strings.ContainsAny(match[2], "-*+")) &&
+			strings.HasSuffix(match[2], delimiter)
+
+		switch {
+		case isSibling:
+			if pendingGap && len(items) > 0 {
+				tight = false
+			}
+			contentFrom = len(match[0])
+			if match[3] == "" {
+				contentFrom = len(match[1]) + len(match[2]) + 1
+			}
+			items = append(items, &item{lines: []string{strings.TrimPrefix(line, match[0])}})
+		case lineIndent >= contentFrom && len(items) > 0:
+			if pendingGap {
+				items[len(items)-1].lines = append(items[len(items)-1].lines, "")
+			}
+			items[len(items)-1].lines = append(items[len(items)-1].lines, line[contentFrom:])
+		case !pendingGap && len(items) > 0 && !startsBlock(line):
+			// Lazy continuation of the paragraph of the last item
+			items[len(items)-1].lines = append(items[len(items)-1].lines, strings.TrimLeft(line, " "))
+		default:
+			break scan
+		}
+		pendingGap = false
+		i++
+	}
+
+	// A blank line inside an item with several blocks also makes it loose
+	for _, it := range items {
+		for j := 1; j < len(it.lines)-1; j++ {
+			if it.lines[j] == "" && !listPattern.MatchString(it.lines[j+1]) && !strings.HasPrefix(it.lines[j+1], " ") {
+				tight = false
+			}
+		}
+	}
+
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+		start := strings.TrimRight(first[2], ".)")
+		if start != "1" {
+			tag = `ol start="` + strings.TrimLeft(start, "0") + `"`
+		}
+	}
+	b.WriteString("<" + tag + ">\n")
+	for _, it := range items {
+		b.WriteString("<li>")
+		lines := it.lines
+		switch {
+		case strings.HasPrefix(lines[0], "[ ] "):
+			b.WriteString(`<input type="checkbox" disabled> `)
+			lines[0] = lines[0][4:]
+		case strings.HasPrefix(lines[0], "[x] "), strings.HasPrefix(lines[0], "[X] "):
+			b.WriteString(`<input type="checkbox" checked disabled> `)
+			lines[0] = lines[0][4:]
+		}
+		r.blocks(b, lines, tight)
+		b.WriteString("</li>\n")
+	}
+	b.WriteString("</" + strings.Fields(tag)[0] + ">\n")
+
+	return i
+}
+
+func (r *Renderer) table(b *strings.Builder, lines []string, i int) int {
+	header := splitRow(lines[i])
+	var align []string
+	for _, cell := range splitRow(lines[i+1]) {
+		left, right := strings.HasPrefix(cell, ":"), strings.HasSuffix(cell, ":")
+		switch {
+		case left && right:
+			align = append(align, ` style="text-align:center"`)
+		case right:
+			align = append(align, ` style="text-align:right"`)
+		case left:
+			align = append(align, ` style="text-align:left"`)
+		default:
+			align = append(align, "")
+		}
+	}
+
+	cell := func(tag string, row []string, column int) string {
+		style := ""
+		if column < len(align) {
+			style = align[column]
+		}
+		text := ""
+		if column < len(row) {
+			text = row[column]
+		}
+		return "<" + tag + style + ">" + r.inline(text) + "</" + tag + ">"
+	}
+
+	b.WriteString("<table>\n<thead>\n<tr>")
+	for column := range header {
+		b.WriteString(cell("th", header, column))
+	}
+	b.WriteString("</tr>\n</thead>\n<tbody>\n")
+
+	for i += 2; i < len(lines) && strings.TrimSpace(lines[i]) != "" && strings.Contains(lines[i], "|"); i++ {
+		row := splitRow(lines[i])
+		b.WriteString("<tr>")
+		for column := range header {
+			b.WriteString(cell("td", row, column))
+		}
+		b.WriteString("</tr>\n")
+	}
+	b.WriteString("</tbody>\n</table>\n")
+	return i
+}
+
+// splitRow splits a table row into trimmed cells, honouring escaped pipes
+func splitRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, "\\|") {
+		line = line[:len(line)-1]
+	}
+
+	var (
+		cells   []string
+		current strings.Builder
+	)
+	for j := 0; j < len(line); j++ {
+		switch {
+		case line[j] == '\\' && j+1 < len(line) && line[j+1] == '|':
+			current.WriteByte('|')
+			j++
+		case line[j] == '|':
+			cells = append(cells, strings.TrimSpace(current.String()))
+			current.Reset()
+		default:
+			current.WriteByte(line[j])
+		}
+	}
+	return append(cells, strings.TrimSpace(current.String()))
+}
+
+var slugPattern = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+// Slug turns text into a lowercase identifier usable in URLs and anchors
+func Slug(text string) string {
+	return strings.Trim(slugPattern.ReplaceAllString(strings.ToLower(text), "-"), "-")
+}
@@ -0,0 +1,88 @@
+package note
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AttachmentsDirName is the directory inside the notes directory holding
+// attachment files, one subdirectory per note
+const AttachmentsDirName = "attachments"
+
+// AttachmentPrefix starts the Markdown link targets that point to attachments
+// of the same note, e.g. ![diagram](attachment:diagram.png)
+const AttachmentPrefix = "attachment:"
+
+// AttachmentDir returns the directory holding the attachment files of a note
+func (s *Storage) AttachmentDir(id int) string {
+	return filepath.Join(s.notesDir, AttachmentsDirName, strconv.Itoa(id))
+}
+
+// AttachmentPath returns the path of an attachment file of a note
+func (s *Storage) AttachmentPath(id int, name string) string {
+	return filepath.Join(s.AttachmentDir(id), name)
+}
+
+// SaveAttachment writes an attachment file for a note without recording it
+// on the note, and returns the name it was stored under. Names are sanitized
+// and made unique by adding a numeric suffix.
+func (s *Storage) SaveAttachment(id int, name string, data []byte) (string, error) {
+	name = sanitizeAttachmentName(name)
+
+	dir := filepath.Join(s.notesDir, AttachmentsDirName, strconv.Itoa(id))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create attachments directory: %w", err)
+	}
+
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 1; ; i++ {
+		if _, err := os.Stat(filepath.Join(dir, name)); os.IsNotExist(err) {
+			break
+		}
+		name = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write attachment: %w", err)
+	}
+
+	return name, nil
+}
+
+// AddAttachment stores an attachment file and records it on the note
+func (s *Storage) AddAttachment(id int, name string, data []byte) (*Note, error) {
+	if _, err := s.GetNote(id); err != nil {
+		return nil, err
+	}
+
+	stored, err := s.SaveAttachment(id, name, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.modify(id, Change{Action: ActionAttach, Detail: stored}, func(note *Note) error {
+		note.Attachments = append(note.Attachments, stored)
+		note.UpdatedAt = time.Now()
+		return nil
+	})
+}
+
+// removeAttachments deletes all attachment files of a note
+func (s *Storage) removeAttachments(id int) error {
+	return os.RemoveAll(filepath.Join(s.notesDir, AttachmentsDirName, strconv.Itoa(id)))
+}
+
+// sanitizeAttachmentName turns an arbitrary file name into a safe base name
+func sanitizeAttachmentName(name string) string {
+	name = strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(strings.TrimSpace(name))
+	name = strings.Trim(name, ".")
+	if name == "" {
+		name = "attachment"
+	}
+	return name
+}
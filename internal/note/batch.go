@@ -0,0 +1,264 @@
+package note
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Operations applied to every note of a batch
+const (
+	BatchArchive    = "archive"
+	BatchUnarchive  = "unarchive"
+	BatchFavorite   = "favorite"
+	BatchUnfavorite = "unfavorite"
+	BatchDelete     = "delete"
+	BatchTag        = "tag"
+	BatchUntag      = "untag"
+	BatchMove       = "move"
+)
+
+// ErrBatchFailed is returned when a batch is not applied because its
+// operation fails for some of the notes; their results hold the reasons
+var ErrBatchFailed = errors.New("batch was not applied")
+
+// BatchOperation is an operation applied to several notes at once
+type BatchOperation struct {
+	// Name is one of the Batch constants
+	Name string
+	// Value holds the comma separated tags of BatchTag and BatchUntag, or the
+	// notebook of BatchMove; an empty notebook takes notes out of theirs
+	Value string
+}
+
+// BatchResult is the outcome of a batch operation for one note
+type BatchResult struct {
+	ID int
+	// Before is the note before the operation, nil if it was not found
+	Before *Note
+	// After is the note after the operation, nil if it was deleted
+	After *Note
+	// Changed reports whether the operation changes the note; archiving an
+	// archived note, for example, does not
+	Changed bool
+	// Err is why the operation fails for this note
+	Err error
+}
+
+// check returns an error for unknown operations and missing values
+func (op BatchOperation) check() error {
+	switch op.Name {
+	case BatchArchive, BatchUnarchive, BatchFavorite, BatchUnfavorite, BatchDelete, BatchMove:
+		return nil
+	case BatchTag, BatchUntag:
+		if len(op.tags()) == 0 {
+			return fmt.Errorf("no tags given to %s", op.Name)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown operation %q (use archive, unarchive, favorite, unfavorite, delete, tag, untag or move)", op.Name)
+}
+
+func (op BatchOperation) tags() []string {
+	var tags []string
+	for _, tag := range strings.Split(op.Value, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// apply makes the change of the operation to a copy of a note
+func (op BatchOperation) apply(note *Note) {
+	switch op.Name {
+	case BatchArchive, BatchUnarchive:
+		note.SetArchived(op.Name == BatchArchive)
+	case BatchFavorite, BatchUnfavorite:
+		note.SetFavorite(op.Name == BatchFavorite)
+	case BatchTag:
+		for _, tag := range op.tags() {
+			note.AddTag(tag)
+		}
+	case BatchUntag:
+		for _, tag := range op.tags() {
+			note.RemoveTag(tag)
+		}
+	case BatchMove:
+		note.Notebook = strings.Trim(strings.TrimSpace(op.Value), "/")
+	}
+}
+
+// change returns the change reported for a note of the batch
+func (op BatchOperation) change(result BatchResult) Change {
+	change := Change{ID: result.ID, Before: result.Before, After: result.After}
+	switch op.Name {
+	case BatchArchive, BatchUnarchive:
+		change.Action = ActionArchive
+	case BatchFavorite, BatchUnfavorite:
+		change.Action = ActionFavorite
+	case BatchDelete:
+		change.Action = ActionDelete
+	case BatchTag:
+		change.Action, change.Detail = ActionTag, strings.Join(op.tags(), ", ")
+	case BatchUntag:
+		change.Action, change.Detail = ActionUntag, strings.Join(op.tags(), ", ")
+	case BatchMove:
+		change.Action, change.Detail = ActionNotebook, result.After.Notebook
+	}
+	return change
+}
+
+// PlanBatch returns what ApplyBatch would do without changing anything. The
+// error is ErrBatchFailed when the operation fails for some of the notes.
+func (s *Storage) PlanBatch(ids []int, op BatchOperation) ([]BatchResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.planBatch(ids, op)
+}
+
+// ApplyBatch applies an operation to the notes with the given IDs as a
+// whole: if it fails for any note, or a note cannot be saved, no note is
+// changed and the error is ErrBatchFailed or the save error. The changes of
+// all notes that changed are reported as one grouped change.
+func (s *Storage) ApplyBatch(ids []int, op BatchOperation) ([]BatchResult, error) {
+	results, err := s.applyBatch(ids, op)
+	if err != nil {
+		return results, err
+	}
+
+	var removeErr error
+	var changes []Change
+	for _, result := range results {
+		if !result.Changed {
+			continue
+		}
+		if result.After == nil {
+			if err := s.removeAttachments(result.ID); err != nil && removeErr == nil {
+				removeErr = err
+			}
+		}
+		changes = append(changes, op.change(result))
+	}
+	if len(changes) > 0 {
+		s.notify(groupChanges(changes))
+	}
+	return results, removeErr
+}
+
+// planBatch applies the operation to copies of the notes; callers must hold
+// s.mu
+func (s *Storage) planBatch(ids []int, op BatchOperation) ([]BatchResult, error) {
+	if err := op.check(); err != nil {
+		return nil, err
+	}
+
+	results := make([]BatchResult, len(ids))
+	failed := false
+	seen := make(map[int]bool)
+	for i, id := range ids {
+		result := &results[i]
+		result.ID = id
+
+		previous, err := s.readable(id)
+		if err == nil && seen[id] {
+			err = fmt.Errorf("note %d is listed twice", id)
+		}
+		seen[id] = true
+		if err != nil {
+			result.Err = err
+			failed = true
+			continue
+		}
+		result.Before = previous
+
+		if op.Name == BatchDelete {
+			result.Changed = true
+			continue
+		}
+
+		note := previous.Clone()
+		op.apply(note)
+		result.After = note
+		if sameNote(note, previous) {
+			result.After = previous
+			continue
+		}
+		note.UpdatedAt = time.Now()
+		note.Edits++
+		result.Changed = true
+
+		if err := s.validate(note); err != nil {
+			result.Err = err
+			failed = true
+		}
+	}
+
+	if failed {
+		return results, ErrBatchFailed
+	}
+	return results, nil
+}
+
+// sameNote reports whether two notes would be saved the same
+func sameNote(a, b *Note) bool {
+	dataA, errA := json.Marshal(a)
+	dataB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(dataA, dataB)
+}
+
+func (s *Storage) applyBatch(ids []int, op BatchOperation) ([]BatchResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results, err := s.planBatch(ids, op)
+	if err != nil {
+		return results, err
+	}
+
+	// Keep the files as they are, to put them back if a later note fails
+	saved := make(map[int][]byte)
+	restore := func() {
+		for id, data := range saved {
+			os.WriteFile(s.noteFile(id), data, 0644)
+		}
+	}
+
+	for _, result := range results {
+		if !result.Changed {
+			continue
+		}
+
+		filename := s.noteFile(result.ID)
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			restore()
+			return results, fmt.Errorf("failed to read note %d, no note was changed: %w", result.ID, err)
+		}
+		saved[result.ID] = data
+
+		if result.After == nil {
+			err = os.Remove(filename)
+		} else {
+			err = s.saveNote(result.After)
+		}
+		if err != nil {
+			restore()
+			return results, fmt.Errorf("failed to save note %d, no note was changed: %w", result.ID, err)
+		}
+	}
+
+	for _, result := range results {
+		if result.After == nil {
+			delete(s.notes, result.ID)
+		} else {
+			s.notes[result.ID] = result.After
+		}
+	}
+	return results, nil
+}
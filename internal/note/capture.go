@@ -0,0 +1,84 @@
+package note
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// maxInferredTitle is the length titles taken from a first line are cut to
+const maxInferredTitle = 80
+
+// InferTitle derives a title from captured text. A leading "# Heading" becomes
+// the title and is removed from the content; otherwise the first non-empty
+// line is the title and the content is kept whole.
+func InferTitle(text string) (title, content string) {
+	content = strings.TrimSpace(text)
+	first, rest, _ := strings.Cut(content, "\n")
+	first = strings.TrimSpace(first)
+
+	if heading, ok := strings.CutPrefix(first, "# "); ok {
+		title = strings.TrimSpace(heading)
+		if rest = strings.TrimSpace(rest); rest != "" {
+			return title, rest
+		}
+		return title, content
+	}
+
+	title = strings.TrimLeft(first, "#>-*• \t")
+	if utf8.RuneCountInString(title) > maxInferredTitle {
+		runes := []rune(title)
+		title = strings.TrimSpace(string(runes[:maxInferredTitle])) + "…"
+	}
+	return title, content
+}
+
+// SplitCapture splits text holding several notes. With an empty separator a
+// new note starts at every top-level "# " heading; otherwise notes are
+// separated by lines consisting of separator, such as "---". Empty parts
+// are dropped.
+func SplitCapture(text, separator string) []string {
+	var parts []string
+	var current []string
+	flush := func() {
+		if part := strings.TrimSpace(strings.Join(current, "\n")); part != "" {
+			parts = append(parts, part)
+		}
+		current = nil
+	}
+
+	inFence := false
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+		}
+
+		switch {
+		case inFence:
+		case separator != "" && trimmed == separator:
+			flush()
+			continue
+		case separator == "" && strings.HasPrefix(line, "# "):
+			flush()
+		}
+		current = append(current, line)
+	}
+	flush()
+	return parts
+}
+
+// AppendToNote adds text at the end of the content of a note
+func (s *Storage) AppendToNote(id int, text string) (*Note, error) {
+	return s.modify(id, Change{Action: ActionUpdate}, func(note *Note) error {
+		note.UpdateContent(s.formatContent(note.Title, note.Content+"\n"+strings.TrimSpace(text)))
+		return s.validate(note)
+	})
+}
+
+// PrependToNote adds text at the start of the content of a note
+func (s *Storage) PrependToNote(id int, text string) (*Note, error) {
+	return s.modify(id, Change{Action: ActionUpdate}, func(note *Note) error {
+		note.UpdateContent(s.formatContent(note.Title, strings.TrimSpace(text)+"\n"+note.Content))
+		return s.validate(note)
+	})
+}
@@ -0,0 +1,297 @@
+package note
+
+import "fmt"
+
+// Action is the kind of change made to the notes directory
+type Action string
+
+// Actions reported by the storage
+const (
+	ActionCreate   Action = "create"
+	ActionImport   Action = "import"
+	ActionPut      Action = "put"
+	ActionUpdate   Action = "update"
+	ActionDelete   Action = "delete"
+	ActionFavorite Action = "favorite"
+	ActionArchive  Action = "archive"
+	ActionTag      Action = "tag"
+	ActionUntag    Action = "untag"
+	ActionLink     Action = "link"
+	ActionUnlink   Action = "unlink"
+	ActionRemind   Action = "remind"
+	ActionDue      Action = "due"
+	ActionAttach   Action = "attach"
+	ActionSettings Action = "settings"
+	ActionEncrypt  Action = "encrypt"
+	ActionDecrypt  Action = "decrypt"
+	ActionPin      Action = "pin"
+	ActionMove     Action = "move"
+	ActionReorder  Action = "reorder"
+	// ActionNotebook has the new notebook as Detail, empty when the note was
+	// taken out of its notebook
+	ActionNotebook Action = "notebook"
+	// ActionSetProperty and ActionUnsetProperty have the property name as
+	// Detail
+	ActionSetProperty   Action = "setprop"
+	ActionUnsetProperty Action = "unsetprop"
+	// ActionUndo and ActionRedo have the description of the change undone
+	// or redone as Detail
+	ActionUndo Action = "undo"
+	ActionRedo Action = "redo"
+)
+
+// Change describes a change that was saved to the notes directory
+type Change struct {
+	Action Action
+	// ID is the note that changed; it is 0 for changes to the settings or to
+	// the order of several notes
+	ID int
+	// Before is the note before the change, nil if it was added
+	Before *Note
+	// After is the note after the change, nil if it was deleted
+	After *Note
+	// Detail is the tag, link target, attachment name, property name or new
+	// place of a moved note the change was about
+	Detail string
+	// Others holds the other notes changed along with this one, such as the
+	// notes renumbered by a move or a reorder
+	Others []Change
+	// Grouped is set when Others are further operations reported together
+	// with this one, such as by ApplyBatch or Group
+	Grouped bool
+}
+
+// Message returns a one-line description of the change, such as
+// `Tag note 3 "Go Slices" with go`
+func (c Change) Message() string {
+	if c.Grouped {
+		return c.groupMessage()
+	}
+	if c.encrypted() {
+		return c.encryptedMessage()
+	}
+
+	subject := fmt.Sprintf("note %d", c.ID)
+	if n := c.note(); n != nil {
+		subject = fmt.Sprintf("note %d %q", c.ID, n.Title)
+	}
+
+	switch c.Action {
+	case ActionCreate:
+		return "Create " + subject
+	case ActionImport:
+		return "Import " + subject
+	case ActionPut:
+		if c.Before == nil {
+			return "Add " + subject
+		}
+		return "Replace " + subject
+	case ActionUpdate:
+		if c.Before != nil && c.After != nil && c.Before.Title != c.After.Title {
+			return fmt.Sprintf("Update %s (was %q)", subject, c.Before.Title)
+		}
+		return "Update " + subject
+	case ActionDelete:
+		return "Delete " + subject
+	case ActionFavorite:
+		if c.After != nil && !c.After.IsFavorite {
+			return "Unfavorite " + subject
+		}
+		return "Favorite " + subject
+	case ActionArchive:
+		if c.After != nil && !c.After.IsArchived {
+			return "Unarchive " + subject
+		}
+		return "Archive " + subject
+	case ActionTag:
+		return fmt.Sprintf("Tag %s with %s", subject, c.Detail)
+	case ActionUntag:
+		return fmt.Sprintf("Remove tag %s from %s", c.Detail, subject)
+	case ActionLink:
+		return fmt.Sprintf("Link %s to note %s", subject, c.Detail)
+	case ActionUnlink:
+		return fmt.Sprintf("Unlink %s from note %s", subject, c.Detail)
+	case ActionRemind:
+		if c.After == nil || c.After.RemindAt == nil {
+			return "Clear reminder of " + subject
+		}
+		return fmt.Sprintf("Set reminder on %s for %s", subject, c.After.RemindAt.Format("2006-01-02 15:04"))
+	case ActionDue:
+		if c.After == nil || c.After.DueAt == nil {
+			return "Clear due date of " + subject
+		}
+		return fmt.Sprintf("Set due date of %s to %s", subject, c.After.DueAt.Format("2006-01-02 15:04"))
+	case ActionAttach:
+		return fmt.Sprintf("Attach %s to %s", c.Detail, subject)
+	case ActionSettings:
+		return "Change settings"
+	case ActionPin:
+		if c.After != nil && !c.After.IsPinned {
+			return "Unpin " + subject
+		}
+		return "Pin " + subject
+	case ActionMove:
+		return fmt.Sprintf("Move %s %s", subject, c.Detail)
+	case ActionReorder:
+		return "Reorder notes"
+	case ActionNotebook:
+		if c.Detail == "" {
+			return fmt.Sprintf("Remove %s from its notebook", subject)
+		}
+		return fmt.Sprintf("Move %s to notebook %s", subject, c.Detail)
+	case ActionSetProperty:
+		if c.After != nil {
+			if value, ok := c.After.Properties[c.Detail]; ok {
+				return fmt.Sprintf("Set %s of %s to %s", c.Detail, subject, PropertyText(value))
+			}
+		}
+		return fmt.Sprintf("Set %s of %s", c.Detail, subject)
+	case ActionUnsetProperty:
+		return fmt.Sprintf("Remove property %s from %s", c.Detail, subject)
+	case ActionUndo:
+		return "Undo: " + c.Detail
+	case ActionRedo:
+		return "Redo: " + c.Detail
+	}
+	return fmt.Sprintf("Change %s", subject)
+}
+
+// groupMessage describes a grouped change by its first operation and the
+// number of other notes, such as `Import note 3 "Go Slices" (and 2 other
+// notes)`
+func (c Change) groupMessage() string {
+	first := c
+	first.Grouped, first.Others = false, nil
+	message := first.Message()
+
+	others := make(map[int]bool)
+	for _, other := range c.Others {
+		if other.ID != 0 && other.ID != c.ID {
+			others[other.ID] = true
+		}
+	}
+	switch len(others) {
+	case 0:
+		return message
+	case 1:
+		return message + " (and 1 other note)"
+	}
+	return fmt.Sprintf("%s (and %d other notes)", message, len(others))
+}
+
+// encrypted reports whether the change involves an encrypted note
+func (c Change) encrypted() bool {
+	return (c.Before != nil && c.Before.Encrypted) || (c.After != nil && c.After.Encrypted)
+}
+
+// encryptedMessage describes a change to an encrypted note without its
+// title, tags or other details, since messages end up in plain text such as
+// git commits
+func (c Change) encryptedMessage() string {
+	subject := fmt.Sprintf("encrypted note %d", c.ID)
+	switch c.Action {
+	case ActionCreate:
+		return "Create " + subject
+	case ActionImport:
+		return "Import " + subject
+	case ActionPut:
+		if c.Before == nil {
+			return "Add " + subject
+		}
+		return "Replace " + subject
+	case ActionDelete:
+		return "Delete " + subject
+	case ActionEncrypt:
+		return fmt.Sprintf("Encrypt note %d", c.ID)
+	case ActionDecrypt:
+		return fmt.Sprintf("Decrypt note %d", c.ID)
+	case ActionReorder:
+		return "Reorder notes"
+	case ActionUndo:
+		return "Undo: " + c.Detail
+	case ActionRedo:
+		return "Redo: " + c.Detail
+	}
+	return "Update " + subject
+}
+
+// note returns the note after the change, or before it for deletions
+func (c Change) note() *Note {
+	if c.After != nil {
+		return c.After
+	}
+	return c.Before
+}
+
+// OnChange registers fn to be called after every change saved through the
+// storage. Hooks run synchronously in the order they were registered, after
+// the storage lock has been released, so they may read from the storage.
+func (s *Storage) OnChange(fn func(Change)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.hooks = append(s.hooks, fn)
+}
+
+// notify calls the change hooks, or adds the change to the group while
+// Group runs
+func (s *Storage) notify(change Change) {
+	s.mu.Lock()
+	if s.grouping {
+		s.group = append(s.group, change)
+		s.mu.Unlock()
+		return
+	}
+	hooks := s.hooks
+	s.mu.Unlock()
+
+	for _, hook := range hooks {
+		hook(change)
+	}
+}
+
+// Group runs fn and reports the changes it saves as one grouped change once
+// it returns, so that hooks such as the journal or git auto-commit see a
+// single operation rather than one per note, for example for an import.
+// Changes saved by other goroutines while fn runs join the group too, and
+// Group inside fn just runs its function.
+func (s *Storage) Group(fn func() error) error {
+	s.mu.Lock()
+	if s.grouping {
+		s.mu.Unlock()
+		return fn()
+	}
+	s.grouping = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		changes := s.group
+		s.group, s.grouping = nil, false
+		s.mu.Unlock()
+
+		if len(changes) > 0 {
+			s.notify(groupChanges(changes))
+		}
+	}()
+	return fn()
+}
+
+// groupChanges returns the first change with all others, and the notes they
+// changed along with them, in its Others
+func groupChanges(changes []Change) Change {
+	if len(changes) == 1 {
+		return changes[0]
+	}
+
+	group := changes[0]
+	group.Grouped = true
+	group.Others = append([]Change(nil), group.Others...)
+	for _, change := range changes[1:] {
+		others := change.Others
+		change.Others, change.Grouped = nil, false
+		group.Others = append(group.Others, change)
+		group.Others = append(group.Others, others...)
+	}
+	return group
+}
@@ -0,0 +1,405 @@
+package note
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/vault"
+)
+
+// ErrLocked is returned for encrypted notes while the storage is locked
+var ErrLocked = errors.New("notes are locked")
+
+// ErrNoEncryption is returned when encryption has not been set up for the
+// notes directory
+var ErrNoEncryption = errors.New("encryption is not set up")
+
+// encryptedFile is the form of an encrypted note on disk. Only the ID is
+// kept in the clear so the note can be listed and deleted while locked.
+type encryptedFile struct {
+	ID        int    `json:"id"`
+	Encrypted bool   `json:"encrypted"`
+	Data      []byte `json:"data"`
+}
+
+// noteData returns the additional data binding an encrypted note to its ID,
+// so encrypted files cannot be swapped between notes
+func noteData(id int) []byte {
+	return []byte("note:" + strconv.Itoa(id))
+}
+
+// EncryptionEnabled reports whether encryption has been set up for the
+// notes directory
+func (s *Storage) EncryptionEnabled() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.vault != nil
+}
+
+// IsLocked reports whether encryption is set up and no key has been given
+func (s *Storage) IsLocked() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.vault != nil && s.key == nil
+}
+
+// LockedNotes returns the IDs of the encrypted notes that are hidden
+// because the storage is locked
+func (s *Storage) LockedNotes() []int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make([]int, 0, len(s.locked))
+	for id := range s.locked {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// SetupEncryption creates the key of the notes directory from a passphrase
+// and unlocks the storage with it. Notes are not encrypted until they are
+// marked with EncryptNote or the encrypt_all setting is turned on.
+func (s *Storage) SetupEncryption(passphrase string) error {
+	if err := s.setupEncryption(passphrase); err != nil {
+		return err
+	}
+
+	s.notify(Change{Action: ActionSettings})
+	return nil
+}
+
+func (s *Storage) setupEncryption(passphrase string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.vault != nil {
+		return fmt.Errorf("encryption is already set up")
+	}
+
+	file, key, err := vault.Create(passphrase)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.MetaDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create meta directory: %w", err)
+	}
+	if err := file.Save(filepath.Join(s.MetaDir(), vault.FileName)); err != nil {
+		return err
+	}
+
+	s.vault = file
+	s.key = key
+	return nil
+}
+
+// Unlock derives the key from passphrase and decrypts the encrypted notes
+func (s *Storage) Unlock(passphrase string) (*vault.Key, error) {
+	s.mu.RLock()
+	file := s.vault
+	s.mu.RUnlock()
+
+	if file == nil {
+		return nil, ErrNoEncryption
+	}
+
+	key, err := file.Unlock(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return key, s.UnlockWithKey(key)
+}
+
+// UnlockWithKey decrypts the encrypted notes with a key returned by Unlock
+// earlier, such as one cached for a session
+func (s *Storage) UnlockWithKey(key *vault.Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.vault == nil {
+		return ErrNoEncryption
+	}
+	if !s.vault.Matches(key) {
+		return vault.ErrWrongPassphrase
+	}
+
+	s.key = key
+	for id, data := range s.locked {
+		note, err := s.decodeNote(data)
+		if err != nil {
+			fmt.Printf("Warning: failed to decrypt note %d: %v\n", id, err)
+			continue
+		}
+		s.notes[id] = note
+		delete(s.locked, id)
+	}
+	return nil
+}
+
+// Lock forgets the key and hides the encrypted notes again
+func (s *Storage) Lock() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.key = nil
+	return s.reload()
+}
+
+// ShareEncryption lets other, such as a notes directory synced with s, store
+// encrypted notes with the key of s. A directory without encryption is given
+// the key file of s; one with encryption is unlocked if it uses the same key.
+func (s *Storage) ShareEncryption(other *Storage) error {
+	s.mu.RLock()
+	file, key := s.vault, s.key
+	s.mu.RUnlock()
+
+	if file == nil {
+		return nil
+	}
+	if key == nil {
+		return ErrLocked
+	}
+
+	if other.EncryptionEnabled() {
+		if err := other.UnlockWithKey(key); err != nil {
+			return fmt.Errorf("%s is encrypted with another passphrase", other.Dir())
+		}
+		return nil
+	}
+
+	other.mu.Lock()
+	defer other.mu.Unlock()
+
+	if err := os.MkdirAll(other.MetaDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create meta directory: %w", err)
+	}
+	if err := file.Save(filepath.Join(other.MetaDir(), vault.FileName)); err != nil {
+		return err
+	}
+	other.vault = file
+	other.key = key
+	return nil
+}
+
+// EncryptNote marks a note as encrypted and saves it encrypted
+func (s *Storage) EncryptNote(id int) (*Note, error) {
+	return s.modify(id, Change{Action: ActionEncrypt}, func(note *Note) error {
+		if s.key == nil {
+			return s.lockedError()
+		}
+		note.Encrypted = true
+		return nil
+	})
+}
+
+// DecryptNote saves an encrypted note in the clear again
+func (s *Storage) DecryptNote(id int) (*Note, error) {
+	return s.modify(id, Change{Action: ActionDecrypt}, func(note *Note) error {
+		if s.settings.EncryptAll {
+			return fmt.Errorf("cannot decrypt note %d while encrypt_all is on", id)
+		}
+		note.Encrypted = false
+		return nil
+	})
+}
+
+// lockedError explains why an encrypted note cannot be written; callers
+// must hold s.mu
+func (s *Storage) lockedError() error {
+	if s.vault == nil {
+		return ErrNoEncryption
+	}
+	return ErrLocked
+}
+
+// encrypts reports whether note is saved encrypted; callers must hold s.mu
+func (s *Storage) encrypts(note *Note) bool {
+	return note.Encrypted || (s.settings.EncryptAll && s.vault != nil)
+}
+
+// EncodeNote returns a note in the form it is saved in, encrypted if the
+// note or the settings ask for it
+func (s *Storage) EncodeNote(note *Note) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.encodeNote(note)
+}
+
+func (s *Storage) encodeNote(note *Note) ([]byte, error) {
+	if !s.encrypts(note) {
+		return json.MarshalIndent(note, "", "  ")
+	}
+	if s.key == nil {
+		return nil, fmt.Errorf("cannot save encrypted note %d: %w", note.ID, s.lockedError())
+	}
+
+	encrypted := note.Clone()
+	encrypted.Encrypted = true
+	plaintext, err := json.Marshal(encrypted)
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.key.Seal(plaintext, noteData(note.ID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt note %d: %w", note.ID, err)
+	}
+	return json.MarshalIndent(encryptedFile{ID: note.ID, Encrypted: true, Data: data}, "", "  ")
+}
+
+// DecodeNote reads a note saved by EncodeNote, decrypting it if needed. It
+// returns ErrLocked for encrypted notes while the storage is locked.
+func (s *Storage) DecodeNote(data []byte) (*Note, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.decodeNote(data)
+}
+
+func (s *Storage) decodeNote(data []byte) (*Note, error) {
+	var file encryptedFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal note: %w", err)
+	}
+
+	if !file.Encrypted || file.Data == nil {
+		var note Note
+		if err := json.Unmarshal(data, &note); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal note: %w", err)
+		}
+		return &note, nil
+	}
+
+	if s.key == nil {
+		return nil, fmt.Errorf("note %d is encrypted: %w", file.ID, s.lockedError())
+	}
+	plaintext, err := s.key.Open(file.Data, noteData(file.ID))
+	if err != nil {
+		return nil, fmt.Errorf("note %d: %w", file.ID, err)
+	}
+
+	var note Note
+	if err := json.Unmarshal(plaintext, &note); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal note %d: %w", file.ID, err)
+	}
+	if note.ID != file.ID {
+		return nil, fmt.Errorf("encrypted note %d has ID %d", file.ID, note.ID)
+	}
+	note.Encrypted = true
+	return &note, nil
+}
+
+// NoteIDs returns the IDs of all notes, including locked ones, sorted
+func (s *Storage) NoteIDs() []int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make([]int, 0, len(s.notes)+len(s.locked))
+	for id := range s.notes {
+		ids = append(ids, id)
+	}
+	for id := range s.locked {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// NoteFile returns the file of a note as it is saved on disk, encrypted or
+// not
+func (s *Storage) NoteFile(id int) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, exists := s.notes[id]
+	if _, locked := s.locked[id]; !exists && !locked {
+		return nil, fmt.Errorf("note with ID %d %w", id, ErrNotFound)
+	}
+	return os.ReadFile(s.noteFile(id))
+}
+
+// PutNoteFile stores a note file as read from disk, such as from a backup,
+// under the ID it carries. Encrypted notes are written as they are and stay
+// locked if they cannot be decrypted with the current key.
+func (s *Storage) PutNoteFile(data []byte) error {
+	stored, previous, err := s.putNoteFile(data)
+	if err != nil {
+		return err
+	}
+
+	s.notify(Change{Action: ActionPut, ID: stored.ID, Before: previous, After: stored})
+	return nil
+}
+
+func (s *Storage) putNoteFile(data []byte) (stored, previous *Note, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var file encryptedFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal note: %w", err)
+	}
+	if file.ID <= 0 {
+		return nil, nil, fmt.Errorf("invalid note ID: %d", file.ID)
+	}
+
+	stored, err = s.decodeNote(data)
+	locked := errors.Is(err, ErrLocked) || errors.Is(err, ErrNoEncryption) || errors.Is(err, vault.ErrDecrypt)
+	if err != nil && !locked {
+		return nil, nil, err
+	}
+
+	if err := os.WriteFile(s.noteFile(file.ID), data, 0644); err != nil {
+		return nil, nil, fmt.Errorf("failed to write note file: %w", err)
+	}
+
+	previous = s.notes[file.ID]
+	if _, ok := s.locked[file.ID]; ok {
+		previous = lockedNote(file.ID)
+	}
+
+	if locked {
+		delete(s.notes, file.ID)
+		s.locked[file.ID] = data
+		stored = lockedNote(file.ID)
+	} else {
+		delete(s.locked, file.ID)
+		s.notes[file.ID] = stored
+	}
+	if file.ID >= s.nextID {
+		s.nextID = file.ID + 1
+	}
+	return stored, previous, nil
+}
+
+// lockedNote stands in for an encrypted note that cannot be read in the
+// changes reported to hooks
+func lockedNote(id int) *Note {
+	return &Note{ID: id, Encrypted: true}
+}
+
+// loadVault reads the key file of the notes directory, if any. A key that no
+// longer matches it is dropped.
+func (s *Storage) loadVault() error {
+	file, err := vault.Load(filepath.Join(s.MetaDir(), vault.FileName))
+	if errors.Is(err, os.ErrNotExist) {
+		s.vault, s.key = nil, nil
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read encryption key file: %w", err)
+	}
+
+	s.vault = file
+	if s.key != nil && !file.Matches(s.key) {
+		s.key = nil
+	}
+	return nil
+}
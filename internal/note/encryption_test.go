@@ -0,0 +1,146 @@
+package note
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/vault"
+)
+
+// encryptedStorage returns a storage with encryption set up and two notes,
+// the second of them encrypted
+func encryptedStorage(t *testing.T) *Storage {
+	t.Helper()
+	storage, err := NewStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := storage.CreateNote("Plain", "plain text", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := storage.CreateNote("Diary", "dear diary", []string{"private"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.SetupEncryption("secret"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := storage.EncryptNote(2); err != nil {
+		t.Fatal(err)
+	}
+	return storage
+}
+
+func TestEncryptionRoundTrip(t *testing.T) {
+	storage := encryptedStorage(t)
+
+	data, err := os.ReadFile(storage.noteFile(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, text := range []string{"Diary", "dear diary", "private"} {
+		if bytes.Contains(data, []byte(text)) {
+			t.Errorf("the file of the encrypted note contains %q", text)
+		}
+	}
+
+	// A new storage of the same directory starts locked
+	reopened, err := NewStorage(storage.Dir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reopened.IsLocked() {
+		t.Fatal("IsLocked() = false for a new storage")
+	}
+	if _, err := reopened.GetNote(2); !errors.Is(err, ErrLocked) {
+		t.Errorf("GetNote() while locked error = %v, want ErrLocked", err)
+	}
+	if _, err := reopened.GetNote(1); err != nil {
+		t.Errorf("GetNote() of the plain note while locked: %v", err)
+	}
+
+	if _, err := reopened.Unlock("secret"); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+	n, err := reopened.GetNote(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.Title != "Diary" || n.Content != "dear diary" || !n.Encrypted {
+		t.Errorf("decrypted note = %q %q encrypted %v", n.Title, n.Content, n.Encrypted)
+	}
+
+	// Decrypting saves the note in the clear again
+	if _, err := reopened.DecryptNote(2); err != nil {
+		t.Fatal(err)
+	}
+	data, err = os.ReadFile(reopened.noteFile(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte("dear diary")) {
+		t.Error("the file of the decrypted note is not in the clear")
+	}
+}
+
+func TestUnlockWrongPassphrase(t *testing.T) {
+	storage := encryptedStorage(t)
+
+	reopened, err := NewStorage(storage.Dir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reopened.Unlock("not the secret"); !errors.Is(err, vault.ErrWrongPassphrase) {
+		t.Errorf("Unlock() error = %v, want ErrWrongPassphrase", err)
+	}
+	if !reopened.IsLocked() {
+		t.Error("IsLocked() = false after a wrong passphrase")
+	}
+}
+
+func TestDecodeTamperedNote(t *testing.T) {
+	storage := encryptedStorage(t)
+	if _, err := storage.EncryptNote(1); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := storage.NoteFile(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := storage.NoteFile(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file encryptedFile
+	if err := json.Unmarshal(first, &file); err != nil {
+		t.Fatal(err)
+	}
+
+	// The encrypted data of note 1 under the ID of note 2 fails, since the
+	// ID is authenticated as additional data
+	file.ID = 2
+	swapped, err := json.Marshal(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := storage.DecodeNote(swapped); !errors.Is(err, vault.ErrDecrypt) {
+		t.Errorf("DecodeNote() of swapped data error = %v, want ErrDecrypt", err)
+	}
+
+	file.ID = 1
+	file.Data[len(file.Data)-1] ^= 1
+	modified, err := json.Marshal(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := storage.DecodeNote(modified); !errors.Is(err, vault.ErrDecrypt) {
+		t.Errorf("DecodeNote() of modified data error = %v, want ErrDecrypt", err)
+	}
+
+	if _, err := storage.DecodeNote(second); err != nil {
+		t.Errorf("DecodeNote() of the original file: %v", err)
+	}
+}
@@ -1,22 +1,70 @@
 package note
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
 
+// LinkPrefix starts the Markdown link targets that point to other notes,
+// e.g. [Go Slices](note:12)
+const LinkPrefix = "note:"
+
+// LinkTarget returns the Markdown link target pointing to the note with id
+func LinkTarget(id int) string {
+	return LinkPrefix + strconv.Itoa(id)
+}
+
+// linkPattern matches Markdown link targets pointing to notes, e.g. (note:12)
+var linkPattern = regexp.MustCompile(`\(` + LinkPrefix + `(\d+)\)`)
+
+// RewriteLinks replaces the note IDs in the Markdown links of content with
+// the IDs returned by fn. Links for which fn returns false are kept.
+func RewriteLinks(content string, fn func(id int) (int, bool)) string {
+	return linkPattern.ReplaceAllStringFunc(content, func(match string) string {
+		id, _ := strconv.Atoi(linkPattern.FindStringSubmatch(match)[1])
+		if target, ok := fn(id); ok {
+			return "(" + LinkTarget(target) + ")"
+		}
+		return match
+	})
+}
+
 // Note represents a single note in the application
 type Note struct {
 	ID         int       `json:"id"`
 	Title      string    `json:"title"`
 	Content    string    `json:"content"`
 	Tags       []string  `json:"tags"`
+	Notebook   string    `json:"notebook,omitempty"`
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
 	IsArchived bool      `json:"is_archived"`
 	IsFavorite bool      `json:"is_favorite"`
+	IsPinned   bool      `json:"is_pinned"`
+	// Position orders notes manually within the pinned and the other notes;
+	// 0 means the note has no manual position, see SortNotes
+	Position    int        `json:"position,omitempty"`
+	RemindAt    *time.Time `json:"remind_at,omitempty"`
+	DueAt       *time.Time `json:"due_at,omitempty"`
+	Links       []int      `json:"links,omitempty"`
+	Attachments []string   `json:"attachments,omitempty"`
+	// UID identifies the note across synced notes directories, where it may
+	// have a different ID. Notes without one are identified by SyncID.
+	UID string `json:"uid,omitempty"`
+	// Encrypted notes are stored encrypted with the key of the notes
+	// directory, see Storage.Unlock
+	Encrypted bool `json:"encrypted,omitempty"`
+	// Properties are custom fields holding strings, numbers or bools; see
+	// Schema for their types
+	Properties map[string]interface{} `json:"properties,omitempty"`
+	// Edits counts the changes saved to the note since it was created
+	Edits int `json:"edits,omitempty"`
 }
 
 // NewNote creates a new note with default values
@@ -39,7 +87,7 @@ func (n *Note) Validate() error {
 	if strings.TrimSpace(n.Content) == "" {
 		return fmt.Errorf("note content cannot be empty")
 	}
-	return nil
+	return n.validateProperties()
 }
 
 // AddTag adds a tag to the note if it doesn't already exist
@@ -79,6 +127,34 @@ func (n *Note) HasTag(tag string) bool {
 	return false
 }
 
+// AddLink records a link from the note to another note
+func (n *Note) AddLink(id int) {
+	if id == n.ID || n.HasLink(id) {
+		return
+	}
+	n.Links = append(n.Links, id)
+}
+
+// RemoveLink removes the link to another note
+func (n *Note) RemoveLink(id int) {
+	for i, linked := range n.Links {
+		if linked == id {
+			n.Links = append(n.Links[:i], n.Links[i+1:]...)
+			return
+		}
+	}
+}
+
+// HasLink checks if the note links to another note
+func (n *Note) HasLink(id int) bool {
+	for _, linked := range n.Links {
+		if linked == id {
+			return true
+		}
+	}
+	return false
+}
+
 // UpdateContent updates the note content and sets the updated timestamp
 func (n *Note) UpdateContent(content string) {
 	n.Content = strings.TrimSpace(content)
@@ -91,18 +167,103 @@ func (n *Note) UpdateTitle(title string) {
 	n.UpdatedAt = time.Now()
 }
 
-// ToggleFavorite toggles the favorite status of the note
-func (n *Note) ToggleFavorite() {
-	n.IsFavorite = !n.IsFavorite
+// SetFavorite marks the note as favorite or not
+func (n *Note) SetFavorite(favorite bool) {
+	if n.IsFavorite == favorite {
+		return
+	}
+	n.IsFavorite = favorite
+	n.UpdatedAt = time.Now()
+}
+
+// SetPinned pins the note to the top of lists or unpins it. The note loses
+// its manual position, since it moves to the other group.
+func (n *Note) SetPinned(pinned bool) {
+	if n.IsPinned == pinned {
+		return
+	}
+	n.IsPinned = pinned
+	n.Position = 0
+	n.UpdatedAt = time.Now()
+}
+
+// SetArchived archives or unarchives the note
+func (n *Note) SetArchived(archived bool) {
+	if n.IsArchived == archived {
+		return
+	}
+	n.IsArchived = archived
+	n.UpdatedAt = time.Now()
+}
+
+// SetReminder schedules a reminder for the note at the given time
+func (n *Note) SetReminder(at time.Time) {
+	n.RemindAt = &at
 	n.UpdatedAt = time.Now()
 }
 
-// Archive archives or unarchives the note
-func (n *Note) Archive() {
-	n.IsArchived = !n.IsArchived
+// ClearReminder removes any pending reminder from the note
+func (n *Note) ClearReminder() {
+	n.RemindAt = nil
 	n.UpdatedAt = time.Now()
 }
 
+// SetDue sets the due date of the note, or clears it when at is nil
+func (n *Note) SetDue(at *time.Time) {
+	n.DueAt = at
+	n.UpdatedAt = time.Now()
+}
+
+// ReminderDue reports whether the note has a reminder that should fire at now
+func (n *Note) ReminderDue(now time.Time) bool {
+	return n.RemindAt != nil && !n.RemindAt.After(now)
+}
+
+// IsOverdue reports whether the note has a due date that has already passed
+func (n *Note) IsOverdue(now time.Time) bool {
+	return n.DueAt != nil && n.DueAt.Before(now)
+}
+
+// Clone returns a deep copy of the note
+func (n *Note) Clone() *Note {
+	clone := *n
+	if n.Tags != nil {
+		clone.Tags = append([]string(nil), n.Tags...)
+	}
+	if n.Links != nil {
+		clone.Links = append([]int(nil), n.Links...)
+	}
+	if n.Attachments != nil {
+		clone.Attachments = append([]string(nil), n.Attachments...)
+	}
+	if n.RemindAt != nil {
+		remindAt := *n.RemindAt
+		clone.RemindAt = &remindAt
+	}
+	if n.DueAt != nil {
+		dueAt := *n.DueAt
+		clone.DueAt = &dueAt
+	}
+	if n.Properties != nil {
+		clone.Properties = make(map[string]interface{}, len(n.Properties))
+		for name, value := range n.Properties {
+			clone.Properties[name] = value
+		}
+	}
+	return &clone
+}
+
+// SyncID returns the UID of the note, or for notes without one an ID derived
+// from its ID and creation time. Copies of a notes directory therefore agree
+// on the identity of the notes they share.
+func (n *Note) SyncID() string {
+	if n.UID != "" {
+		return n.UID
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d/%d", n.ID, n.CreatedAt.UnixNano())))
+	return hex.EncodeToString(sum[:8])
+}
+
 // ToJSON converts the note to JSON string
 func (n *Note) ToJSON() (string, error) {
 	data, err := json.MarshalIndent(n, "", "  ")
@@ -0,0 +1,197 @@
+package note
+
+import (
+	"fmt"
+	"sort"
+)
+
+// SortNotes sorts notes in list order: pinned notes before the others, and
+// within both groups the notes with a manual position in position order,
+// followed by the rest newest first
+func SortNotes(notes []*Note) {
+	sort.SliceStable(notes, func(i, j int) bool {
+		a, b := notes[i], notes[j]
+		if a.IsPinned != b.IsPinned {
+			return a.IsPinned
+		}
+		if (a.Position > 0) != (b.Position > 0) {
+			return a.Position > 0
+		}
+		if a.Position != b.Position {
+			return a.Position < b.Position
+		}
+		return a.CreatedAt.After(b.CreatedAt)
+	})
+}
+
+// PinNote pins a note to the top of lists, or unpins it
+func (s *Storage) PinNote(id int, pinned bool) (*Note, error) {
+	return s.modify(id, Change{Action: ActionPin}, func(note *Note) error {
+		note.SetPinned(pinned)
+		return nil
+	})
+}
+
+// MoveNote moves a note right before or after another note in list order.
+// Both notes must be pinned or both unpinned. Notes listed before the new
+// place that had no manual position are given one, so they stay in place.
+func (s *Storage) MoveNote(id, target int, after bool) (*Note, error) {
+	moved, previous, others, err := s.moveNote(id, target, after)
+	if err != nil {
+		return nil, err
+	}
+
+	place := "before"
+	if after {
+		place = "after"
+	}
+	s.notify(Change{Action: ActionMove, ID: id, Before: previous, After: moved, Detail: fmt.Sprintf("%s note %d", place, target), Others: others})
+	return moved, nil
+}
+
+func (s *Storage) moveNote(id, target int, after bool) (moved, previous *Note, others []Change, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id == target {
+		return nil, nil, nil, fmt.Errorf("cannot move note %d relative to itself", id)
+	}
+	previous, err = s.readable(id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	other, err := s.readable(target)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if previous.IsPinned != other.IsPinned {
+		return nil, nil, nil, fmt.Errorf("cannot move note %d next to note %d, only one of them is pinned", id, target)
+	}
+
+	// The group in its current order, without the moved note
+	var group []*Note
+	for _, n := range s.notes {
+		if n.IsPinned == previous.IsPinned && n.ID != id {
+			group = append(group, n)
+		}
+	}
+	SortNotes(group)
+
+	index := 0
+	for i, n := range group {
+		if n.ID == target {
+			index = i
+		}
+	}
+	if after {
+		index++
+	}
+	group = append(group[:index], append([]*Note{previous}, group[index:]...)...)
+
+	// Number the notes up to the moved one and every note that already had
+	// a position; the others keep following in their natural order
+	end := index
+	for i, n := range group {
+		if n.Position > 0 && n.ID != id {
+			end = max(end, i)
+		}
+	}
+	ids := make([]int, 0, end+1)
+	for _, n := range group[:end+1] {
+		ids = append(ids, n.ID)
+	}
+
+	renumbered, err := s.reorder(ids)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for _, change := range renumbered {
+		if change.ID != id {
+			others = append(others, change)
+		}
+	}
+	return s.notes[id], previous, others, nil
+}
+
+// ReorderNotes gives the notes the order of ids, such as after dragging a
+// note to a new place. Pinned and other notes are ordered separately; notes
+// that had a manual position but are not listed follow the listed ones.
+func (s *Storage) ReorderNotes(ids []int) error {
+	renumbered, err := s.reorderNotes(ids)
+	if err != nil {
+		return err
+	}
+
+	s.notify(Change{Action: ActionReorder, Others: renumbered})
+	return nil
+}
+
+func (s *Storage) reorderNotes(ids []int) ([]Change, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := make(map[int]bool)
+	for _, id := range ids {
+		if _, err := s.readable(id); err != nil {
+			return nil, err
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("note %d is listed twice", id)
+		}
+		seen[id] = true
+	}
+
+	return s.reorder(ids)
+}
+
+// reorder numbers the notes of ids from 1 within their group, followed by
+// the other notes that had a position, and saves the notes whose position
+// changed, which it returns as changes; callers must hold s.mu
+func (s *Storage) reorder(ids []int) ([]Change, error) {
+	listed := make(map[int]bool)
+	order := make([]*Note, 0, len(ids))
+	for _, id := range ids {
+		listed[id] = true
+		order = append(order, s.notes[id])
+	}
+
+	var rest []*Note
+	for _, n := range s.notes {
+		if n.Position > 0 && !listed[n.ID] {
+			rest = append(rest, n)
+		}
+	}
+	SortNotes(rest)
+	order = append(order, rest...)
+
+	var changes []Change
+	positions := make(map[bool]int)
+	for _, n := range order {
+		positions[n.IsPinned]++
+		if n.Position == positions[n.IsPinned] {
+			continue
+		}
+
+		moved := n.Clone()
+		moved.Position = positions[n.IsPinned]
+		if err := s.saveNote(moved); err != nil {
+			return changes, err
+		}
+		s.notes[moved.ID] = moved
+		changes = append(changes, Change{Action: ActionReorder, ID: moved.ID, Before: n, After: moved})
+	}
+	return changes, nil
+}
+
+// readable returns a note that exists and is not locked; callers must hold
+// s.mu
+func (s *Storage) readable(id int) (*Note, error) {
+	if _, locked := s.locked[id]; locked {
+		return nil, fmt.Errorf("note %d is encrypted: %w", id, ErrLocked)
+	}
+	note, exists := s.notes[id]
+	if !exists {
+		return nil, fmt.Errorf("note with ID %d %w", id, ErrNotFound)
+	}
+	return note, nil
+}
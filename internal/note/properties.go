@@ -0,0 +1,440 @@
+package note
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// schemaFile is the name of the property schema inside the meta directory
+const schemaFile = "schema.json"
+
+// DateLayout is the form dates are stored in by date properties
+const DateLayout = "2006-01-02"
+
+// PropertyType is the type of a property declared in the schema
+type PropertyType string
+
+// Property types
+const (
+	TypeString PropertyType = "string"
+	TypeNumber PropertyType = "number"
+	TypeDate   PropertyType = "date"
+	TypeEnum   PropertyType = "enum"
+	TypeBool   PropertyType = "bool"
+)
+
+// PropertyDef declares the type of a property
+type PropertyDef struct {
+	Type PropertyType `json:"type"`
+	// Values are the allowed values of enum properties
+	Values []string `json:"values,omitempty"`
+}
+
+// String describes the definition, such as "enum (draft, done)"
+func (d PropertyDef) String() string {
+	if d.Type == TypeEnum {
+		return fmt.Sprintf("enum (%s)", strings.Join(d.Values, ", "))
+	}
+	return string(d.Type)
+}
+
+// Schema declares the types of properties in a notes directory. Properties
+// that are not declared may hold any string, number or bool.
+type Schema map[string]PropertyDef
+
+// propertyName matches valid property names
+var propertyName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// PropertyName normalizes a property name and checks it
+func PropertyName(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !propertyName.MatchString(name) {
+		return "", fmt.Errorf("invalid property name %q (use letters, digits, - and _)", name)
+	}
+	return name, nil
+}
+
+// Define declares a property. Enum properties need at least one value.
+func (sc Schema) Define(name string, typ PropertyType, values []string) error {
+	name, err := PropertyName(name)
+	if err != nil {
+		return err
+	}
+
+	switch typ {
+	case TypeString, TypeNumber, TypeDate, TypeBool:
+		if len(values) > 0 {
+			return fmt.Errorf("only enum properties have values")
+		}
+	case TypeEnum:
+		if len(values) == 0 {
+			return fmt.Errorf("enum property %s needs values", name)
+		}
+	default:
+		return fmt.Errorf("unknown property type %q (use string, number, date, enum or bool)", typ)
+	}
+
+	sc[name] = PropertyDef{Type: typ, Values: values}
+	return nil
+}
+
+// Parse converts the text form of a property value, as typed on the command
+// line, to the value stored on notes. Declared properties are converted to
+// their type; others become a bool or number if they look like one, or stay
+// a string.
+func (sc Schema) Parse(name, text string) (interface{}, error) {
+	text = strings.TrimSpace(text)
+	def, declared := sc[name]
+	if !declared {
+		if b, err := strconv.ParseBool(text); err == nil {
+			return b, nil
+		}
+		if f, err := strconv.ParseFloat(text, 64); err == nil {
+			return f, nil
+		}
+		return text, nil
+	}
+
+	switch def.Type {
+	case TypeNumber:
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a number, got %q", name, text)
+		}
+		return f, nil
+	case TypeBool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false, got %q", name, text)
+		}
+		return b, nil
+	case TypeDate:
+		for _, layout := range []string{DateLayout, time.RFC3339} {
+			if t, err := time.Parse(layout, text); err == nil {
+				return t.Format(DateLayout), nil
+			}
+		}
+		return nil, fmt.Errorf("%s must be a date like 2024-05-31, got %q", name, text)
+	case TypeEnum:
+		for _, value := range def.Values {
+			if strings.EqualFold(value, text) {
+				return value, nil
+			}
+		}
+		return nil, fmt.Errorf("%s must be one of %s, got %q", name, strings.Join(def.Values, ", "), text)
+	}
+	return text, nil
+}
+
+// Check verifies that the declared properties of a note have values of
+// their type
+func (sc Schema) Check(n *Note) error {
+	for name, value := range n.Properties {
+		def, declared := sc[name]
+		if !declared {
+			continue
+		}
+
+		var ok bool
+		switch def.Type {
+		case TypeString:
+			_, ok = value.(string)
+		case TypeNumber:
+			_, ok = toFloat(value)
+		case TypeBool:
+			_, ok = value.(bool)
+		case TypeDate:
+			var s string
+			if s, ok = value.(string); ok {
+				_, err := time.Parse(DateLayout, s)
+				ok = err == nil
+			}
+		case TypeEnum:
+			var s string
+			if s, ok = value.(string); ok {
+				ok = false
+				for _, allowed := range def.Values {
+					ok = ok || s == allowed
+				}
+			}
+		}
+		if !ok {
+			return fmt.Errorf("property %s of note %d must be %s, got %v", name, n.ID, def, value)
+		}
+	}
+	return nil
+}
+
+// validateProperties checks property names and that values are plain
+// strings, numbers or bools
+func (n *Note) validateProperties() error {
+	for name, value := range n.Properties {
+		if _, err := PropertyName(name); err != nil {
+			return err
+		}
+		switch value.(type) {
+		case string, bool:
+		default:
+			if _, ok := toFloat(value); !ok {
+				return fmt.Errorf("property %s has unsupported value %v", name, value)
+			}
+		}
+	}
+	return nil
+}
+
+// PropertyText returns the text form of a property value
+func PropertyText(value interface{}) string {
+	if f, ok := toFloat(value); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// toFloat returns numeric property values as float64; JSON decodes numbers
+// as float64 but notes built in code may use ints
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// propertyOperators are the comparisons of property filters, longest first
+var propertyOperators = []string{">=", "<=", "!=", "=", ">", "<"}
+
+// ParsePropertyFilter parses a property condition such as "status=draft",
+// "difficulty>=3" or "due<2024-06-01". A name alone matches notes that have
+// the property. Numbers and bools compare by value, other values as text,
+// ignoring case, which orders dates correctly.
+func ParsePropertyFilter(expr string) (func(n *Note) bool, error) {
+	for _, op := range propertyOperators {
+		name, want, found := strings.Cut(expr, op)
+		if !found {
+			continue
+		}
+		name, err := PropertyName(name)
+		if err != nil {
+			return nil, err
+		}
+		return func(n *Note) bool {
+			value, ok := n.Properties[name]
+			return ok && compareProperty(value, op, strings.TrimSpace(want))
+		}, nil
+	}
+
+	name, err := PropertyName(expr)
+	if err != nil {
+		return nil, err
+	}
+	return func(n *Note) bool {
+		_, ok := n.Properties[name]
+		return ok
+	}, nil
+}
+
+// compareProperty compares a property value with the text of a filter
+func compareProperty(value interface{}, op, want string) bool {
+	var cmp int
+	if f, ok := toFloat(value); ok {
+		w, err := strconv.ParseFloat(want, 64)
+		if err != nil {
+			return op == "!="
+		}
+		switch {
+		case f < w:
+			cmp = -1
+		case f > w:
+			cmp = 1
+		}
+	} else {
+		cmp = strings.Compare(strings.ToLower(PropertyText(value)), strings.ToLower(want))
+	}
+
+	switch op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
+
+// PropertyNames returns the names of the properties of a note, sorted
+func (n *Note) PropertyNames() []string {
+	names := make([]string, 0, len(n.Properties))
+	for name := range n.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PropertyString returns the text form of a property of the note, or ""
+// if the note does not have it
+func (n *Note) PropertyString(name string) string {
+	value, ok := n.Properties[name]
+	if !ok {
+		return ""
+	}
+	return PropertyText(value)
+}
+
+// Schema returns the property schema of the notes directory
+func (s *Storage) Schema() Schema {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	schema := make(Schema, len(s.schema))
+	for name, def := range s.schema {
+		schema[name] = def
+	}
+	return schema
+}
+
+// SaveSchema replaces and persists the property schema. Notes are not
+// checked against the new schema until they are changed.
+func (s *Storage) SaveSchema(schema Schema) error {
+	if err := s.saveSchema(schema); err != nil {
+		return err
+	}
+
+	s.notify(Change{Action: ActionSettings})
+	return nil
+}
+
+func (s *Storage) saveSchema(schema Schema) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal schema: %w", err)
+	}
+
+	if err := os.MkdirAll(s.MetaDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create meta directory: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(s.MetaDir(), schemaFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to write schema: %w", err)
+	}
+
+	s.schema = schema
+	return nil
+}
+
+// loadSchema reads the property schema of the notes directory, if any
+func (s *Storage) loadSchema() error {
+	s.schema = Schema{}
+
+	data, err := os.ReadFile(filepath.Join(s.MetaDir(), schemaFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read schema: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &s.schema); err != nil {
+		return fmt.Errorf("failed to unmarshal schema: %w", err)
+	}
+	return nil
+}
+
+// SetProperty sets a property of a note from its text form, converted as
+// described by Schema.Parse
+func (s *Storage) SetProperty(id int, name, text string) (*Note, error) {
+	name, err := PropertyName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.modify(id, Change{Action: ActionSetProperty, Detail: name}, func(note *Note) error {
+		value, err := s.schema.Parse(name, text)
+		if err != nil {
+			return err
+		}
+		if note.Properties == nil {
+			note.Properties = make(map[string]interface{})
+		}
+		note.Properties[name] = value
+		note.UpdatedAt = time.Now()
+		return s.validate(note)
+	})
+}
+
+// UnsetProperty removes a property from a note
+func (s *Storage) UnsetProperty(id int, name string) (*Note, error) {
+	name, err := PropertyName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.modify(id, Change{Action: ActionUnsetProperty, Detail: name}, func(note *Note) error {
+		if _, ok := note.Properties[name]; !ok {
+			return fmt.Errorf("note %d has no property %s", id, name)
+		}
+		delete(note.Properties, name)
+		if len(note.Properties) == 0 {
+			note.Properties = nil
+		}
+		note.UpdatedAt = time.Now()
+		return nil
+	})
+}
+
+// GetPropertyNames returns the names of all properties used by notes or
+// declared in the schema, sorted
+func (s *Storage) GetPropertyNames() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	for name := range s.schema {
+		seen[name] = true
+	}
+	for _, note := range s.notes {
+		for name := range note.Properties {
+			seen[name] = true
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validate checks a note and its properties against the schema; callers
+// must hold s.mu
+func (s *Storage) validate(note *Note) error {
+	if err := note.Validate(); err != nil {
+		return err
+	}
+	return s.schema.Check(note)
+}
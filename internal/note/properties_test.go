@@ -0,0 +1,67 @@
+package note
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPutNoteChecksSchema(t *testing.T) {
+	storage, err := NewStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema := Schema{}
+	if err := schema.Define("status", TypeEnum, []string{"draft", "done"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := schema.Define("difficulty", TypeNumber, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.SaveSchema(schema); err != nil {
+		t.Fatal(err)
+	}
+
+	original, err := storage.CreateNote("Plan", "Content", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if original, err = storage.SetProperty(original.ID, "status", "draft"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		properties map[string]interface{}
+		wantErr    bool
+	}{
+		{name: "enum value not allowed", properties: map[string]interface{}{"status": "archived"}, wantErr: true},
+		{name: "number as text", properties: map[string]interface{}{"difficulty": "hard"}, wantErr: true},
+		{name: "valid", properties: map[string]interface{}{"status": "done", "difficulty": 3.0, "other": "any"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := original.Clone()
+			n.Properties = tt.properties
+
+			_, err := storage.PutNote(n)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PutNote() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			stored, err := storage.GetNote(original.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := tt.properties
+			if tt.wantErr {
+				want = original.Properties
+			}
+			if !reflect.DeepEqual(stored.Properties, want) {
+				t.Errorf("properties = %v, want %v", stored.Properties, want)
+			}
+			original = stored
+		})
+	}
+}
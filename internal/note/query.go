@@ -0,0 +1,224 @@
+package note
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Query selects notes with a small search language. Terms are separated by
+// spaces and must all match; a leading "-" negates a term:
+//
+//	tag:go              notes tagged go, or with a nested tag such as go/sync
+//	notebook:work       notes in the notebook work or one of its sub-notebooks
+//	is:archived         also is:favorite, is:pinned, is:active, is:encrypted
+//	has:reminder        also has:due, has:links, has:attachments, has:tags, has:notebook,
+//	                    has:properties
+//	title:slices        notes whose title contains the word
+//	id:12               the note with ID 12
+//	prop:status=draft   notes whose property matches, see ParsePropertyFilter
+//	channels            notes whose title or content contains the word
+//	"worker pool"       phrases are quoted, also in values: tag:"go tips"
+type Query struct {
+	source string
+	terms  []queryTerm
+}
+
+// queryTerm is a single condition of a query
+type queryTerm struct {
+	negate bool
+	match  func(n *Note) bool
+}
+
+// ParseQuery parses a query. An empty query matches every note.
+func ParseQuery(input string) (*Query, error) {
+	q := &Query{source: strings.TrimSpace(input)}
+
+	words, err := splitQuery(input)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, word := range words {
+		term := queryTerm{}
+		text := word.text
+		if strings.HasPrefix(text, "-") && len(text) > 1 {
+			term.negate = true
+			text = text[1:]
+		}
+
+		key, value, hasKey := strings.Cut(text, ":")
+		if !hasKey || word.phrase {
+			term.match = textMatcher(text)
+		} else {
+			term.match, err = filterMatcher(strings.ToLower(key), value)
+			if err != nil {
+				return nil, err
+			}
+		}
+		q.terms = append(q.terms, term)
+	}
+
+	return q, nil
+}
+
+// String returns the query as it was written
+func (q *Query) String() string {
+	return q.source
+}
+
+// Match reports whether a note satisfies every term of the query
+func (q *Query) Match(n *Note) bool {
+	for _, term := range q.terms {
+		if term.match(n) == term.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// Filter returns the notes matching the query, keeping their order
+func (q *Query) Filter(notes []*Note) []*Note {
+	var matched []*Note
+	for _, n := range notes {
+		if q.Match(n) {
+			matched = append(matched, n)
+		}
+	}
+	return matched
+}
+
+// filterMatcher returns the matcher for a key:value term
+func filterMatcher(key, value string) (func(n *Note) bool, error) {
+	if value == "" {
+		return nil, fmt.Errorf("missing value for %s:", key)
+	}
+	lower := strings.ToLower(value)
+
+	switch key {
+	case "tag":
+		return func(n *Note) bool {
+			for _, tag := range n.Tags {
+				if tag == lower || strings.HasPrefix(tag, lower+"/") {
+					return true
+				}
+			}
+			return false
+		}, nil
+
+	case "notebook":
+		return func(n *Note) bool {
+			notebook := strings.ToLower(n.Notebook)
+			return notebook == lower || strings.HasPrefix(notebook, lower+"/")
+		}, nil
+
+	case "is":
+		switch lower {
+		case "archived":
+			return func(n *Note) bool { return n.IsArchived }, nil
+		case "favorite":
+			return func(n *Note) bool { return n.IsFavorite }, nil
+		case "pinned":
+			return func(n *Note) bool { return n.IsPinned }, nil
+		case "active":
+			return func(n *Note) bool { return !n.IsArchived }, nil
+		case "encrypted":
+			return func(n *Note) bool { return n.Encrypted }, nil
+		}
+		return nil, fmt.Errorf("unknown value is:%s (use archived, favorite, pinned, active or encrypted)", value)
+
+	case "has":
+		switch lower {
+		case "reminder":
+			return func(n *Note) bool { return n.RemindAt != nil }, nil
+		case "due":
+			return func(n *Note) bool { return n.DueAt != nil }, nil
+		case "links":
+			return func(n *Note) bool { return len(n.Links) > 0 }, nil
+		case "attachments":
+			return func(n *Note) bool { return len(n.Attachments) > 0 }, nil
+		case "tags":
+			return func(n *Note) bool { return len(n.Tags) > 0 }, nil
+		case "notebook":
+			return func(n *Note) bool { return n.Notebook != "" }, nil
+		case "properties":
+			return func(n *Note) bool { return len(n.Properties) > 0 }, nil
+		}
+		return nil, fmt.Errorf("unknown value has:%s (use reminder, due, links, attachments, tags, notebook or properties)", value)
+
+	case "title":
+		return func(n *Note) bool {
+			return strings.Contains(strings.ToLower(n.Title), lower)
+		}, nil
+
+	case "id":
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid id:%s", value)
+		}
+		return func(n *Note) bool { return n.ID == id }, nil
+
+	case "prop":
+		return ParsePropertyFilter(value)
+	}
+
+	return nil, fmt.Errorf("unknown filter %q (use tag, notebook, is, has, title, id or prop)", key+":")
+}
+
+// textMatcher matches notes whose title or content contains text
+func textMatcher(text string) func(n *Note) bool {
+	lower := strings.ToLower(text)
+	return func(n *Note) bool {
+		return strings.Contains(strings.ToLower(n.Title), lower) ||
+			strings.Contains(strings.ToLower(n.Content), lower)
+	}
+}
+
+// queryWord is a word of a query. Phrases start with a quote and are always
+// searched as text, even if they contain a colon.
+type queryWord struct {
+	text   string
+	phrase bool
+}
+
+// splitQuery splits a query into words, keeping quoted text together and
+// removing the quotes
+func splitQuery(input string) ([]queryWord, error) {
+	var (
+		words   []queryWord
+		current strings.Builder
+		quoted  bool
+		started bool
+		phrase  bool
+	)
+
+	for _, r := range input {
+		switch {
+		case r == '"':
+			if current.Len() == 0 || current.String() == "-" {
+				phrase = true
+			}
+			quoted = !quoted
+			started = true
+		case unicode.IsSpace(r) && !quoted:
+			if started {
+				words = append(words, queryWord{text: current.String(), phrase: phrase})
+				current.Reset()
+				started = false
+				phrase = false
+			}
+		default:
+			current.WriteRune(r)
+			started = true
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote in query")
+	}
+	if started && current.Len() > 0 {
+		words = append(words, queryWord{text: current.String(), phrase: phrase})
+	}
+
+	return words, nil
+}
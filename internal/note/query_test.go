@@ -0,0 +1,105 @@
+package note
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestQueryFilter(t *testing.T) {
+	remind := time.Date(2024, time.May, 20, 9, 0, 0, 0, time.UTC)
+	notes := []*Note{
+		{ID: 1, Title: "Go Slices", Content: "Slices are dynamic arrays", Tags: []string{"go", "go/slices"}, Notebook: "Work/Go"},
+		{ID: 2, Title: "Channels", Content: "Use a worker pool with channels", Tags: []string{"go/sync"}, IsFavorite: true, RemindAt: &remind},
+		{ID: 3, Title: "Groceries", Content: "Milk, eggs: the usual", Notebook: "Home", IsArchived: true},
+		{ID: 4, Title: "Draft: Go tips", Content: "Nothing yet", Tags: []string{"go tips"}, IsPinned: true, Links: []int{1},
+			Properties: map[string]interface{}{"status": "draft", "difficulty": 3.0}},
+		{ID: 5, Title: "Diary", Content: "secret", Encrypted: true, Properties: map[string]interface{}{"status": "done"}},
+	}
+
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{query: "", want: []int{1, 2, 3, 4, 5}},
+		{query: "go", want: []int{1, 4}},
+		{query: "SLICES", want: []int{1}},
+		{query: "tag:go", want: []int{1, 2}},
+		{query: "tag:go/sync", want: []int{2}},
+		{query: "tag:gopher", want: nil},
+		{query: `tag:"go tips"`, want: []int{4}},
+		{query: "-tag:go", want: []int{3, 4, 5}},
+		{query: "notebook:work", want: []int{1}},
+		{query: "notebook:Work/Go", want: []int{1}},
+		{query: "notebook:wor", want: nil},
+		{query: "is:archived", want: []int{3}},
+		{query: "is:active", want: []int{1, 2, 4, 5}},
+		{query: "is:favorite", want: []int{2}},
+		{query: "is:pinned", want: []int{4}},
+		{query: "is:encrypted", want: []int{5}},
+		{query: "has:reminder", want: []int{2}},
+		{query: "has:links", want: []int{4}},
+		{query: "has:tags", want: []int{1, 2, 4}},
+		{query: "has:notebook", want: []int{1, 3}},
+		{query: "has:properties", want: []int{4, 5}},
+		{query: "-has:properties -is:archived", want: []int{1, 2}},
+		{query: "title:go", want: []int{1, 4}},
+		{query: "id:3", want: []int{3}},
+		{query: "prop:status=draft", want: []int{4}},
+		{query: "prop:status", want: []int{4, 5}},
+		{query: "prop:difficulty>=3", want: []int{4}},
+		{query: `"worker pool"`, want: []int{2}},
+		{query: `"eggs: the"`, want: []int{3}},
+		{query: `-"worker pool" go`, want: []int{1, 4}},
+		{query: "tag:go is:favorite", want: []int{2}},
+		{query: "  tag:go   slices  ", want: []int{1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("ParseQuery(%q) error = %v", tt.query, err)
+			}
+
+			var got []int
+			for _, n := range q.Filter(notes) {
+				got = append(got, n.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseQuery(%q).Filter() = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []string{
+		"tag:",
+		"is:deleted",
+		"has:wings",
+		"id:abc",
+		"color:red",
+		`"worker pool`,
+		`tag:"go tips`,
+		"prop:=draft",
+	}
+
+	for _, query := range tests {
+		t.Run(query, func(t *testing.T) {
+			if _, err := ParseQuery(query); err == nil {
+				t.Errorf("ParseQuery(%q) = nil error, want an error", query)
+			}
+		})
+	}
+}
+
+func TestQueryString(t *testing.T) {
+	q, err := ParseQuery("  tag:go -is:archived ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := q.String(); got != "tag:go -is:archived" {
+		t.Errorf("String() = %q, want %q", got, "tag:go -is:archived")
+	}
+}
@@ -0,0 +1,102 @@
+package note
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// SetReminder schedules a reminder for a note
+func (s *Storage) SetReminder(id int, at time.Time) (*Note, error) {
+	return s.modify(id, Change{Action: ActionRemind}, func(note *Note) error {
+		note.SetReminder(at)
+		return nil
+	})
+}
+
+// ClearReminder removes the pending reminder from a note
+func (s *Storage) ClearReminder(id int) (*Note, error) {
+	return s.modify(id, Change{Action: ActionRemind}, func(note *Note) error {
+		note.ClearReminder()
+		return nil
+	})
+}
+
+// SnoozeReminder pushes the reminder of a note back by the given duration,
+// counted from now so that already fired reminders can be snoozed as well
+func (s *Storage) SnoozeReminder(id int, d time.Duration) (*Note, error) {
+	if d <= 0 {
+		return nil, fmt.Errorf("snooze duration must be positive")
+	}
+
+	return s.modify(id, Change{Action: ActionRemind}, func(note *Note) error {
+		note.SetReminder(time.Now().Add(d))
+		return nil
+	})
+}
+
+// SetDueDate sets or clears (when at is nil) the due date of a note
+func (s *Storage) SetDueDate(id int, at *time.Time) (*Note, error) {
+	return s.modify(id, Change{Action: ActionDue}, func(note *Note) error {
+		note.SetDue(at)
+		return nil
+	})
+}
+
+// GetDueReminders returns active notes whose reminder should fire at now
+func (s *Storage) GetDueReminders(now time.Time) []*Note {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var dueNotes []*Note
+	for _, note := range s.notes {
+		if !note.IsArchived && note.ReminderDue(now) {
+			dueNotes = append(dueNotes, note)
+		}
+	}
+
+	// Sort by reminder time (oldest first)
+	sort.Slice(dueNotes, func(i, j int) bool {
+		return dueNotes[i].RemindAt.Before(*dueNotes[j].RemindAt)
+	})
+
+	return dueNotes
+}
+
+// GetScheduledNotes returns active notes that have a reminder or due date
+func (s *Storage) GetScheduledNotes() []*Note {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var scheduledNotes []*Note
+	for _, note := range s.notes {
+		if note.IsArchived {
+			continue
+		}
+
+		if note.RemindAt != nil || note.DueAt != nil {
+			scheduledNotes = append(scheduledNotes, note)
+		}
+	}
+
+	// Sort by the earliest of reminder and due date (soonest first)
+	sort.Slice(scheduledNotes, func(i, j int) bool {
+		return nextScheduled(scheduledNotes[i]).Before(nextScheduled(scheduledNotes[j]))
+	})
+
+	return scheduledNotes
+}
+
+// nextScheduled returns the earliest of the reminder and due date of a note
+func nextScheduled(note *Note) time.Time {
+	switch {
+	case note.RemindAt == nil:
+		return *note.DueAt
+	case note.DueAt == nil:
+		return *note.RemindAt
+	case note.DueAt.Before(*note.RemindAt):
+		return *note.DueAt
+	default:
+		return *note.RemindAt
+	}
+}
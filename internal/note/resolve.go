@@ -0,0 +1,122 @@
+package note
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FindNotes returns the notes a reference such as "12", "Go Slices" or "slic"
+// may mean. A reference of digits only is an ID and never matches titles, so
+// "12" does not find "Release 2012 plan". Otherwise titles equal to the
+// reference come first, then titles starting with it, titles containing it
+// and finally titles containing its letters in order, best first. A kind of
+// match is only tried when the ones before found nothing, so a single note
+// means the reference is unambiguous.
+func (s *Storage) FindNotes(ref string) ([]*Note, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil, fmt.Errorf("no note given")
+	}
+
+	if IsID(ref) {
+		id, err := strconv.Atoi(ref)
+		if err != nil {
+			return nil, fmt.Errorf("note with ID %s %w", ref, ErrNotFound)
+		}
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		n, err := s.readable(id)
+		if err != nil {
+			return nil, err
+		}
+		return []*Note{n}, nil
+	}
+
+	return MatchTitles(s.GetAllNotes(), ref), nil
+}
+
+// IsID reports whether a reference is made of digits only, so that it can
+// only mean an ID
+func IsID(ref string) bool {
+	if ref == "" {
+		return false
+	}
+	for _, r := range ref {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// HasNote reports whether a note with the ID exists, including encrypted
+// notes that are locked
+func (s *Storage) HasNote(id int) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, exists := s.notes[id]
+	_, locked := s.locked[id]
+	return exists || locked
+}
+
+// MatchTitles returns the notes whose title matches ref as described for
+// FindNotes, ignoring case
+func MatchTitles(notes []*Note, ref string) []*Note {
+	ref = strings.ToLower(strings.TrimSpace(ref))
+
+	var exact, prefix, contains []*Note
+	var fuzzy []*Note
+	gaps := make(map[*Note]int)
+	for _, n := range notes {
+		title := strings.ToLower(n.Title)
+		switch {
+		case title == ref:
+			exact = append(exact, n)
+		case strings.HasPrefix(title, ref):
+			prefix = append(prefix, n)
+		case strings.Contains(title, ref):
+			contains = append(contains, n)
+		default:
+			if gap, ok := fuzzyGap(title, ref); ok {
+				fuzzy = append(fuzzy, n)
+				gaps[n] = gap
+			}
+		}
+	}
+
+	for _, matches := range [][]*Note{exact, prefix, contains} {
+		if len(matches) > 0 {
+			return matches
+		}
+	}
+	sort.SliceStable(fuzzy, func(i, j int) bool {
+		return gaps[fuzzy[i]] < gaps[fuzzy[j]]
+	})
+	return fuzzy
+}
+
+// fuzzyGap reports whether title contains the letters of ref in order, and
+// how many other characters lie between them
+func fuzzyGap(title, ref string) (int, bool) {
+	letters := []rune(strings.Join(strings.Fields(ref), ""))
+	if len(letters) == 0 {
+		return 0, false
+	}
+
+	gap, next, started := 0, 0, false
+	for _, r := range title {
+		if r == letters[next] {
+			started = true
+			next++
+			if next == len(letters) {
+				return gap, true
+			}
+		} else if started {
+			gap++
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,143 @@
+package note
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMatchTitles(t *testing.T) {
+	notes := []*Note{
+		{ID: 1, Title: "Go Slices"},
+		{ID: 2, Title: "Release 2012 plan"},
+		{ID: 3, Title: "Slices in depth"},
+		{ID: 4, Title: "Go"},
+		{ID: 5, Title: "Goroutines and channels"},
+		{ID: 6, Title: "Using slices"},
+	}
+
+	tests := []struct {
+		ref  string
+		want []int
+	}{
+		{"go", []int{4}},
+		{"  GO SLICES ", []int{1}},
+		{"go s", []int{1}},
+		{"slices", []int{3}},
+		{"slic", []int{3}},
+		{"lice", []int{1, 3, 6}},
+		{"2012", []int{2}},
+		{"gslc", []int{6, 1}},
+		{"grtn", []int{5}},
+		{"gsc", []int{6, 1, 5}},
+		{"xyz", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			var got []int
+			for _, n := range MatchTitles(notes, tt.ref) {
+				got = append(got, n.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MatchTitles(%q) = %v, want %v", tt.ref, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindNotes(t *testing.T) {
+	s, err := NewStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, title := range []string{"Go Slices", "Release 2012 plan", "12 rules"} {
+		if _, err := s.CreateNote(title, "text", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		ref     string
+		want    []int
+		wantErr string
+	}{
+		{ref: "1", want: []int{1}},
+		{ref: " 2 ", want: []int{2}},
+		{ref: "12", wantErr: "note with ID 12 not found"},
+		{ref: "2012", wantErr: "note with ID 2012 not found"},
+		{ref: "99999999999999999999", wantErr: "note with ID 99999999999999999999 not found"},
+		{ref: "12 rules", want: []int{3}},
+		{ref: "release", want: []int{2}},
+		{ref: "", wantErr: "no note given"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			notes, err := s.FindNotes(tt.ref)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("FindNotes(%q) error = %v, want %q", tt.ref, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FindNotes(%q) error = %v", tt.ref, err)
+			}
+			var got []int
+			for _, n := range notes {
+				got = append(got, n.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindNotes(%q) = %v, want %v", tt.ref, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindNotesLocked(t *testing.T) {
+	s, err := NewStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetupEncryption("correct horse"); err != nil {
+		t.Fatal(err)
+	}
+	n, err := s.CreateNote("Secret", "hidden", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.EncryptNote(n.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Lock(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.FindNotes("1"); !errors.Is(err, ErrLocked) {
+		t.Errorf("FindNotes(\"1\") error = %v, want ErrLocked", err)
+	}
+	if !s.HasNote(n.ID) {
+		t.Errorf("HasNote(%d) = false for a locked note", n.ID)
+	}
+	if s.HasNote(n.ID + 1) {
+		t.Errorf("HasNote(%d) = true for a missing note", n.ID+1)
+	}
+}
+
+func TestIsID(t *testing.T) {
+	tests := map[string]bool{
+		"1":     true,
+		"2012":  true,
+		"":      false,
+		"-1":    false,
+		"+1":    false,
+		"1a":    false,
+		"12 go": false,
+	}
+	for ref, want := range tests {
+		if got := IsID(ref); got != want {
+			t.Errorf("IsID(%q) = %v, want %v", ref, got, want)
+		}
+	}
+}
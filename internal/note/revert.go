@@ -0,0 +1,125 @@
+package note
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrConflict is returned by Revert when a note is no longer in the version
+// a revision starts from
+var ErrConflict = errors.New("changed since")
+
+// Revision takes a note from one version to another. A nil version means the
+// note does not exist, so a revision from nil adds the note back and one to
+// nil deletes it.
+type Revision struct {
+	ID   int
+	From *Note
+	To   *Note
+}
+
+// Revert applies revisions as a whole, such as to undo or redo earlier
+// changes. Every note must still be as its revision starts from; otherwise
+// no note is changed and the error wraps ErrConflict. Notes are saved as
+// they are, keeping their timestamps, and attachment files are left alone.
+// A single change with the given action and detail is reported for all
+// notes.
+func (s *Storage) Revert(action Action, detail string, revisions []Revision) error {
+	if len(revisions) == 0 {
+		return nil
+	}
+	if err := s.revert(revisions); err != nil {
+		return err
+	}
+
+	change := Change{Action: action, Detail: detail}
+	for i, revision := range revisions {
+		step := Change{Action: action, ID: revision.ID, Before: revision.From, After: revision.To, Detail: detail}
+		if i == 0 {
+			change = step
+			continue
+		}
+		change.Others = append(change.Others, step)
+	}
+	s.notify(change)
+	return nil
+}
+
+func (s *Storage) revert(revisions []Revision) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := make(map[int]bool)
+	for _, revision := range revisions {
+		if seen[revision.ID] {
+			return fmt.Errorf("note %d is listed twice", revision.ID)
+		}
+		seen[revision.ID] = true
+
+		if _, locked := s.locked[revision.ID]; locked {
+			return fmt.Errorf("note %d is encrypted: %w", revision.ID, ErrLocked)
+		}
+		current, exists := s.notes[revision.ID]
+		switch {
+		case revision.From == nil && exists:
+			return fmt.Errorf("note %d %w: it was added", revision.ID, ErrConflict)
+		case revision.From != nil && !exists:
+			return fmt.Errorf("note %d %w: it was deleted", revision.ID, ErrConflict)
+		case revision.From != nil && !sameNote(current, revision.From):
+			return fmt.Errorf("note %d %q %w", revision.ID, current.Title, ErrConflict)
+		}
+	}
+
+	// Keep the files as they are, to put them back if a later note fails
+	saved := make(map[int][]byte)
+	var written []int
+	restore := func() {
+		for _, id := range written {
+			if data, ok := saved[id]; ok {
+				os.WriteFile(s.noteFile(id), data, 0644)
+			} else {
+				os.Remove(s.noteFile(id))
+			}
+		}
+	}
+
+	restored := make(map[int]*Note)
+	for _, revision := range revisions {
+		filename := s.noteFile(revision.ID)
+		if revision.From != nil {
+			data, err := os.ReadFile(filename)
+			if err != nil {
+				restore()
+				return fmt.Errorf("failed to read note %d, no note was changed: %w", revision.ID, err)
+			}
+			saved[revision.ID] = data
+		}
+
+		written = append(written, revision.ID)
+		var err error
+		if revision.To == nil {
+			err = os.Remove(filename)
+		} else {
+			restored[revision.ID] = revision.To.Clone()
+			err = s.saveNote(restored[revision.ID])
+		}
+		if err != nil {
+			restore()
+			return fmt.Errorf("failed to save note %d, no note was changed: %w", revision.ID, err)
+		}
+	}
+
+	for _, revision := range revisions {
+		note, ok := restored[revision.ID]
+		if !ok {
+			delete(s.notes, revision.ID)
+			continue
+		}
+		s.notes[revision.ID] = note
+		if revision.ID >= s.nextID {
+			s.nextID = revision.ID + 1
+		}
+	}
+	return nil
+}
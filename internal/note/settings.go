@@ -0,0 +1,131 @@
+package note
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// settingsFile is the name of the settings file inside the meta directory
+const settingsFile = "config.json"
+
+// Settings are options that apply to a single notes directory
+type Settings struct {
+	// FormatGoBlocks gofmt-formats fenced Go code blocks when notes are saved
+	FormatGoBlocks bool `json:"format_go_blocks"`
+	// GitAutoCommit commits every change to the git repository holding the
+	// notes directory
+	GitAutoCommit bool `json:"git_autocommit"`
+	// EncryptAll encrypts every note when it is saved; it requires
+	// encryption to be set up
+	EncryptAll bool `json:"encrypt_all"`
+}
+
+// Set updates a setting from its string form
+func (st *Settings) Set(key, value string) error {
+	switch key {
+	case "format_go_blocks":
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %s", key, value)
+		}
+		st.FormatGoBlocks = enabled
+	case "git_autocommit":
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %s", key, value)
+		}
+		st.GitAutoCommit = enabled
+	case "encrypt_all":
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %s", key, value)
+		}
+		st.EncryptAll = enabled
+	default:
+		return fmt.Errorf("unknown setting: %s", key)
+	}
+	return nil
+}
+
+// Values returns all settings in their string form
+func (st Settings) Values() map[string]string {
+	return map[string]string{
+		"format_go_blocks": strconv.FormatBool(st.FormatGoBlocks),
+		"git_autocommit":   strconv.FormatBool(st.GitAutoCommit),
+		"encrypt_all":      strconv.FormatBool(st.EncryptAll),
+	}
+}
+
+// Keys returns the names of all settings, sorted
+func (st Settings) Keys() []string {
+	var keys []string
+	for key := range st.Values() {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Settings returns the settings of the notes directory
+func (s *Storage) Settings() Settings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.settings
+}
+
+// SaveSettings replaces and persists the settings of the notes directory
+func (s *Storage) SaveSettings(settings Settings) error {
+	if err := s.saveSettings(settings); err != nil {
+		return err
+	}
+
+	s.notify(Change{Action: ActionSettings})
+	return nil
+}
+
+func (s *Storage) saveSettings(settings Settings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if settings.EncryptAll && s.vault == nil {
+		return fmt.Errorf("cannot turn on encrypt_all: %w (run 'gonotes encrypt --all')", ErrNoEncryption)
+	}
+
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal settings: %w", err)
+	}
+
+	if err := os.MkdirAll(s.MetaDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create meta directory: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(s.MetaDir(), settingsFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to write settings: %w", err)
+	}
+
+	s.settings = settings
+	return nil
+}
+
+// loadSettings reads the settings of the notes directory, if any
+func (s *Storage) loadSettings() error {
+	data, err := os.ReadFile(filepath.Join(s.MetaDir(), settingsFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read settings: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &s.settings); err != nil {
+		return fmt.Errorf("failed to unmarshal settings: %w", err)
+	}
+	return nil
+}
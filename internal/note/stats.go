@@ -0,0 +1,347 @@
+package note
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxMostEdited is the number of notes listed in Stats.MostEdited
+const maxMostEdited = 10
+
+// MaxRangeYears is the longest range of activity in Stats; all time goes
+// back no further either
+const MaxRangeYears = 10
+
+// Stats is a report on the notes, see Storage.GetStats. The counts of notes,
+// words and tags cover all notes; the activity covers the days of the range.
+type Stats struct {
+	Total     int `json:"total"`
+	Active    int `json:"active"`
+	Archived  int `json:"archived"`
+	Favorites int `json:"favorites"`
+	// Tags is the number of tags used by active notes
+	Tags int `json:"tags"`
+
+	Words             int     `json:"words"`
+	Characters        int     `json:"characters"`
+	AverageWords      float64 `json:"average_words"`
+	AverageCharacters float64 `json:"average_characters"`
+
+	// TagFrequency lists the tags of active notes, most used first
+	TagFrequency []TagCount `json:"tag_frequency"`
+	// TagCooccurrence counts the active notes having both of two tags, by
+	// tag and then by the other tag
+	TagCooccurrence map[string]map[string]int `json:"tag_cooccurrence"`
+
+	// Since is the first day of the range, nil for all time
+	Since *time.Time `json:"since,omitempty"`
+	// CreatedPerDay counts the notes created on every day of the range, and
+	// UpdatedPerDay the notes last changed on it after the day they were
+	// created; the weekly counts start on Mondays
+	CreatedPerDay  []DayCount `json:"created_per_day"`
+	UpdatedPerDay  []DayCount `json:"updated_per_day"`
+	CreatedPerWeek []DayCount `json:"created_per_week"`
+	UpdatedPerWeek []DayCount `json:"updated_per_week"`
+	// LongestStreak is the longest run of days in the range on which notes
+	// were created or updated, and CurrentStreak the run up to today
+	LongestStreak Streak `json:"longest_streak"`
+	CurrentStreak Streak `json:"current_streak"`
+	// MostEdited lists the notes last changed in the range with the most
+	// edits, counting every edit since the note was created, not only those
+	// in the range
+	MostEdited []EditCount `json:"most_edited"`
+}
+
+// TagCount is a tag with the number of notes having it
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// TagPair is two tags with the number of notes having both
+type TagPair struct {
+	Tags  [2]string `json:"tags"`
+	Count int       `json:"count"`
+}
+
+// DayCount is a number of notes on a day, or in the week starting on it
+type DayCount struct {
+	Date  string `json:"date"`
+	Count int    `json:"count"`
+}
+
+// Streak is a run of consecutive days with activity
+type Streak struct {
+	Days  int    `json:"days"`
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+}
+
+// EditCount is a note with the number of times it was changed since it was
+// created
+type EditCount struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+	Edits int    `json:"edits"`
+}
+
+// GetStats returns statistics about the notes, with the activity from since
+// until today; a zero since covers all time
+func (s *Storage) GetStats(since time.Time) Stats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	notes := make([]*Note, 0, len(s.notes))
+	for _, note := range s.notes {
+		notes = append(notes, note)
+	}
+	return newStats(notes, since, time.Now())
+}
+
+// ParseRange reads the range of GetStats, such as "30d", "12w", "6m", "1y",
+// a first day like "2024-01-31", or "all", and returns its first day; all
+// time is the zero time. Ranges longer than MaxRangeYears are an error.
+func ParseRange(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(strings.ToLower(value))
+	if value == "" || value == "all" {
+		return time.Time{}, nil
+	}
+	invalid := fmt.Errorf("invalid range %q (use such as 30d, 12w, 6m, 1y, 2024-01-31 or all)", value)
+	today := startOfDay(now)
+	oldest := oldestDay(today)
+	tooLong := fmt.Errorf("range %q is longer than %d years", value, MaxRangeYears)
+
+	if day, err := time.ParseInLocation(DateLayout, value, time.Local); err == nil {
+		if day.Before(oldest) {
+			return time.Time{}, tooLong
+		}
+		return day, nil
+	}
+
+	n, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || n < 1 {
+		return time.Time{}, invalid
+	}
+	// Larger numbers are too long in any unit, and could overflow below
+	if n > 366*MaxRangeYears {
+		return time.Time{}, tooLong
+	}
+
+	var first time.Time
+	switch value[len(value)-1] {
+	case 'd':
+		first = today.AddDate(0, 0, 1-n)
+	case 'w':
+		first = today.AddDate(0, 0, 1-7*n)
+	case 'm':
+		first = today.AddDate(0, -n, 1)
+	case 'y':
+		first = today.AddDate(-n, 0, 1)
+	default:
+		return time.Time{}, invalid
+	}
+	if first.Before(oldest) {
+		return time.Time{}, tooLong
+	}
+	return first, nil
+}
+
+// oldestDay returns the first day of a range of MaxRangeYears until today
+func oldestDay(today time.Time) time.Time {
+	return today.AddDate(-MaxRangeYears, 0, 1)
+}
+
+// TagPairs returns the pairs of tags used together, most common first
+func (st Stats) TagPairs() []TagPair {
+	var pairs []TagPair
+	for a, others := range st.TagCooccurrence {
+		for b, count := range others {
+			if a < b {
+				pairs = append(pairs, TagPair{Tags: [2]string{a, b}, Count: count})
+			}
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].Count != pairs[j].Count {
+			return pairs[i].Count > pairs[j].Count
+		}
+		if pairs[i].Tags[0] != pairs[j].Tags[0] {
+			return pairs[i].Tags[0] < pairs[j].Tags[0]
+		}
+		return pairs[i].Tags[1] < pairs[j].Tags[1]
+	})
+	return pairs
+}
+
+func newStats(notes []*Note, since, now time.Time) Stats {
+	st := Stats{
+		Total:           len(notes),
+		TagCooccurrence: make(map[string]map[string]int),
+	}
+
+	tags := make(map[string]int)
+	for _, note := range notes {
+		if note.IsArchived {
+			st.Archived++
+		} else {
+			st.Active++
+			for i, tag := range note.Tags {
+				tags[tag]++
+				for _, other := range note.Tags[i+1:] {
+					if other == tag {
+						continue
+					}
+					st.addPair(tag, other)
+					st.addPair(other, tag)
+				}
+			}
+		}
+		if note.IsFavorite {
+			st.Favorites++
+		}
+
+		st.Words += len(strings.Fields(note.Content))
+		st.Characters += utf8.RuneCountInString(note.Content)
+	}
+	if st.Total > 0 {
+		st.AverageWords = float64(st.Words) / float64(st.Total)
+		st.AverageCharacters = float64(st.Characters) / float64(st.Total)
+	}
+
+	st.Tags = len(tags)
+	st.TagFrequency = make([]TagCount, 0, len(tags))
+	for tag, count := range tags {
+		st.TagFrequency = append(st.TagFrequency, TagCount{Name: tag, Count: count})
+	}
+	sort.Slice(st.TagFrequency, func(i, j int) bool {
+		a, b := st.TagFrequency[i], st.TagFrequency[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Name < b.Name
+	})
+
+	st.addActivity(notes, since, now)
+	return st
+}
+
+func (st *Stats) addPair(tag, other string) {
+	if st.TagCooccurrence[tag] == nil {
+		st.TagCooccurrence[tag] = make(map[string]int)
+	}
+	st.TagCooccurrence[tag][other]++
+}
+
+// addActivity counts the notes created and updated on every day of the
+// range, from since or the day the first note was created, until now. Notes
+// without a creation time are left out of all time, and the range never goes
+// back more than MaxRangeYears.
+func (st *Stats) addActivity(notes []*Note, since, now time.Time) {
+	today := startOfDay(now)
+	first := startOfDay(since)
+	if since.IsZero() {
+		first = today
+		for _, note := range notes {
+			if note.CreatedAt.IsZero() {
+				continue
+			}
+			if created := startOfDay(note.CreatedAt); created.Before(first) {
+				first = created
+			}
+		}
+	}
+	if oldest := oldestDay(today); first.Before(oldest) {
+		first, since = oldest, oldest
+	}
+	if !since.IsZero() {
+		st.Since = &first
+	}
+
+	created := make(map[string]int)
+	updated := make(map[string]int)
+	var edited []*Note
+	for _, note := range notes {
+		createdDay := startOfDay(note.CreatedAt)
+		updatedDay := startOfDay(note.UpdatedAt)
+		if !createdDay.Before(first) {
+			created[dayKey(createdDay)]++
+		}
+		if updatedDay.After(createdDay) && !updatedDay.Before(first) {
+			updated[dayKey(updatedDay)]++
+		}
+		if note.Edits > 0 && !updatedDay.Before(first) {
+			edited = append(edited, note)
+		}
+	}
+
+	st.CreatedPerDay = []DayCount{}
+	st.UpdatedPerDay = []DayCount{}
+	st.CreatedPerWeek = []DayCount{}
+	st.UpdatedPerWeek = []DayCount{}
+	var streak Streak
+	for day := first; !day.After(today); day = day.AddDate(0, 0, 1) {
+		key := dayKey(day)
+		st.CreatedPerDay = append(st.CreatedPerDay, DayCount{Date: key, Count: created[key]})
+		st.UpdatedPerDay = append(st.UpdatedPerDay, DayCount{Date: key, Count: updated[key]})
+
+		if day.Equal(first) || day.Weekday() == time.Monday {
+			week := dayKey(weekStart(day))
+			st.CreatedPerWeek = append(st.CreatedPerWeek, DayCount{Date: week})
+			st.UpdatedPerWeek = append(st.UpdatedPerWeek, DayCount{Date: week})
+		}
+		st.CreatedPerWeek[len(st.CreatedPerWeek)-1].Count += created[key]
+		st.UpdatedPerWeek[len(st.UpdatedPerWeek)-1].Count += updated[key]
+
+		if created[key]+updated[key] == 0 {
+			streak = Streak{}
+			continue
+		}
+		if streak.Days == 0 {
+			streak.Start = key
+		}
+		streak.Days++
+		streak.End = key
+		if streak.Days > st.LongestStreak.Days {
+			st.LongestStreak = streak
+		}
+	}
+
+	// A streak is still current if it ended yesterday, since there may be
+	// activity later today
+	if streak.End == dayKey(today) || streak.End == dayKey(today.AddDate(0, 0, -1)) {
+		st.CurrentStreak = streak
+	}
+
+	sort.Slice(edited, func(i, j int) bool {
+		if edited[i].Edits != edited[j].Edits {
+			return edited[i].Edits > edited[j].Edits
+		}
+		return edited[i].ID < edited[j].ID
+	})
+	if len(edited) > maxMostEdited {
+		edited = edited[:maxMostEdited]
+	}
+	st.MostEdited = make([]EditCount, len(edited))
+	for i, note := range edited {
+		st.MostEdited[i] = EditCount{ID: note.ID, Title: note.Title, Edits: note.Edits}
+	}
+}
+
+// startOfDay returns midnight of the local day of t
+func startOfDay(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// weekStart returns the Monday of the week of day
+func weekStart(day time.Time) time.Time {
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+func dayKey(day time.Time) string {
+	return day.Format(DateLayout)
+}
@@ -0,0 +1,86 @@
+package note
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRange(t *testing.T) {
+	now := time.Date(2024, time.May, 15, 18, 30, 0, 0, time.Local)
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "", want: time.Time{}},
+		{value: "all", want: time.Time{}},
+		{value: " ALL ", want: time.Time{}},
+		{value: "1d", want: day(2024, time.May, 15)},
+		{value: "30d", want: day(2024, time.April, 16)},
+		{value: "2w", want: day(2024, time.May, 2)},
+		{value: "6m", want: day(2023, time.November, 16)},
+		{value: "1y", want: day(2023, time.May, 16)},
+		{value: "10y", want: day(2014, time.May, 16)},
+		{value: "120m", want: day(2014, time.May, 16)},
+		{value: "2024-01-31", want: day(2024, time.January, 31)},
+		{value: "2014-05-16", want: day(2014, time.May, 16)},
+		{value: "11y", wantErr: true},
+		{value: "121m", wantErr: true},
+		{value: "99999999y", wantErr: true},
+		{value: "9999999999999999999999d", wantErr: true},
+		{value: "2014-05-15", wantErr: true},
+		{value: "0d", wantErr: true},
+		{value: "-3d", wantErr: true},
+		{value: "3x", wantErr: true},
+		{value: "d", wantErr: true},
+		{value: "week", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseRange(tt.value, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseRange(%q) = %v, want an error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRange(%q) error = %v", tt.value, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseRange(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatsAllTimeActivity(t *testing.T) {
+	now := time.Date(2024, time.May, 15, 12, 0, 0, 0, time.Local)
+	notes := []*Note{
+		{ID: 1, Title: "Undated"},
+		{ID: 2, Title: "Old", CreatedAt: now.AddDate(0, 0, -2), UpdatedAt: now.AddDate(0, 0, -2)},
+		{ID: 3, Title: "New", CreatedAt: now, UpdatedAt: now},
+	}
+
+	st := newStats(notes, time.Time{}, now)
+	if len(st.CreatedPerDay) != 3 {
+		t.Fatalf("got %d days of activity, want 3 from the first dated note", len(st.CreatedPerDay))
+	}
+	if st.Since != nil {
+		t.Errorf("Since = %v, want nil for all time", st.Since)
+	}
+	if got := st.CreatedPerDay[0].Date; got != "2024-05-13" {
+		t.Errorf("activity starts on %s, want 2024-05-13", got)
+	}
+
+	notes[1].CreatedAt = time.Date(1900, time.January, 1, 0, 0, 0, 0, time.Local)
+	st = newStats(notes, time.Time{}, now)
+	if st.Since == nil || dayKey(*st.Since) != "2014-05-16" {
+		t.Errorf("Since = %v, want the activity cut to %d years", st.Since, MaxRangeYears)
+	}
+}
@@ -2,20 +2,51 @@ package note
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/gocode"
+	"github.com/midimurphdesigns/go-lang-notes/internal/vault"
 )
 
+// MetaDirName is the directory inside the notes directory that holds
+// application data other than notes, such as review state or settings
+const MetaDirName = ".gonotes"
+
+// ErrNotFound is returned for notes that do not exist
+var ErrNotFound = errors.New("not found")
+
 // Storage represents the note storage system
 type Storage struct {
+	mu       sync.RWMutex
 	notesDir string
 	notes    map[int]*Note
 	nextID   int
+	settings Settings
+	// schema declares the types of note properties
+	schema Schema
+	// vault describes the encryption key, nil if encryption is not set up
+	vault *vault.File
+	// key decrypts encrypted notes; it is nil while the storage is locked
+	key *vault.Key
+	// locked holds the files of encrypted notes that cannot be read while
+	// the storage is locked, by ID
+	locked map[int][]byte
+	// hooks are called after every change, see OnChange
+	hooks []func(Change)
+	// group collects the changes reported while Group runs, and grouping
+	// is set meanwhile
+	group    []Change
+	grouping bool
 }
 
 // NewStorage creates a new storage instance
@@ -27,6 +58,7 @@ func NewStorage(notesDir string) (*Storage, error) {
 	storage := &Storage{
 		notesDir: notesDir,
 		notes:    make(map[int]*Note),
+		locked:   make(map[int][]byte),
 		nextID:   1,
 	}
 
@@ -35,6 +67,21 @@ func NewStorage(notesDir string) (*Storage, error) {
 		return nil, fmt.Errorf("failed to create notes directory: %w", err)
 	}
 
+	// Load directory settings
+	if err := storage.loadSettings(); err != nil {
+		return nil, err
+	}
+
+	// Load the property schema
+	if err := storage.loadSchema(); err != nil {
+		return nil, err
+	}
+
+	// Load the encryption key file
+	if err := storage.loadVault(); err != nil {
+		return nil, err
+	}
+
 	// Load existing notes
 	if err := storage.loadNotes(); err != nil {
 		return nil, fmt.Errorf("failed to load notes: %w", err)
@@ -43,9 +90,33 @@ func NewStorage(notesDir string) (*Storage, error) {
 	return storage, nil
 }
 
+// Dir returns the directory notes are stored in
+func (s *Storage) Dir() string {
+	return s.notesDir
+}
+
+// MetaDir returns the directory for application data inside the notes directory
+func (s *Storage) MetaDir() string {
+	return filepath.Join(s.notesDir, MetaDirName)
+}
+
 // CreateNote creates a new note and saves it
 func (s *Storage) CreateNote(title, content string, tags []string) (*Note, error) {
+	note, err := s.createNote(title, content, tags)
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(Change{Action: ActionCreate, ID: note.ID, After: note})
+	return note, nil
+}
+
+func (s *Storage) createNote(title, content string, tags []string) (*Note, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	note := NewNote(title, content, tags)
+	note.Content = s.formatContent(note.Title, note.Content)
 	note.ID = s.nextID
 	s.nextID++
 
@@ -64,32 +135,121 @@ func (s *Storage) CreateNote(title, content string, tags []string) (*Note, error
 	return note, nil
 }
 
+// ImportNote adds a note built elsewhere, such as by an importer. The note is
+// given a new ID but keeps its timestamps, flags and other fields.
+func (s *Storage) ImportNote(note *Note) (*Note, error) {
+	imported, err := s.importNote(note)
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(Change{Action: ActionImport, ID: imported.ID, After: imported})
+	return imported, nil
+}
+
+func (s *Storage) importNote(note *Note) (*Note, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	imported := note.Clone()
+	imported.ID = s.nextID
+	if imported.CreatedAt.IsZero() {
+		imported.CreatedAt = time.Now()
+	}
+	if imported.UpdatedAt.IsZero() {
+		imported.UpdatedAt = imported.CreatedAt
+	}
+
+	if err := s.validate(imported); err != nil {
+		return nil, err
+	}
+
+	if err := s.saveNote(imported); err != nil {
+		return nil, err
+	}
+
+	s.notes[imported.ID] = imported
+	s.nextID++
+	return imported, nil
+}
+
+// PutNote stores a complete note under its own ID, replacing any note with
+// that ID. Unlike UpdateNote, all fields including timestamps are kept as is;
+// properties are still checked against the schema.
+func (s *Storage) PutNote(note *Note) (*Note, error) {
+	stored, previous, err := s.putNote(note)
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(Change{Action: ActionPut, ID: stored.ID, Before: previous, After: stored})
+	return stored, nil
+}
+
+func (s *Storage) putNote(note *Note) (stored, previous *Note, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if note.ID <= 0 {
+		return nil, nil, fmt.Errorf("invalid note ID: %d", note.ID)
+	}
+	if _, locked := s.locked[note.ID]; locked {
+		return nil, nil, fmt.Errorf("note %d is encrypted: %w", note.ID, ErrLocked)
+	}
+
+	stored = note.Clone()
+	if err := s.validate(stored); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.saveNote(stored); err != nil {
+		return nil, nil, err
+	}
+
+	previous = s.notes[stored.ID]
+	s.notes[stored.ID] = stored
+	if stored.ID >= s.nextID {
+		s.nextID = stored.ID + 1
+	}
+	return stored, previous, nil
+}
+
 // GetNote retrieves a note by ID
 func (s *Storage) GetNote(id int) (*Note, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	note, exists := s.notes[id]
+	if _, locked := s.locked[id]; locked {
+		return nil, fmt.Errorf("note %d is encrypted: %w", id, ErrLocked)
+	}
 	if !exists {
-		return nil, fmt.Errorf("note with ID %d not found", id)
+		return nil, fmt.Errorf("note with ID %d %w", id, ErrNotFound)
 	}
 	return note, nil
 }
 
 // GetAllNotes returns all notes
 func (s *Storage) GetAllNotes() []*Note {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	notes := make([]*Note, 0, len(s.notes))
 	for _, note := range s.notes {
 		notes = append(notes, note)
 	}
 
-	// Sort by creation date (newest first)
-	sort.Slice(notes, func(i, j int) bool {
-		return notes[i].CreatedAt.After(notes[j].CreatedAt)
-	})
+	// Pinned notes first, see SortNotes
+	SortNotes(notes)
 
 	return notes
 }
 
 // GetActiveNotes returns only non-archived notes
 func (s *Storage) GetActiveNotes() []*Note {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	var activeNotes []*Note
 	for _, note := range s.notes {
 		if !note.IsArchived {
@@ -97,16 +257,17 @@ func (s *Storage) GetActiveNotes() []*Note {
 		}
 	}
 
-	// Sort by creation date (newest first)
-	sort.Slice(activeNotes, func(i, j int) bool {
-		return activeNotes[i].CreatedAt.After(activeNotes[j].CreatedAt)
-	})
+	// Pinned notes first, see SortNotes
+	SortNotes(activeNotes)
 
 	return activeNotes
 }
 
 // GetFavoriteNotes returns only favorite notes
 func (s *Storage) GetFavoriteNotes() []*Note {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	var favoriteNotes []*Note
 	for _, note := range s.notes {
 		if note.IsFavorite {
@@ -114,10 +275,8 @@ func (s *Storage) GetFavoriteNotes() []*Note {
 		}
 	}
 
-	// Sort by creation date (newest first)
-	sort.Slice(favoriteNotes, func(i, j int) bool {
-		return favoriteNotes[i].CreatedAt.After(favoriteNotes[j].CreatedAt)
-	})
+	// Pinned notes first, see SortNotes
+	SortNotes(favoriteNotes)
 
 	return favoriteNotes
 }
@@ -128,6 +287,9 @@ func (s *Storage) SearchNotes(query string) []*Note {
 		return s.GetActiveNotes()
 	}
 
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	var results []*Note
 	query = strings.ToLower(strings.TrimSpace(query))
 
@@ -152,6 +314,9 @@ func (s *Storage) SearchNotes(query string) []*Note {
 
 // GetNotesByTag returns notes that have a specific tag
 func (s *Storage) GetNotesByTag(tag string) []*Note {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	var taggedNotes []*Note
 	tag = strings.ToLower(strings.TrimSpace(tag))
 
@@ -165,16 +330,22 @@ func (s *Storage) GetNotesByTag(tag string) []*Note {
 		}
 	}
 
-	// Sort by creation date (newest first)
-	sort.Slice(taggedNotes, func(i, j int) bool {
-		return taggedNotes[i].CreatedAt.After(taggedNotes[j].CreatedAt)
-	})
+	// Pinned notes first, see SortNotes
+	SortNotes(taggedNotes)
 
 	return taggedNotes
 }
 
 // GetAllTags returns all unique tags used across notes
 func (s *Storage) GetAllTags() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.allTags()
+}
+
+// allTags collects the unique tags of all active notes; callers must hold s.mu
+func (s *Storage) allTags() []string {
 	tagSet := make(map[string]bool)
 
 	for _, note := range s.notes {
@@ -198,117 +369,247 @@ func (s *Storage) GetAllTags() []string {
 
 // UpdateNote updates an existing note
 func (s *Storage) UpdateNote(id int, title, content string, tags []string) (*Note, error) {
-	note, exists := s.notes[id]
-	if !exists {
-		return nil, fmt.Errorf("note with ID %d not found", id)
-	}
-
-	note.UpdateTitle(title)
-	note.UpdateContent(content)
-	note.Tags = tags
-	note.UpdatedAt = time.Now()
-
-	if err := note.Validate(); err != nil {
-		return nil, err
-	}
-
-	if err := s.saveNote(note); err != nil {
-		return nil, err
-	}
-
-	return note, nil
+	return s.modify(id, Change{Action: ActionUpdate}, func(note *Note) error {
+		note.UpdateTitle(title)
+		note.UpdateContent(s.formatContent(title, content))
+		note.Tags = tags
+		note.UpdatedAt = time.Now()
+		return s.validate(note)
+	})
 }
 
 // DeleteNote removes a note
 func (s *Storage) DeleteNote(id int) error {
-	_, exists := s.notes[id]
+	deleted, err := s.deleteNote(id)
+	if deleted != nil {
+		s.notify(Change{Action: ActionDelete, ID: id, Before: deleted})
+	}
+	return err
+}
+
+func (s *Storage) deleteNote(id int) (*Note, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	note, exists := s.notes[id]
+	if _, locked := s.locked[id]; locked {
+		// Encrypted notes can be deleted without unlocking them
+		note, exists = lockedNote(id), true
+	}
 	if !exists {
-		return fmt.Errorf("note with ID %d not found", id)
+		return nil, fmt.Errorf("note with ID %d %w", id, ErrNotFound)
 	}
 
 	// Remove from disk
-	filename := filepath.Join(s.notesDir, fmt.Sprintf("%d.json", id))
+	filename := s.noteFile(id)
 
 	// Remove from memory
 	delete(s.notes, id)
+	delete(s.locked, id)
+
+	if err := os.Remove(filename); err != nil {
+		return note, err
+	}
 
-	return os.Remove(filename)
+	return note, s.removeAttachments(id)
 }
 
-// ToggleFavorite toggles the favorite status of a note
-func (s *Storage) ToggleFavorite(id int) (*Note, error) {
-	note, exists := s.notes[id]
-	if !exists {
-		return nil, fmt.Errorf("note with ID %d not found", id)
-	}
+// FavoriteNote marks a note as favorite or not. Marking a note that already
+// is, or is not, a favorite leaves it unchanged.
+func (s *Storage) FavoriteNote(id int, favorite bool) (*Note, error) {
+	return s.modify(id, Change{Action: ActionFavorite}, func(note *Note) error {
+		note.SetFavorite(favorite)
+		return nil
+	})
+}
 
-	note.ToggleFavorite()
+// ArchiveNote archives or unarchives a note. Archiving an archived note, or
+// unarchiving an active one, leaves it unchanged.
+func (s *Storage) ArchiveNote(id int, archived bool) (*Note, error) {
+	return s.modify(id, Change{Action: ActionArchive}, func(note *Note) error {
+		note.SetArchived(archived)
+		return nil
+	})
+}
 
-	if err := s.saveNote(note); err != nil {
+// AddTag adds a tag to a note
+func (s *Storage) AddTag(id int, tag string) (*Note, error) {
+	return s.modify(id, Change{Action: ActionTag, Detail: tag}, func(note *Note) error {
+		note.AddTag(tag)
+		note.UpdatedAt = time.Now()
+		return nil
+	})
+}
+
+// RemoveTag removes a tag from a note
+func (s *Storage) RemoveTag(id int, tag string) (*Note, error) {
+	return s.modify(id, Change{Action: ActionUntag, Detail: tag}, func(note *Note) error {
+		note.RemoveTag(tag)
+		note.UpdatedAt = time.Now()
+		return nil
+	})
+}
+
+// AddLink links a note to another note
+func (s *Storage) AddLink(id, target int) (*Note, error) {
+	if _, err := s.GetNote(target); err != nil {
 		return nil, err
 	}
 
-	return note, nil
+	return s.modify(id, Change{Action: ActionLink, Detail: strconv.Itoa(target)}, func(note *Note) error {
+		note.AddLink(target)
+		return nil
+	})
 }
 
-// ArchiveNote archives or unarchives a note
-func (s *Storage) ArchiveNote(id int) (*Note, error) {
-	note, exists := s.notes[id]
-	if !exists {
-		return nil, fmt.Errorf("note with ID %d not found", id)
+// RemoveLink removes the link from a note to another note
+func (s *Storage) RemoveLink(id, target int) (*Note, error) {
+	return s.modify(id, Change{Action: ActionUnlink, Detail: strconv.Itoa(target)}, func(note *Note) error {
+		note.RemoveLink(target)
+		return nil
+	})
+}
+
+// GetBacklinks returns the notes that link to the given note
+func (s *Storage) GetBacklinks(id int) []*Note {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var backlinks []*Note
+	for _, note := range s.notes {
+		if note.HasLink(id) {
+			backlinks = append(backlinks, note)
+		}
 	}
 
-	note.Archive()
+	// Sort by creation date (newest first)
+	sort.Slice(backlinks, func(i, j int) bool {
+		return backlinks[i].CreatedAt.After(backlinks[j].CreatedAt)
+	})
 
-	if err := s.saveNote(note); err != nil {
+	return backlinks
+}
+
+// modify applies fn to a copy of the note with the given ID and persists the
+// result, then reports change with the note before and after. The stored note
+// is left untouched if fn or the save fails.
+func (s *Storage) modify(id int, change Change, fn func(note *Note) error) (*Note, error) {
+	note, previous, err := s.apply(id, fn)
+	if err != nil {
 		return nil, err
 	}
 
+	change.ID = id
+	change.Before = previous
+	change.After = note
+	s.notify(change)
 	return note, nil
 }
 
-// AddTag adds a tag to a note
-func (s *Storage) AddTag(id int, tag string) (*Note, error) {
-	note, exists := s.notes[id]
+func (s *Storage) apply(id int, fn func(note *Note) error) (note, previous *Note, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, exists := s.notes[id]
+	if _, locked := s.locked[id]; locked {
+		return nil, nil, fmt.Errorf("note %d is encrypted: %w", id, ErrLocked)
+	}
 	if !exists {
-		return nil, fmt.Errorf("note with ID %d not found", id)
+		return nil, nil, fmt.Errorf("note with ID %d %w", id, ErrNotFound)
 	}
 
-	note.AddTag(tag)
-	note.UpdatedAt = time.Now()
+	note = previous.Clone()
+	if err := fn(note); err != nil {
+		return nil, nil, err
+	}
+	if !sameNote(note, previous) {
+		note.Edits++
+	}
 
 	if err := s.saveNote(note); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return note, nil
+	s.notes[id] = note
+	return note, previous, nil
 }
 
-// RemoveTag removes a tag from a note
-func (s *Storage) RemoveTag(id int, tag string) (*Note, error) {
-	note, exists := s.notes[id]
-	if !exists {
-		return nil, fmt.Errorf("note with ID %d not found", id)
+// Stamp identifies the state of the note files by their names, sizes and
+// modification times, so changes made by other processes are noticed
+// without reading every note
+func (s *Storage) Stamp() string {
+	entries, err := os.ReadDir(s.notesDir)
+	if err != nil {
+		return ""
 	}
 
-	note.RemoveTag(tag)
-	note.UpdatedAt = time.Now()
+	hash := fnv.New64a()
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || entry.IsDir() {
+			continue
+		}
+		fmt.Fprintf(hash, "%s %d %d\n", entry.Name(), info.Size(), info.ModTime().UnixNano())
+	}
+	return fmt.Sprintf("%x", hash.Sum64())
+}
 
-	if err := s.saveNote(note); err != nil {
-		return nil, err
+// Reload discards the in-memory notes and reads them from disk again, picking
+// up changes made by other processes sharing the notes directory
+func (s *Storage) Reload() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.reload()
+}
+
+func (s *Storage) reload() error {
+	s.notes = make(map[int]*Note)
+	s.locked = make(map[int][]byte)
+	s.nextID = 1
+	s.settings = Settings{}
+
+	if err := s.loadSettings(); err != nil {
+		return err
+	}
+	if err := s.loadSchema(); err != nil {
+		return err
+	}
+	if err := s.loadVault(); err != nil {
+		return err
 	}
+	return s.loadNotes()
+}
 
-	return note, nil
+// formatContent applies the content formatting enabled in the settings.
+// Problems are reported as warnings and never prevent a note from being saved.
+func (s *Storage) formatContent(title, content string) string {
+	if !s.settings.FormatGoBlocks {
+		return content
+	}
+
+	formatted, problems := gocode.FormatContent(content)
+	for _, problem := range problems {
+		fmt.Fprintf(os.Stderr, "Warning: Go block %d of note '%s' left unformatted: line %d: %s\n",
+			problem.Block, title, problem.Line, problem.Message)
+	}
+
+	return formatted
 }
 
-// saveNote saves a single note to disk
+// saveNote saves a single note to disk, encrypted if the note or the
+// settings ask for it
 func (s *Storage) saveNote(note *Note) error {
-	data, err := json.MarshalIndent(note, "", "  ")
+	if s.encrypts(note) {
+		note.Encrypted = true
+	}
+
+	data, err := s.encodeNote(note)
 	if err != nil {
 		return fmt.Errorf("failed to marshal note: %w", err)
 	}
 
-	filename := filepath.Join(s.notesDir, fmt.Sprintf("%d.json", note.ID))
+	filename := s.noteFile(note.ID)
 	if err := os.WriteFile(filename, data, 0644); err != nil {
 		return fmt.Errorf("failed to write note file: %w", err)
 	}
@@ -345,13 +646,19 @@ func (s *Storage) loadNoteFromFile(entry fs.DirEntry) error {
 		return fmt.Errorf("failed to read file: %w", err)
 	}
 
-	var note Note
-	if err := json.Unmarshal(data, &note); err != nil {
-		return fmt.Errorf("failed to unmarshal note: %w", err)
+	note, err := s.decodeNote(data)
+	if errors.Is(err, ErrLocked) || errors.Is(err, ErrNoEncryption) {
+		// Kept aside until the storage is unlocked
+		var file encryptedFile
+		json.Unmarshal(data, &file)
+		note = lockedNote(file.ID)
+		s.locked[note.ID] = data
+	} else if err != nil {
+		return err
+	} else {
+		s.notes[note.ID] = note
 	}
 
-	s.notes[note.ID] = &note
-
 	// Update nextID if this note has a higher ID
 	if note.ID >= s.nextID {
 		s.nextID = note.ID + 1
@@ -360,27 +667,7 @@ func (s *Storage) loadNoteFromFile(entry fs.DirEntry) error {
 	return nil
 }
 
-// GetStats returns statistics about the notes
-func (s *Storage) GetStats() map[string]int {
-	stats := map[string]int{
-		"total":     len(s.notes),
-		"active":    0,
-		"archived":  0,
-		"favorites": 0,
-		"tags":      len(s.GetAllTags()),
-	}
-
-	for _, note := range s.notes {
-		if note.IsArchived {
-			stats["archived"]++
-		} else {
-			stats["active"]++
-		}
-
-		if note.IsFavorite {
-			stats["favorites"]++
-		}
-	}
-
-	return stats
+// noteFile returns the path of the file of a note
+func (s *Storage) noteFile(id int) string {
+	return filepath.Join(s.notesDir, fmt.Sprintf("%d.json", id))
 }
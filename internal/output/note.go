@@ -0,0 +1,129 @@
+// Package output renders command results as text for people or as JSON,
+// JSON Lines, YAML, tables, CSV or Go templates for scripts. Notes use the
+// same schema in the CLI and the HTTP API.
+package output
+
+import (
+	"time"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/journal"
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+)
+
+// NoteResponse is a note as returned by the HTTP API and printed by the CLI
+type NoteResponse struct {
+	ID          int                    `json:"id"`
+	Title       string                 `json:"title"`
+	Content     string                 `json:"content"`
+	Tags        []string               `json:"tags"`
+	Notebook    string                 `json:"notebook,omitempty"`
+	CreatedAt   time.Time              `json:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at"`
+	RemindAt    *time.Time             `json:"remind_at,omitempty"`
+	DueAt       *time.Time             `json:"due_at,omitempty"`
+	Links       []int                  `json:"links,omitempty"`
+	Attachments []string               `json:"attachments,omitempty"`
+	IsArchived  bool                   `json:"is_archived"`
+	IsFavorite  bool                   `json:"is_favorite"`
+	IsPinned    bool                   `json:"is_pinned"`
+	Position    int                    `json:"position,omitempty"`
+	Encrypted   bool                   `json:"encrypted,omitempty"`
+	Properties  map[string]interface{} `json:"properties,omitempty"`
+	Edits       int                    `json:"edits,omitempty"`
+}
+
+// NewNoteResponse converts a note
+func NewNoteResponse(n *note.Note) NoteResponse {
+	tags := n.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	return NoteResponse{
+		ID:          n.ID,
+		Title:       n.Title,
+		Content:     n.Content,
+		Tags:        tags,
+		Notebook:    n.Notebook,
+		CreatedAt:   n.CreatedAt,
+		UpdatedAt:   n.UpdatedAt,
+		RemindAt:    n.RemindAt,
+		DueAt:       n.DueAt,
+		Links:       n.Links,
+		Attachments: n.Attachments,
+		IsArchived:  n.IsArchived,
+		IsFavorite:  n.IsFavorite,
+		IsPinned:    n.IsPinned,
+		Position:    n.Position,
+		Encrypted:   n.Encrypted,
+		Properties:  n.Properties,
+		Edits:       n.Edits,
+	}
+}
+
+// NewNoteResponses converts a list of notes; an empty list stays a list
+func NewNoteResponses(notes []*note.Note) []NoteResponse {
+	response := make([]NoteResponse, len(notes))
+	for i, n := range notes {
+		response[i] = NewNoteResponse(n)
+	}
+	return response
+}
+
+// TagCount is a tag with the number of notes having it
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// BatchResult is the outcome of a bulk operation for one note
+type BatchResult struct {
+	ID      int    `json:"id"`
+	Title   string `json:"title,omitempty"`
+	Changed bool   `json:"changed"`
+	Error   string `json:"error,omitempty"`
+	// Note is the note after the operation, nil if it was deleted or failed
+	Note *NoteResponse `json:"note,omitempty"`
+}
+
+// NewBatchResults converts the results of a bulk operation
+func NewBatchResults(results []note.BatchResult) []BatchResult {
+	response := make([]BatchResult, len(results))
+	for i, result := range results {
+		response[i] = BatchResult{ID: result.ID, Changed: result.Changed}
+		if result.Before != nil {
+			response[i].Title = result.Before.Title
+		}
+		if result.Err != nil {
+			response[i].Error = result.Err.Error()
+		} else if result.After != nil {
+			n := NewNoteResponse(result.After)
+			response[i].Note = &n
+		}
+	}
+	return response
+}
+
+// Operation is an operation in the undo journal
+type Operation struct {
+	ID      int       `json:"id"`
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+	Notes   []int     `json:"notes"`
+	Undone  bool      `json:"undone"`
+}
+
+// NewOperations converts journal entries
+func NewOperations(entries []journal.Entry) []Operation {
+	response := make([]Operation, len(entries))
+	for i, entry := range entries {
+		response[i] = Operation{
+			ID:      entry.ID,
+			Time:    entry.Time,
+			Message: entry.Message,
+			Notes:   entry.NoteIDs(),
+			Undone:  entry.Undone,
+		}
+	}
+	return response
+}
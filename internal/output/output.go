@@ -0,0 +1,296 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Supported output formats
+const (
+	FormatText  = "text"
+	FormatJSON  = "json"
+	FormatJSONL = "jsonl"
+	FormatYAML  = "yaml"
+	FormatTable = "table"
+	FormatCSV   = "csv"
+)
+
+// tableCellWidth is the number of characters table cells are cut to
+const tableCellWidth = 40
+
+// Options select how results are written
+type Options struct {
+	// Format is one of the Format constants
+	Format string
+	// Template is a Go template executed for every item of a list, or once
+	// for a single result, such as "{{.ID}} {{.Title}}"
+	Template string
+}
+
+// Check returns an error for unsupported options
+func (o Options) Check() error {
+	switch o.Format {
+	case FormatText, FormatJSON, FormatJSONL, FormatYAML, FormatTable, FormatCSV:
+	default:
+		return fmt.Errorf("unsupported output %q (use text, json, jsonl, yaml, table or csv)", o.Format)
+	}
+	if o.Template != "" && o.Format != FormatText {
+		return fmt.Errorf("--format cannot be combined with --output %s", o.Format)
+	}
+	if o.Template != "" {
+		if _, err := parseTemplate(o.Template); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Structured reports whether results are written by Write instead of the
+// text meant for people
+func (o Options) Structured() bool {
+	return o.Format != FormatText || o.Template != ""
+}
+
+// Write writes a result, such as a NoteResponse, a list of them or a map of
+// counts
+func Write(w io.Writer, opts Options, value interface{}) error {
+	if opts.Template != "" {
+		return writeTemplate(w, opts.Template, value)
+	}
+
+	switch opts.Format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	case FormatJSONL:
+		encoder := json.NewEncoder(w)
+		for _, item := range items(value) {
+			if err := encoder.Encode(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case FormatYAML:
+		return writeYAML(w, value)
+	case FormatTable:
+		header, rows := table(value)
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.ToUpper(strings.Join(header, "\t")))
+		for _, row := range rows {
+			for i, cell := range row {
+				row[i] = shorten(cell)
+			}
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	case FormatCSV:
+		header, rows := table(value)
+		cw := csv.NewWriter(w)
+		cw.Write(header)
+		cw.WriteAll(rows)
+		return cw.Error()
+	}
+	return fmt.Errorf("unsupported output %q", opts.Format)
+}
+
+// items returns the elements of a list, or the value alone
+func items(value interface{}) []interface{} {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice {
+		return []interface{}{value}
+	}
+
+	list := make([]interface{}, v.Len())
+	for i := range list {
+		list[i] = v.Index(i).Interface()
+	}
+	return list
+}
+
+func parseTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("format").Funcs(template.FuncMap{
+		"join": strings.Join,
+		"json": func(v interface{}) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+		"date": func(t time.Time) string {
+			return t.Format("2006-01-02 15:04")
+		},
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --format template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// writeTemplate executes the template for every item, each on its own line
+func writeTemplate(w io.Writer, text string, value interface{}) error {
+	tmpl, err := parseTemplate(text)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items(value) {
+		if err := tmpl.Execute(w, item); err != nil {
+			return fmt.Errorf("failed to execute --format template: %w", err)
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+// writeYAML writes the value with the keys and key order of its JSON form
+func writeYAML(w io.Writer, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	// JSON is YAML, so decoding it as a node keeps the key order
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	blockStyle(&node)
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// blockStyle drops the JSON flow style and quoting of decoded nodes
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+// table returns the header and rows of a result: a row per item of a list
+// with a column per JSON field, or a single row for a single result
+func table(value interface{}) (header []string, rows [][]string) {
+	list := items(value)
+	if len(list) == 0 {
+		t := reflect.TypeOf(value)
+		if t != nil && t.Kind() == reflect.Slice {
+			return columns(t.Elem()), nil
+		}
+		return nil, nil
+	}
+
+	first := reflect.ValueOf(list[0])
+	if first.Kind() == reflect.Map {
+		// Maps such as counts by name have a column per sorted key
+		for _, key := range first.MapKeys() {
+			header = append(header, fmt.Sprint(key.Interface()))
+		}
+		sort.Strings(header)
+		for _, item := range list {
+			v := reflect.ValueOf(item)
+			row := make([]string, len(header))
+			for i, key := range header {
+				row[i] = cell(v.MapIndex(reflect.ValueOf(key)))
+			}
+			rows = append(rows, row)
+		}
+		return header, rows
+	}
+
+	header = columns(first.Type())
+	for _, item := range list {
+		v := reflect.ValueOf(item)
+		row := make([]string, 0, len(header))
+		for i := 0; i < v.NumField(); i++ {
+			if _, ok := columnName(v.Type().Field(i)); ok {
+				row = append(row, cell(v.Field(i)))
+			}
+		}
+		rows = append(rows, row)
+	}
+	return header, rows
+}
+
+// columns returns the JSON names of the fields of a struct
+func columns(t reflect.Type) []string {
+	if t.Kind() != reflect.Struct {
+		return []string{"value"}
+	}
+
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		if name, ok := columnName(t.Field(i)); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func columnName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return "", false
+	}
+	if name == "" {
+		name = field.Name
+	}
+	return name, true
+}
+
+// cell formats a value for a table or CSV cell: lists are comma separated,
+// times use RFC 3339 and maps are JSON
+func cell(v reflect.Value) string {
+	if !v.IsValid() {
+		return ""
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339)
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return ""
+		}
+		return cell(v.Elem())
+	case reflect.Slice:
+		parts := make([]string, v.Len())
+		for i := range parts {
+			parts[i] = cell(v.Index(i))
+		}
+		return strings.Join(parts, ",")
+	case reflect.Map:
+		if v.Len() == 0 {
+			return ""
+		}
+		data, _ := json.Marshal(v.Interface())
+		return string(data)
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// shorten fits a cell on one line of a table
+func shorten(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > tableCellWidth {
+		return string(runes[:tableCellWidth-1]) + "…"
+	}
+	return text
+}
@@ -0,0 +1,299 @@
+package peer
+
+import (
+	"strings"
+	"time"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+)
+
+// Fields of a note that can conflict. Other fields always merge: tags, links
+// and attachments as sets, flags because they only have two values and
+// reminders by keeping the earlier time.
+const (
+	FieldTitle    = "title"
+	FieldContent  = "content"
+	FieldNotebook = "notebook"
+	// FieldProperties conflicts when a property was set differently on both
+	// sides; properties changed on one side merge one by one
+	FieldProperties = "properties"
+)
+
+// Merge merges the local and remote versions of a note against base, their
+// state at the last sync, which is nil if the note was never synced. The
+// merged note has the local ID. For fields changed differently on both sides
+// it keeps the local value and lists the field as a conflict.
+func Merge(base, local, remote *note.Note) (*note.Note, []string) {
+	if base == nil {
+		base = &note.Note{}
+	}
+
+	merged := local.Clone()
+	var conflicts []string
+
+	var ok bool
+	if merged.Title, ok = mergeValue(base.Title, local.Title, remote.Title); !ok {
+		conflicts = append(conflicts, FieldTitle)
+	}
+	if merged.Content, ok = mergeText(base.Content, local.Content, remote.Content); !ok {
+		conflicts = append(conflicts, FieldContent)
+	}
+	if merged.Notebook, ok = mergeValue(base.Notebook, local.Notebook, remote.Notebook); !ok {
+		conflicts = append(conflicts, FieldNotebook)
+	}
+
+	if merged.Properties, ok = mergeProperties(base.Properties, local.Properties, remote.Properties); !ok {
+		conflicts = append(conflicts, FieldProperties)
+	}
+
+	merged.Tags = mergeSet(base.Tags, local.Tags, remote.Tags)
+	merged.Links = mergeSet(base.Links, local.Links, remote.Links)
+	merged.Attachments = mergeSet(base.Attachments, local.Attachments, remote.Attachments)
+
+	merged.IsArchived, _ = mergeValue(base.IsArchived, local.IsArchived, remote.IsArchived)
+	merged.IsFavorite, _ = mergeValue(base.IsFavorite, local.IsFavorite, remote.IsFavorite)
+	merged.IsPinned, _ = mergeValue(base.IsPinned, local.IsPinned, remote.IsPinned)
+	merged.Position, _ = mergeValue(base.Position, local.Position, remote.Position)
+	merged.Encrypted, _ = mergeValue(base.Encrypted, local.Encrypted, remote.Encrypted)
+	merged.RemindAt = mergeTime(base.RemindAt, local.RemindAt, remote.RemindAt)
+	merged.DueAt = mergeTime(base.DueAt, local.DueAt, remote.DueAt)
+
+	if remote.CreatedAt.Before(merged.CreatedAt) && !remote.CreatedAt.IsZero() {
+		merged.CreatedAt = remote.CreatedAt
+	}
+	if remote.UpdatedAt.After(merged.UpdatedAt) {
+		merged.UpdatedAt = remote.UpdatedAt
+	}
+
+	return merged, conflicts
+}
+
+// mergeValue merges a single value: a change on one side wins, the same
+// change on both sides is fine and different changes conflict, keeping the
+// local value
+func mergeValue[T comparable](base, local, remote T) (T, bool) {
+	switch {
+	case local == remote, remote == base:
+		return local, true
+	case local == base:
+		return remote, true
+	}
+	return local, false
+}
+
+// mergeProperties merges properties one by one with mergeValue; a missing
+// property is nil, so removing it is a change like any other
+func mergeProperties(base, local, remote map[string]interface{}) (map[string]interface{}, bool) {
+	names := make(map[string]bool)
+	for _, properties := range []map[string]interface{}{base, local, remote} {
+		for name := range properties {
+			names[name] = true
+		}
+	}
+
+	merged := make(map[string]interface{})
+	clean := true
+	for name := range names {
+		value, ok := mergeValue(base[name], local[name], remote[name])
+		clean = clean && ok
+		if value != nil {
+			merged[name] = value
+		}
+	}
+
+	if len(merged) == 0 {
+		return nil, clean
+	}
+	return merged, clean
+}
+
+// mergeTime merges an optional time. Times set differently on both sides
+// resolve to the earlier one, so a reminder is never missed.
+func mergeTime(base, local, remote *time.Time) *time.Time {
+	equal := func(a, b *time.Time) bool {
+		return a == nil && b == nil || a != nil && b != nil && a.Equal(*b)
+	}
+
+	switch {
+	case equal(local, remote), equal(remote, base):
+		return local
+	case equal(local, base):
+		return remote
+	case local == nil:
+		return remote
+	case remote == nil || local.Before(*remote):
+		return local
+	}
+	return remote
+}
+
+// mergeSet merges sets kept as slices: an element is kept if both sides
+// have it or one side added it, so additions and removals on either side
+// are applied. The local order is kept, with remote additions appended.
+func mergeSet[T comparable](base, local, remote []T) []T {
+	inBase := toSet(base)
+	inLocal := toSet(local)
+	inRemote := toSet(remote)
+
+	var merged []T
+	for _, item := range local {
+		if inRemote[item] || !inBase[item] {
+			merged = append(merged, item)
+		}
+	}
+	for _, item := range remote {
+		if !inLocal[item] && !inBase[item] {
+			merged = append(merged, item)
+		}
+	}
+	return merged
+}
+
+func toSet[T comparable](items []T) map[T]bool {
+	set := make(map[T]bool, len(items))
+	for _, item := range items {
+		set[item] = true
+	}
+	return set
+}
+
+// mergeText merges text line by line. Changes to different lines of the base
+// are combined; overlapping changes that differ are a conflict, in which
+// case the local text is returned.
+func mergeText(base, local, remote string) (string, bool) {
+	if local == remote || remote == base {
+		return local, true
+	}
+	if local == base {
+		return remote, true
+	}
+
+	merged, ok := merge3(splitLines(base), splitLines(local), splitLines(remote))
+	if !ok {
+		return local, false
+	}
+	return strings.Join(merged, "\n"), true
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// merge3 performs a three-way merge of lines (diff3). Both sides are matched
+// against the base; between lines that are unchanged on both sides, a chunk
+// changed on only one side takes that side and a chunk changed identically
+// on both is taken once. Any other chunk is a conflict.
+func merge3(base, local, remote []string) ([]string, bool) {
+	matchLocal := matchLines(base, local)
+	matchRemote := matchLines(base, remote)
+
+	var merged []string
+	i, l, r := 0, 0, 0
+	for {
+		// Copy lines unchanged on both sides
+		for i < len(base) && matchLocal[i] == l && matchRemote[i] == r {
+			merged = append(merged, base[i])
+			i, l, r = i+1, l+1, r+1
+		}
+		if i == len(base) && l == len(local) && r == len(remote) {
+			return merged, true
+		}
+
+		// Find the next base line kept by both sides
+		next, nextLocal, nextRemote := len(base), len(local), len(remote)
+		for k := i; k < len(base); k++ {
+			if matchLocal[k] >= 0 && matchRemote[k] >= 0 {
+				next, nextLocal, nextRemote = k, matchLocal[k], matchRemote[k]
+				break
+			}
+		}
+
+		baseChunk := base[i:next]
+		localChunk := local[l:nextLocal]
+		remoteChunk := remote[r:nextRemote]
+		switch {
+		case equalLines(localChunk, baseChunk):
+			merged = append(merged, remoteChunk...)
+		case equalLines(remoteChunk, baseChunk), equalLines(localChunk, remoteChunk):
+			merged = append(merged, localChunk...)
+		default:
+			return nil, false
+		}
+		i, l, r = next, nextLocal, nextRemote
+	}
+}
+
+// matchLines returns for every line of base the index of the matching line
+// in other, or -1, using a longest common subsequence
+func matchLines(base, other []string) []int {
+	match := make([]int, len(base))
+	for i := range match {
+		match[i] = -1
+	}
+
+	// Common prefix and suffix are matched directly, which keeps the table
+	// small for the usual case of a few changed lines
+	start := 0
+	for start < len(base) && start < len(other) && base[start] == other[start] {
+		match[start] = start
+		start++
+	}
+	endBase, endOther := len(base), len(other)
+	for endBase > start && endOther > start && base[endBase-1] == other[endOther-1] {
+		endBase--
+		endOther--
+		match[endBase] = endOther
+	}
+
+	a, b := base[start:endBase], other[start:endOther]
+	if len(a) == 0 || len(b) == 0 {
+		return match
+	}
+
+	// lengths[i][j] is the LCS length of a[i:] and b[j:]
+	lengths := make([][]int32, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int32, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			case lengths[i+1][j] >= lengths[i][j+1]:
+				lengths[i][j] = lengths[i+1][j]
+			default:
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			match[start+i] = start + j
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return match
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,259 @@
+package peer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+)
+
+func TestMergeText(t *testing.T) {
+	lines := func(l ...string) string { return strings.Join(l, "\n") }
+
+	tests := []struct {
+		name   string
+		base   string
+		local  string
+		remote string
+		want   string
+		ok     bool
+	}{
+		{name: "unchanged", base: "a", local: "a", remote: "a", want: "a", ok: true},
+		{name: "local only", base: "a", local: "b", remote: "a", want: "b", ok: true},
+		{name: "remote only", base: "a", local: "a", remote: "b", want: "b", ok: true},
+		{name: "same change", base: "a", local: "b", remote: "b", want: "b", ok: true},
+		{
+			name:   "different lines",
+			base:   lines("1", "2", "3", "4", "5"),
+			local:  lines("1", "two", "3", "4", "5"),
+			remote: lines("1", "2", "3", "4", "five"),
+			want:   lines("1", "two", "3", "4", "five"),
+			ok:     true,
+		},
+		{
+			name:   "insertions on both sides",
+			base:   lines("1", "2", "3"),
+			local:  lines("0", "1", "2", "3"),
+			remote: lines("1", "2", "3", "4"),
+			want:   lines("0", "1", "2", "3", "4"),
+			ok:     true,
+		},
+		{
+			name:   "deletion and edit",
+			base:   lines("1", "2", "3", "4"),
+			local:  lines("1", "3", "4"),
+			remote: lines("1", "2", "3", "four"),
+			want:   lines("1", "3", "four"),
+			ok:     true,
+		},
+		{
+			name:   "same insertion on both sides",
+			base:   lines("1", "3"),
+			local:  lines("1", "2", "3"),
+			remote: lines("1", "2", "3", "4"),
+			want:   lines("1", "2", "3", "4"),
+			ok:     true,
+		},
+		{
+			name:   "same line changed differently",
+			base:   lines("1", "2", "3"),
+			local:  lines("1", "local", "3"),
+			remote: lines("1", "remote", "3"),
+			want:   lines("1", "local", "3"),
+			ok:     false,
+		},
+		{
+			name:   "adjacent lines",
+			base:   lines("1", "2", "3", "4"),
+			local:  lines("1", "two", "3", "4"),
+			remote: lines("1", "2", "three", "4"),
+			want:   lines("1", "two", "3", "4"),
+			ok:     false,
+		},
+		{
+			name:   "different insertions at the same place",
+			base:   lines("1", "2"),
+			local:  lines("1", "local", "2"),
+			remote: lines("1", "remote", "2"),
+			want:   lines("1", "local", "2"),
+			ok:     false,
+		},
+		{
+			name:   "deleted on one side and edited on the other",
+			base:   lines("1", "2", "3"),
+			local:  lines("1", "3"),
+			remote: lines("1", "changed", "3"),
+			want:   lines("1", "3"),
+			ok:     false,
+		},
+		{
+			name:   "both added to empty base",
+			base:   "",
+			local:  "local",
+			remote: "remote",
+			want:   "local",
+			ok:     false,
+		},
+		{
+			name:   "repeated lines",
+			base:   lines("x", "x", "x"),
+			local:  lines("x", "x", "x", "y"),
+			remote: lines("z", "x", "x", "x"),
+			want:   lines("z", "x", "x", "x", "y"),
+			ok:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := mergeText(tt.base, tt.local, tt.remote)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("mergeText() = %q, %v, want %q, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestMergeSet(t *testing.T) {
+	tests := []struct {
+		name                string
+		base, local, remote []string
+		want                []string
+	}{
+		{name: "additions", base: []string{"a"}, local: []string{"a", "b"}, remote: []string{"a", "c"}, want: []string{"a", "b", "c"}},
+		{name: "removal on one side", base: []string{"a", "b"}, local: []string{"a"}, remote: []string{"a", "b"}, want: []string{"a"}},
+		{name: "removal on the other side", base: []string{"a", "b"}, local: []string{"b", "a"}, remote: []string{"a"}, want: []string{"a"}},
+		{name: "same addition", base: nil, local: []string{"x"}, remote: []string{"x"}, want: []string{"x"}},
+		{name: "removed everywhere", base: []string{"a"}, local: nil, remote: nil, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeSet(tt.base, tt.local, tt.remote); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeSet() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeTime(t *testing.T) {
+	early := time.Date(2024, time.May, 1, 9, 0, 0, 0, time.UTC)
+	late := early.Add(time.Hour)
+	base := early.Add(-time.Hour)
+
+	tests := []struct {
+		name                string
+		base, local, remote *time.Time
+		want                *time.Time
+	}{
+		{name: "unchanged", base: &base, local: &base, remote: &base, want: &base},
+		{name: "set remotely", base: nil, local: nil, remote: &late, want: &late},
+		{name: "cleared remotely", base: &base, local: &base, remote: nil, want: nil},
+		{name: "changed on both sides", base: &base, local: &late, remote: &early, want: &early},
+		{name: "cleared locally and changed remotely", base: &base, local: nil, remote: &late, want: &late},
+		{name: "set on both sides", base: nil, local: &early, remote: &late, want: &early},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mergeTime(tt.base, tt.local, tt.remote)
+			if (got == nil) != (tt.want == nil) || got != nil && !got.Equal(*tt.want) {
+				t.Errorf("mergeTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	created := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	base := &note.Note{
+		ID:         3,
+		Title:      "Plan",
+		Content:    "one\ntwo\nthree",
+		Tags:       []string{"work"},
+		Notebook:   "Work",
+		CreatedAt:  created,
+		UpdatedAt:  created,
+		Properties: map[string]interface{}{"status": "draft", "owner": "sam"},
+	}
+
+	local := base.Clone()
+	local.ID = 7
+	local.Content = "one\n2\nthree"
+	local.Tags = []string{"work", "urgent"}
+	local.IsPinned = true
+	local.Properties = map[string]interface{}{"status": "review", "owner": "sam"}
+	local.UpdatedAt = created.Add(time.Hour)
+
+	remote := base.Clone()
+	remote.Title = "Plan for May"
+	remote.Content = "one\ntwo\nthree\nfour"
+	remote.Tags = nil
+	remote.IsFavorite = true
+	remote.Properties = map[string]interface{}{"status": "draft", "priority": 1.0}
+	remote.CreatedAt = created.Add(-time.Hour)
+	remote.UpdatedAt = created.Add(2 * time.Hour)
+
+	merged, conflicts := Merge(base, local, remote)
+	if len(conflicts) != 0 {
+		t.Errorf("Merge() conflicts = %v, want none", conflicts)
+	}
+
+	want := local.Clone()
+	want.Title = "Plan for May"
+	want.Content = "one\n2\nthree\nfour"
+	want.Tags = []string{"urgent"}
+	want.IsFavorite = true
+	want.Properties = map[string]interface{}{"status": "review", "priority": 1.0}
+	want.CreatedAt = remote.CreatedAt
+	want.UpdatedAt = remote.UpdatedAt
+	if !reflect.DeepEqual(merged, want) {
+		t.Errorf("Merge() =\n%+v\nwant\n%+v", merged, want)
+	}
+
+	// The inputs are left untouched
+	if local.Title != "Plan" || len(local.Tags) != 2 {
+		t.Errorf("Merge() changed the local note: %+v", local)
+	}
+}
+
+func TestMergeConflicts(t *testing.T) {
+	base := &note.Note{ID: 1, Title: "Plan", Content: "a", Notebook: "Work", Properties: map[string]interface{}{"status": "draft"}}
+
+	local := base.Clone()
+	local.Title = "Local plan"
+	local.Content = "local"
+	local.Notebook = "Home"
+	local.Properties = map[string]interface{}{"status": "done"}
+
+	remote := base.Clone()
+	remote.Title = "Remote plan"
+	remote.Content = "remote"
+	remote.Notebook = "Archive"
+	remote.Properties = map[string]interface{}{"status": "review"}
+
+	merged, conflicts := Merge(base, local, remote)
+	wantConflicts := []string{FieldTitle, FieldContent, FieldNotebook, FieldProperties}
+	if !reflect.DeepEqual(conflicts, wantConflicts) {
+		t.Errorf("Merge() conflicts = %v, want %v", conflicts, wantConflicts)
+	}
+	if merged.Title != local.Title || merged.Content != local.Content || merged.Notebook != local.Notebook ||
+		merged.Properties["status"] != "done" {
+		t.Errorf("Merge() = %+v, want the local values", merged)
+	}
+}
+
+func TestMergeWithoutBase(t *testing.T) {
+	local := &note.Note{ID: 1, Title: "Same", Content: "same", Tags: []string{"a"}}
+	remote := &note.Note{ID: 9, Title: "Same", Content: "same", Tags: []string{"b"}}
+
+	merged, conflicts := Merge(nil, local, remote)
+	if len(conflicts) != 0 {
+		t.Errorf("Merge() conflicts = %v, want none", conflicts)
+	}
+	if merged.ID != 1 || !reflect.DeepEqual(merged.Tags, []string{"a", "b"}) {
+		t.Errorf("Merge() = ID %d, tags %v, want ID 1 with tags [a b]", merged.ID, merged.Tags)
+	}
+}
@@ -0,0 +1,154 @@
+// Package peer synchronizes two notes stores in both directions, such as a
+// local notes directory and a shared one on a mounted drive or another
+// gonotes server. Notes changed on both sides are merged field by field
+// against the state of the last sync; changes that cannot be merged are kept
+// as conflict copies.
+package peer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+)
+
+// Store is one side of a sync. Notes are read all at once and written one
+// by one under the ID they carry.
+type Store interface {
+	Notes() ([]*note.Note, error)
+	Put(n *note.Note) error
+	Delete(id int) error
+}
+
+// Open opens the peer at location, which is either the URL of a running
+// gonotes server or a notes directory
+func Open(location string) (Store, error) {
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		return &httpStore{
+			base:   strings.TrimSuffix(location, "/"),
+			client: &http.Client{Timeout: 30 * time.Second},
+		}, nil
+	}
+
+	info, err := os.Stat(location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open peer: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("peer %s is not a directory", location)
+	}
+
+	storage, err := note.NewStorage(location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open peer: %w", err)
+	}
+	return NewLocal(storage), nil
+}
+
+// Location returns the canonical form of a peer location, used to tell
+// peers apart: an absolute path for directories, the URL for servers
+func Location(location string) string {
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		return strings.TrimSuffix(location, "/")
+	}
+	if abs, err := filepath.Abs(location); err == nil {
+		return abs
+	}
+	return filepath.Clean(location)
+}
+
+// localStore is a notes directory opened in this process
+type localStore struct {
+	storage *note.Storage
+}
+
+// NewLocal returns a store backed by storage
+func NewLocal(storage *note.Storage) Store {
+	return &localStore{storage: storage}
+}
+
+func (s *localStore) Notes() ([]*note.Note, error) {
+	if locked := s.storage.LockedNotes(); len(locked) > 0 {
+		return nil, fmt.Errorf("%d encrypted notes of the peer are locked, sync with it through its unlocked server instead", len(locked))
+	}
+	return s.storage.GetAllNotes(), nil
+}
+
+func (s *localStore) Put(n *note.Note) error {
+	_, err := s.storage.PutNote(n)
+	return err
+}
+
+func (s *localStore) Delete(id int) error {
+	return s.storage.DeleteNote(id)
+}
+
+// httpStore is another gonotes server, reached through its /api/peer routes
+type httpStore struct {
+	base   string
+	client *http.Client
+}
+
+func (s *httpStore) Notes() ([]*note.Note, error) {
+	var notes []*note.Note
+	if err := s.do(http.MethodGet, "/api/peer/notes", nil, &notes); err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+func (s *httpStore) Put(n *note.Note) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("failed to encode note %d: %w", n.ID, err)
+	}
+	return s.do(http.MethodPut, "/api/peer/notes/"+strconv.Itoa(n.ID), body, nil)
+}
+
+func (s *httpStore) Delete(id int) error {
+	return s.do(http.MethodDelete, "/api/peer/notes/"+strconv.Itoa(id), nil, nil)
+}
+
+// do sends a request to the server and decodes the JSON response into out
+func (s *httpStore) do(method, path string, body []byte, out interface{}) error {
+	req, err := http.NewRequest(method, s.base+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach %s: %w", s.base, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Error != "" {
+			return fmt.Errorf("%s %s: %s", method, path, apiErr.Error)
+		}
+		return fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid response from %s: %w", s.base, err)
+	}
+	return nil
+}
@@ -0,0 +1,514 @@
+package peer
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+)
+
+// peersDir is the folder inside the metadata directory holding the sync
+// base of every peer
+const peersDir = "peers"
+
+// ConflictTag is added to the conflict copies created by a sync
+const ConflictTag = "conflict"
+
+// Options controls a sync
+type Options struct {
+	// DryRun reports what a sync would change without changing anything
+	DryRun bool
+}
+
+// Result reports what a sync changed, or would change for a dry run
+type Result struct {
+	// Pulled counts notes added or updated locally, Pushed notes added or
+	// updated on the peer
+	Pulled int
+	Pushed int
+	// DeletedLocal and DeletedRemote count notes deleted on one side because
+	// they were deleted on the other
+	DeletedLocal  int
+	DeletedRemote int
+	Conflicts     []Conflict
+}
+
+// Conflict is a note changed differently on both sides. The note keeps the
+// local values of the conflicting fields and the peer's version is saved as
+// a conflict copy on both sides.
+type Conflict struct {
+	ID     int
+	Title  string
+	CopyID int
+	Fields []string
+}
+
+// base is the state of the notes after the last sync with a peer, in local
+// IDs, keyed by SyncID
+type base struct {
+	Peer     string                `json:"peer"`
+	SyncedAt time.Time             `json:"synced_at"`
+	Notes    map[string]*note.Note `json:"-"`
+	// Files are the notes as saved by the storage, so encrypted notes are
+	// encrypted in the base as well
+	Files map[string]json.RawMessage `json:"notes"`
+}
+
+// Sync synchronizes the notes of storage with a peer in both directions.
+// location identifies the peer for its sync base, see Location.
+//
+// Notes are matched by their SyncID, so they may have different IDs on the
+// two sides; links between notes are translated. A note added on one side is
+// added to the other, keeping its ID if it is free. A note deleted on one
+// side is deleted on the other unless it was changed there since the last
+// sync. Notes changed on both sides are merged with Merge.
+//
+// Attachment files are not transferred, only the attachment names.
+func Sync(storage *note.Storage, peer Store, location string, opts Options) (*Result, error) {
+	location = Location(location)
+	if location == Location(storage.Dir()) {
+		return nil, fmt.Errorf("cannot sync the notes directory with itself")
+	}
+	// Locked notes look deleted, which would delete them on the peer
+	if locked := storage.LockedNotes(); len(locked) > 0 {
+		return nil, fmt.Errorf("%d encrypted notes are locked, unlock them before syncing", len(locked))
+	}
+	// A notes directory encrypts with the same key, so encrypted notes can
+	// be written to it and read from it
+	if local, ok := peer.(*localStore); ok {
+		if err := storage.ShareEncryption(local.storage); err != nil {
+			return nil, fmt.Errorf("failed to open encrypted notes of peer: %w", err)
+		}
+	}
+
+	last, err := loadBase(storage, location)
+	if err != nil {
+		return nil, err
+	}
+
+	remoteNotes, err := peer.Notes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notes of peer: %w", err)
+	}
+
+	s := newSession(storage.GetAllNotes(), remoteNotes, last)
+	result := &Result{}
+
+	for _, uid := range s.uids() {
+		local, remote, prev := s.local[uid], s.remote[uid], last.Notes[uid]
+
+		switch {
+		case local == nil && remote == nil:
+			// Deleted on both sides
+
+		case remote == nil && prev == nil:
+			// Added locally
+			s.pushNote(uid, local)
+			result.Pushed++
+
+		case local == nil && prev == nil:
+			// Added on the peer
+			s.pullNote(uid, s.toLocal(remote, s.localID[uid]))
+			result.Pulled++
+
+		case remote == nil:
+			// Deleted on the peer: follow unless changed locally since
+			if sameNote(local, prev) {
+				s.deleteLocal = append(s.deleteLocal, local.ID)
+				result.DeletedLocal++
+			} else {
+				s.pushNote(uid, local)
+				result.Pushed++
+			}
+
+		case local == nil:
+			// Deleted locally: follow unless changed on the peer since
+			incoming := s.toLocal(remote, s.localID[uid])
+			if sameNote(incoming, prev) {
+				s.deleteRemote = append(s.deleteRemote, remote.ID)
+				result.DeletedRemote++
+			} else {
+				s.pullNote(uid, incoming)
+				result.Pulled++
+			}
+
+		default:
+			incoming := s.toLocal(remote, local.ID)
+			merged, fields := Merge(prev, local, incoming)
+			merged.UID = local.UID
+			s.base[uid] = merged
+
+			if !sameNote(merged, local) || !merged.UpdatedAt.Equal(local.UpdatedAt) {
+				s.putLocal = append(s.putLocal, merged)
+				result.Pulled++
+			}
+			if !sameNote(merged, incoming) || !merged.UpdatedAt.Equal(incoming.UpdatedAt) {
+				s.putRemote = append(s.putRemote, s.outgoing(uid, merged, remote))
+				result.Pushed++
+			}
+
+			if len(fields) > 0 {
+				copied := s.conflictCopy(incoming)
+				result.Conflicts = append(result.Conflicts, Conflict{ID: local.ID, Title: merged.Title, CopyID: copied.ID, Fields: fields})
+			}
+		}
+	}
+
+	if opts.DryRun {
+		return result, nil
+	}
+
+	// The notes written here are reported as one change, such as a single
+	// entry in the journal
+	err = storage.Group(func() error {
+		return s.apply(storage, peer)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	last.Peer = location
+	last.SyncedAt = time.Now()
+	last.Notes = s.base
+	if err := saveBase(storage, location, last); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// session holds the notes of both sides during a sync, the ID each note has
+// or will have on either side and the writes to make
+type session struct {
+	local, remote map[string]*note.Note
+	last          *base
+
+	localID, remoteID     map[string]int
+	localUID, remoteUID   map[int]string
+	usedLocal, usedRemote map[int]bool
+	maxLocal, maxRemote   int
+
+	// base is the new sync base
+	base map[string]*note.Note
+
+	putLocal, putRemote       []*note.Note
+	deleteLocal, deleteRemote []int
+}
+
+func newSession(localNotes, remoteNotes []*note.Note, last *base) *session {
+	s := &session{
+		local:      make(map[string]*note.Note),
+		remote:     make(map[string]*note.Note),
+		last:       last,
+		localID:    make(map[string]int),
+		remoteID:   make(map[string]int),
+		usedLocal:  make(map[int]bool),
+		usedRemote: make(map[int]bool),
+		base:       make(map[string]*note.Note),
+	}
+
+	for _, n := range localNotes {
+		s.local[n.SyncID()] = n
+		s.localID[n.SyncID()] = n.ID
+		s.usedLocal[n.ID] = true
+		if n.ID > s.maxLocal {
+			s.maxLocal = n.ID
+		}
+	}
+	for _, n := range remoteNotes {
+		s.remote[n.SyncID()] = n
+		s.remoteID[n.SyncID()] = n.ID
+		s.usedRemote[n.ID] = true
+		if n.ID > s.maxRemote {
+			s.maxRemote = n.ID
+		}
+	}
+
+	// Notes deleted locally since the last sync keep their old ID unless it
+	// was taken by a new note, so they come back under it if the peer
+	// changed them
+	for uid, n := range last.Notes {
+		if _, ok := s.localID[uid]; !ok && !s.usedLocal[n.ID] {
+			s.localID[uid] = s.allocate(n.ID, s.usedLocal, &s.maxLocal)
+		}
+	}
+
+	// Notes that exist on one side only are given an ID on the other before
+	// anything is translated, so links to them can be rewritten
+	for _, uid := range s.uids() {
+		if _, ok := s.localID[uid]; !ok && s.remote[uid] != nil {
+			s.localID[uid] = s.allocate(s.remote[uid].ID, s.usedLocal, &s.maxLocal)
+		}
+		if _, ok := s.remoteID[uid]; !ok && s.local[uid] != nil {
+			s.remoteID[uid] = s.allocate(s.local[uid].ID, s.usedRemote, &s.maxRemote)
+		}
+	}
+
+	s.localUID = reverse(s.localID)
+	s.remoteUID = reverse(s.remoteID)
+	return s
+}
+
+// reverse maps IDs back to SyncIDs
+func reverse(ids map[string]int) map[int]string {
+	uids := make(map[int]string, len(ids))
+	for uid, id := range ids {
+		uids[id] = uid
+	}
+	return uids
+}
+
+// uids returns the SyncIDs of all notes on either side or in the base, in a
+// stable order
+func (s *session) uids() []string {
+	seen := make(map[string]bool)
+	var uids []string
+	add := func(uid string) {
+		if !seen[uid] {
+			seen[uid] = true
+			uids = append(uids, uid)
+		}
+	}
+	for uid := range s.local {
+		add(uid)
+	}
+	for uid := range s.remote {
+		add(uid)
+	}
+	for uid := range s.last.Notes {
+		add(uid)
+	}
+	sort.Strings(uids)
+	return uids
+}
+
+// allocate reserves the preferred ID if it is free, or the next unused one
+func (s *session) allocate(preferred int, used map[int]bool, max *int) int {
+	id := preferred
+	if id <= 0 || used[id] {
+		*max++
+		id = *max
+	}
+	used[id] = true
+	if id > *max {
+		*max = id
+	}
+	return id
+}
+
+// pushNote schedules a local note to be written to the peer
+func (s *session) pushNote(uid string, local *note.Note) {
+	s.base[uid] = local
+	s.putRemote = append(s.putRemote, s.outgoing(uid, local, s.remote[uid]))
+}
+
+// pullNote schedules a note of the peer, already translated to local IDs, to
+// be written locally
+func (s *session) pullNote(uid string, incoming *note.Note) {
+	s.base[uid] = incoming
+	s.putLocal = append(s.putLocal, incoming)
+}
+
+// outgoing translates a local note for the peer, keeping the UID the peer
+// has for it; notes new to the peer carry their SyncID as UID since their
+// ID there may differ
+func (s *session) outgoing(uid string, local, remote *note.Note) *note.Note {
+	out := translate(local, s.remoteID[uid], func(id int) (int, bool) {
+		return lookup(id, s.localUID, s.remoteID)
+	})
+	if remote != nil {
+		out.UID = remote.UID
+	} else {
+		out.UID = uid
+	}
+	return out
+}
+
+// toLocal translates a note of the peer to local IDs under the given ID,
+// keeping the local UID form
+func (s *session) toLocal(remote *note.Note, id int) *note.Note {
+	incoming := translate(remote, id, func(id int) (int, bool) {
+		return lookup(id, s.remoteUID, s.localID)
+	})
+	uid := remote.SyncID()
+	if local := s.local[uid]; local != nil {
+		incoming.UID = local.UID
+	} else {
+		incoming.UID = uid
+	}
+	return incoming
+}
+
+// lookup translates a note ID from one side to the other
+func lookup(id int, from map[int]string, to map[string]int) (int, bool) {
+	uid, ok := from[id]
+	if !ok {
+		return 0, false
+	}
+	target, ok := to[uid]
+	return target, ok
+}
+
+// conflictCopy schedules a copy of the peer's version of a note, translated
+// to local IDs, to be added on both sides
+func (s *session) conflictCopy(incoming *note.Note) *note.Note {
+	now := time.Now()
+	copied := incoming.Clone()
+	copied.UID = newUID()
+	copied.Title = fmt.Sprintf("%s (conflict copy %s)", incoming.Title, now.Format("2006-01-02 15:04"))
+	copied.AddTag(ConflictTag)
+	copied.CreatedAt = now
+	copied.UpdatedAt = now
+	copied.ID = s.allocate(0, s.usedLocal, &s.maxLocal)
+	s.localID[copied.UID] = copied.ID
+	s.remoteID[copied.UID] = s.allocate(0, s.usedRemote, &s.maxRemote)
+
+	s.pullNote(copied.UID, copied)
+	s.putRemote = append(s.putRemote, s.outgoing(copied.UID, copied, nil))
+	return copied
+}
+
+// apply makes the scheduled writes, deleting before writing so freed IDs
+// can be reused
+func (s *session) apply(storage *note.Storage, peer Store) error {
+	for _, id := range s.deleteLocal {
+		if err := storage.DeleteNote(id); err != nil {
+			return fmt.Errorf("failed to delete note %d: %w", id, err)
+		}
+	}
+	for _, id := range s.deleteRemote {
+		if err := peer.Delete(id); err != nil {
+			return fmt.Errorf("failed to delete note %d on peer: %w", id, err)
+		}
+	}
+	for _, n := range s.putLocal {
+		if _, err := storage.PutNote(n); err != nil {
+			return fmt.Errorf("failed to save note %d: %w", n.ID, err)
+		}
+	}
+	for _, n := range s.putRemote {
+		if err := peer.Put(n); err != nil {
+			return fmt.Errorf("failed to save note %d on peer: %w", n.ID, err)
+		}
+	}
+	return nil
+}
+
+// translate copies a note under a new ID, rewriting its links with fn
+func translate(n *note.Note, id int, fn func(id int) (int, bool)) *note.Note {
+	out := n.Clone()
+	out.ID = id
+	out.Content = note.RewriteLinks(n.Content, fn)
+	for i, link := range out.Links {
+		if target, ok := fn(link); ok {
+			out.Links[i] = target
+		}
+	}
+	return out
+}
+
+// sameNote reports whether two versions of a note have the same contents,
+// ignoring their IDs and modification time
+func sameNote(a, b *note.Note) bool {
+	normalize := func(n *note.Note) ([]byte, error) {
+		c := n.Clone()
+		c.ID, c.UID = 0, ""
+		c.UpdatedAt = time.Time{}
+		return json.Marshal(c)
+	}
+
+	left, err := normalize(a)
+	if err != nil {
+		return false
+	}
+	right, err := normalize(b)
+	if err != nil {
+		return false
+	}
+	return string(left) == string(right)
+}
+
+// newUID returns a random note UID
+func newUID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// basePath returns the file holding the sync base of a peer
+func basePath(storage *note.Storage, location string) string {
+	sum := sha256.Sum256([]byte(location))
+	return filepath.Join(storage.MetaDir(), peersDir, hex.EncodeToString(sum[:8])+".json")
+}
+
+// loadBase reads the sync base of a peer; peers never synced with have an
+// empty base
+func loadBase(storage *note.Storage, location string) (*base, error) {
+	last := &base{Peer: location, Notes: make(map[string]*note.Note)}
+
+	data, err := os.ReadFile(basePath(storage, location))
+	if errors.Is(err, os.ErrNotExist) {
+		return last, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sync state: %w", err)
+	}
+
+	if err := json.Unmarshal(data, last); err != nil {
+		return nil, fmt.Errorf("failed to read sync state: %w", err)
+	}
+	for uid, file := range last.Files {
+		n, err := storage.DecodeNote(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sync state: %w", err)
+		}
+		last.Notes[uid] = n
+	}
+	return last, nil
+}
+
+// saveBase writes the sync base of a peer
+func saveBase(storage *note.Storage, location string, last *base) error {
+	last.Files = make(map[string]json.RawMessage, len(last.Notes))
+	for uid, n := range last.Notes {
+		file, err := storage.EncodeNote(n)
+		if err != nil {
+			return fmt.Errorf("failed to encode sync state: %w", err)
+		}
+		last.Files[uid] = file
+	}
+
+	data, err := json.MarshalIndent(last, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode sync state: %w", err)
+	}
+
+	file := basePath(storage, location)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return fmt.Errorf("failed to save sync state: %w", err)
+	}
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		return fmt.Errorf("failed to save sync state: %w", err)
+	}
+	return nil
+}
+
+// LastSync returns when the notes were last synced with a peer, or the zero
+// time if never
+func LastSync(storage *note.Storage, location string) time.Time {
+	last, err := loadBase(storage, Location(location))
+	if err != nil {
+		return time.Time{}
+	}
+	return last.SyncedAt
+}
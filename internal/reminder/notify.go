@@ -0,0 +1,99 @@
+package reminder
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+)
+
+// commandTimeout bounds how long a reminder command may run
+const commandTimeout = time.Minute
+
+// Notifier delivers a fired reminder somewhere
+type Notifier interface {
+	Notify(n *note.Note) error
+}
+
+// NotifierFunc adapts an ordinary function to the Notifier interface
+type NotifierFunc func(n *note.Note) error
+
+// Notify calls f(n)
+func (f NotifierFunc) Notify(n *note.Note) error {
+	return f(n)
+}
+
+// TerminalNotifier prints reminders to a terminal
+type TerminalNotifier struct {
+	Out io.Writer
+}
+
+// Notify prints the reminder, ringing the terminal bell
+func (t TerminalNotifier) Notify(n *note.Note) error {
+	_, err := fmt.Fprintf(t.Out, "\a⏰ Reminder: [%d] %s\n", n.ID, n.Title)
+	if err != nil {
+		return err
+	}
+
+	if n.DueAt != nil {
+		_, err = fmt.Fprintf(t.Out, "   Due: %s\n", n.DueAt.Format("2006-01-02 15:04"))
+	}
+	return err
+}
+
+// CommandNotifier runs a local shell command for each reminder. The note is
+// described to the command through the GONOTES_NOTE_ID, GONOTES_NOTE_TITLE
+// and GONOTES_NOTE_DUE environment variables.
+type CommandNotifier struct {
+	Command string
+}
+
+// Notify runs the configured command
+func (c CommandNotifier) Notify(n *note.Note) error {
+	if c.Command == "" {
+		return nil
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", c.Command)
+	} else {
+		cmd = exec.Command("sh", "-c", c.Command)
+	}
+
+	due := ""
+	if n.DueAt != nil {
+		due = n.DueAt.Format(time.RFC3339)
+	}
+
+	cmd.Env = append(os.Environ(),
+		"GONOTES_NOTE_ID="+strconv.Itoa(n.ID),
+		"GONOTES_NOTE_TITLE="+n.Title,
+		"GONOTES_NOTE_DUE="+due,
+	)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start reminder command: %w", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("reminder command failed: %w", err)
+		}
+		return nil
+	case <-time.After(commandTimeout):
+		cmd.Process.Kill()
+		return fmt.Errorf("reminder command timed out after %s", commandTimeout)
+	}
+}
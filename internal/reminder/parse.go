@@ -0,0 +1,278 @@
+package reminder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default times of day used when an expression names a day but no time
+const (
+	defaultHour = 9
+	eveningHour = 20
+)
+
+// absoluteLayouts are the explicit date formats accepted by ParseWhen
+var absoluteLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02 3:04pm",
+	"2006-01-02 3pm",
+	"2006-01-02",
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"sun":       time.Sunday,
+	"monday":    time.Monday,
+	"mon":       time.Monday,
+	"tuesday":   time.Tuesday,
+	"tue":       time.Tuesday,
+	"wednesday": time.Wednesday,
+	"wed":       time.Wednesday,
+	"thursday":  time.Thursday,
+	"thu":       time.Thursday,
+	"friday":    time.Friday,
+	"fri":       time.Friday,
+	"saturday":  time.Saturday,
+	"sat":       time.Saturday,
+}
+
+// ParseWhen parses a human friendly point in time relative to now.
+//
+// Supported forms include:
+//
+//	2026-01-02, 2026-01-02 15:04, RFC 3339 timestamps
+//	in 10 minutes, in 2h, in 3 days, 1h30m
+//	9am, 14:30, noon, midnight
+//	today 5pm, tonight, tomorrow 9am, friday, next monday at 10:30
+func ParseWhen(input string, now time.Time) (time.Time, error) {
+	text := strings.ToLower(strings.TrimSpace(input))
+	if text == "" {
+		return time.Time{}, fmt.Errorf("empty date expression")
+	}
+
+	// Timestamps keep their case, since layouts such as RFC 3339 need an
+	// upper case T and Z
+	exact := strings.TrimSpace(input)
+	for _, layout := range absoluteLayouts {
+		t, err := time.ParseInLocation(layout, exact, now.Location())
+		if err != nil {
+			t, err = time.ParseInLocation(layout, text, now.Location())
+		}
+		if err == nil {
+			if layout == "2006-01-02" {
+				t = t.Add(defaultHour * time.Hour)
+			}
+			return t, nil
+		}
+	}
+
+	if d, ok := parseRelative(text); ok {
+		return now.Add(d), nil
+	}
+
+	t, err := parseDayAndTime(text, now)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not understand date %q: %w", input, err)
+	}
+	return t, nil
+}
+
+// ParseDuration parses durations such as "10m", "2 hours" or "3 days"
+func ParseDuration(input string) (time.Duration, error) {
+	text := strings.ToLower(strings.TrimSpace(input))
+	text = strings.TrimPrefix(text, "in ")
+	if d, ok := parseRelative("in " + text); ok {
+		return d, nil
+	}
+	return 0, fmt.Errorf("invalid duration %q", input)
+}
+
+// parseRelative handles "in <n> <unit>" and Go duration strings like "1h30m".
+// Durations pointing into the past, such as "-5m", are not relative dates.
+func parseRelative(text string) (time.Duration, bool) {
+	d, ok := parseSpan(text)
+	return d, ok && d >= 0
+}
+
+// parseSpan is parseRelative, allowing negative durations
+func parseSpan(text string) (time.Duration, bool) {
+	if d, err := time.ParseDuration(text); err == nil {
+		return d, true
+	}
+
+	if !strings.HasPrefix(text, "in ") {
+		return 0, false
+	}
+	rest := strings.TrimSpace(strings.TrimPrefix(text, "in "))
+	if d, err := time.ParseDuration(rest); err == nil {
+		return d, true
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) != 2 {
+		return 0, false
+	}
+
+	amount, err := strconv.Atoi(fields[0])
+	if err != nil {
+		if fields[0] != "a" && fields[0] != "an" {
+			return 0, false
+		}
+		amount = 1
+	}
+
+	unit := strings.TrimSuffix(fields[1], "s")
+	switch unit {
+	case "min", "minute", "m":
+		return time.Duration(amount) * time.Minute, true
+	case "hour", "hr", "h":
+		return time.Duration(amount) * time.Hour, true
+	case "day", "d":
+		return time.Duration(amount) * 24 * time.Hour, true
+	case "week", "wk", "w":
+		return time.Duration(amount) * 7 * 24 * time.Hour, true
+	}
+	return 0, false
+}
+
+// parseDayAndTime handles expressions made of an optional day and an optional
+// time of day, e.g. "tomorrow 9am" or "next friday at 14:00"
+func parseDayAndTime(text string, now time.Time) (time.Time, error) {
+	fields := strings.Fields(text)
+
+	var (
+		day     time.Time
+		hasDay  bool
+		hour    = defaultHour
+		minute  int
+		hasTime bool
+	)
+
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+
+		switch {
+		case field == "at" || field == "on":
+			continue
+		case field == "today":
+			day, hasDay = now, true
+		case field == "tonight":
+			day, hasDay = now, true
+			if !hasTime {
+				hour, minute = eveningHour, 0
+			}
+		case field == "tomorrow":
+			day, hasDay = now.AddDate(0, 0, 1), true
+		case field == "next" && i+1 < len(fields):
+			wd, ok := weekdays[fields[i+1]]
+			if !ok {
+				return time.Time{}, fmt.Errorf("unknown day %q", fields[i+1])
+			}
+			day, hasDay = nextWeekday(now, wd), true
+			i++
+		case isWeekday(field):
+			day, hasDay = nextWeekday(now, weekdays[field]), true
+		default:
+			// Allow "9 am" as well as "9am"
+			if i+1 < len(fields) && (fields[i+1] == "am" || fields[i+1] == "pm") {
+				field += fields[i+1]
+				i++
+			}
+			h, m, err := parseClock(field)
+			if err != nil {
+				return time.Time{}, err
+			}
+			hour, minute, hasTime = h, m, true
+		}
+	}
+
+	if !hasDay && !hasTime {
+		return time.Time{}, fmt.Errorf("no day or time found")
+	}
+
+	if !hasDay {
+		// A bare time means the next occurrence of that time
+		t := atClock(now, hour, minute)
+		if !t.After(now) {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+
+	return atClock(day, hour, minute), nil
+}
+
+// parseClock parses times of day like "9am", "9:30pm", "14:05", "noon"
+func parseClock(text string) (int, int, error) {
+	switch text {
+	case "noon", "midday":
+		return 12, 0, nil
+	case "midnight":
+		return 0, 0, nil
+	case "morning":
+		return defaultHour, 0, nil
+	case "evening":
+		return eveningHour, 0, nil
+	}
+
+	suffix := ""
+	if strings.HasSuffix(text, "am") || strings.HasSuffix(text, "pm") {
+		suffix = text[len(text)-2:]
+		text = text[:len(text)-2]
+	}
+
+	hourText, minuteText, hasMinutes := strings.Cut(text, ":")
+	hour, err := strconv.Atoi(hourText)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid time %q", text+suffix)
+	}
+
+	minute := 0
+	if hasMinutes {
+		minute, err = strconv.Atoi(minuteText)
+		if err != nil || minute < 0 || minute > 59 {
+			return 0, 0, fmt.Errorf("invalid minutes in %q", text+suffix)
+		}
+	}
+
+	switch suffix {
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0, 0, fmt.Errorf("invalid hour in %q", text+suffix)
+		}
+		hour %= 12
+		if suffix == "pm" {
+			hour += 12
+		}
+	default:
+		if !hasMinutes || hour < 0 || hour > 23 {
+			return 0, 0, fmt.Errorf("invalid time %q", text)
+		}
+	}
+
+	return hour, minute, nil
+}
+
+// isWeekday reports whether text names a day of the week
+func isWeekday(text string) bool {
+	_, ok := weekdays[text]
+	return ok
+}
+
+// nextWeekday returns the next day after now falling on wd
+func nextWeekday(now time.Time, wd time.Weekday) time.Time {
+	days := (int(wd) - int(now.Weekday()) + 7) % 7
+	if days == 0 {
+		days = 7
+	}
+	return now.AddDate(0, 0, days)
+}
+
+// atClock returns day at the given hour and minute
+func atClock(day time.Time, hour, minute int) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, day.Location())
+}
@@ -0,0 +1,107 @@
+package reminder
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseWhen(t *testing.T) {
+	// A Wednesday
+	now := time.Date(2024, time.May, 15, 10, 0, 0, 0, time.UTC)
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2024, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		input   string
+		want    time.Time
+		wantErr bool
+	}{
+		{input: "2026-01-02", want: time.Date(2026, time.January, 2, 9, 0, 0, 0, time.UTC)},
+		{input: "2026-01-02 15:04", want: time.Date(2026, time.January, 2, 15, 4, 0, 0, time.UTC)},
+		{input: "2026-01-02 3pm", want: time.Date(2026, time.January, 2, 15, 0, 0, 0, time.UTC)},
+		{input: "2024-05-20T08:00:00Z", want: at(time.May, 20, 8, 0)},
+		{input: "in 10 minutes", want: at(time.May, 15, 10, 10)},
+		{input: "in 2h", want: at(time.May, 15, 12, 0)},
+		{input: "in an hour", want: at(time.May, 15, 11, 0)},
+		{input: "in 3 days", want: at(time.May, 18, 10, 0)},
+		{input: "in 1 week", want: at(time.May, 22, 10, 0)},
+		{input: "1h30m", want: at(time.May, 15, 11, 30)},
+		{input: "14:30", want: at(time.May, 15, 14, 30)},
+		{input: "9am", want: at(time.May, 16, 9, 0)},
+		{input: "noon", want: at(time.May, 15, 12, 0)},
+		{input: "midnight", want: at(time.May, 16, 0, 0)},
+		{input: "today 5pm", want: at(time.May, 15, 17, 0)},
+		{input: "tonight", want: at(time.May, 15, 20, 0)},
+		{input: "tomorrow", want: at(time.May, 16, 9, 0)},
+		{input: "Tomorrow 9 AM", want: at(time.May, 16, 9, 0)},
+		{input: "tomorrow at 9:30pm", want: at(time.May, 16, 21, 30)},
+		{input: "friday", want: at(time.May, 17, 9, 0)},
+		{input: "wednesday", want: at(time.May, 22, 9, 0)},
+		{input: "next monday at 10:30", want: at(time.May, 20, 10, 30)},
+		{input: "sat evening", want: at(time.May, 18, 20, 0)},
+		{input: "", wantErr: true},
+		{input: "someday", wantErr: true},
+		{input: "25:00", wantErr: true},
+		{input: "13pm", wantErr: true},
+		{input: "9:75", wantErr: true},
+		{input: "next blursday", wantErr: true},
+		{input: "in many days", wantErr: true},
+		{input: "-5m", wantErr: true},
+		{input: "in -5 minutes", wantErr: true},
+		{input: "in -1h", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseWhen(tt.input, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseWhen(%q) = %v, want an error", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseWhen(%q) error = %v", tt.input, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseWhen(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{input: "10m", want: 10 * time.Minute},
+		{input: "1h30m", want: 90 * time.Minute},
+		{input: "2 hours", want: 2 * time.Hour},
+		{input: "3 days", want: 72 * time.Hour},
+		{input: "in a week", want: 7 * 24 * time.Hour},
+		{input: "forever", wantErr: true},
+		{input: "-10m", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseDuration(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseDuration(%q) = %v, want an error", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDuration(%q) error = %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseDuration(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
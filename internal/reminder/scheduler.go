@@ -0,0 +1,107 @@
+package reminder
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+)
+
+// DefaultInterval is how often the scheduler checks for due reminders
+const DefaultInterval = 30 * time.Second
+
+// Scheduler periodically fires reminders that have come due
+type Scheduler struct {
+	storage   *note.Storage
+	notifiers []Notifier
+	interval  time.Duration
+
+	// Reload makes the scheduler re-read the notes directory before a check
+	// when its files changed, so reminders set by other processes are
+	// picked up
+	Reload bool
+	stamp  string
+
+	// OnError is called for every error encountered while firing reminders
+	OnError func(err error)
+}
+
+// NewScheduler creates a scheduler that notifies all notifiers of due reminders
+func NewScheduler(storage *note.Storage, interval time.Duration, notifiers ...Notifier) *Scheduler {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	return &Scheduler{
+		storage:   storage,
+		notifiers: notifiers,
+		interval:  interval,
+	}
+}
+
+// Run checks for due reminders until the context is cancelled
+func (s *Scheduler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.check(time.Now())
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C:
+			s.check(now)
+		}
+	}
+}
+
+// Check fires every reminder that is due at now and returns the notes whose
+// reminders were fired. A fired reminder is cleared so it only fires once.
+func (s *Scheduler) Check(now time.Time) ([]*note.Note, error) {
+	if s.Reload {
+		if stamp := s.storage.Stamp(); stamp != s.stamp {
+			if err := s.storage.Reload(); err != nil {
+				return nil, fmt.Errorf("failed to reload notes: %w", err)
+			}
+			s.stamp = stamp
+		}
+	}
+
+	var fired []*note.Note
+	for _, n := range s.storage.GetDueReminders(now) {
+		for _, notifier := range s.notifiers {
+			if err := notifier.Notify(n); err != nil {
+				s.reportError(fmt.Errorf("failed to notify reminder for note %d: %w", n.ID, err))
+			}
+		}
+
+		cleared, err := s.storage.ClearReminder(n.ID)
+		if err != nil {
+			s.reportError(fmt.Errorf("failed to clear reminder for note %d: %w", n.ID, err))
+			continue
+		}
+		fired = append(fired, cleared)
+	}
+	if s.Reload && len(fired) > 0 {
+		// The notes in memory are up to date with the reminders just cleared
+		s.stamp = s.storage.Stamp()
+	}
+
+	return fired, nil
+}
+
+// check runs Check and reports its error
+func (s *Scheduler) check(now time.Time) {
+	if _, err := s.Check(now); err != nil {
+		s.reportError(err)
+	}
+}
+
+// reportError passes err to OnError if it is set
+func (s *Scheduler) reportError(err error) {
+	if s.OnError != nil {
+		s.OnError(err)
+	}
+}
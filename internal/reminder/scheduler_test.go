@@ -0,0 +1,50 @@
+package reminder
+
+import (
+	"testing"
+	"time"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+)
+
+func TestSchedulerReload(t *testing.T) {
+	now := time.Date(2024, time.May, 15, 10, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+	storage, err := note.NewStorage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := storage.CreateNote("Call", "Call the bank", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var notified []int
+	scheduler := NewScheduler(storage, time.Minute, NotifierFunc(func(n *note.Note) error {
+		notified = append(notified, n.ID)
+		return nil
+	}))
+	scheduler.Reload = true
+	if fired, err := scheduler.Check(now); err != nil || len(fired) != 0 {
+		t.Fatalf("Check() = %d notes, %v, want none", len(fired), err)
+	}
+
+	// A reminder set by another process is picked up
+	other, err := note.NewStorage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.SetReminder(1, now.Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if fired, err := scheduler.Check(now); err != nil || len(fired) != 1 {
+		t.Fatalf("Check() = %d notes, %v, want the reminder set elsewhere", len(fired), err)
+	}
+
+	// The cleared reminder stays cleared
+	if fired, err := scheduler.Check(now); err != nil || len(fired) != 0 {
+		t.Errorf("Check() again = %d notes, %v, want none", len(fired), err)
+	}
+	if len(notified) != 1 || notified[0] != 1 {
+		t.Errorf("notified = %v, want [1]", notified)
+	}
+}
@@ -0,0 +1,478 @@
+// Package site renders notes as a static HTML site with tag and notebook
+// pages, backlinks and a client-side search index.
+package site
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/markdown"
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+)
+
+//go:embed templates/*.html static/*
+var files embed.FS
+
+// generated are the files and folders a build writes to the output folder.
+// They are removed first so that notes no longer matching the query are not
+// left behind.
+var generated = []string{
+	"index.html", "style.css", "search.js", "search.json",
+	"notes", "tags", "notebooks", "attachments",
+}
+
+// Options control how a site is built
+type Options struct {
+	// Query selects the notes to publish; nil publishes every note
+	Query *note.Query
+	// Title is the site title, "Notes" by default
+	Title string
+	// Templates is a folder whose layout.html, index.html, note.html,
+	// tag.html, tags.html or notebook.html replace the built-in templates
+	Templates string
+}
+
+// Result reports what a build wrote
+type Result struct {
+	Notes       int
+	Tags        int
+	Notebooks   int
+	Attachments int
+}
+
+// Build renders the notes matching the options into outDir
+func Build(storage *note.Storage, outDir string, opts Options) (Result, error) {
+	if opts.Title == "" {
+		opts.Title = "Notes"
+	}
+
+	// Encrypted notes are never published, nor put in the search index
+	var notes []*note.Note
+	for _, n := range storage.GetAllNotes() {
+		if !n.Encrypted {
+			notes = append(notes, n)
+		}
+	}
+	if opts.Query != nil {
+		notes = opts.Query.Filter(notes)
+	}
+	sort.Slice(notes, func(i, j int) bool {
+		return notes[i].UpdatedAt.After(notes[j].UpdatedAt)
+	})
+
+	b := &builder{
+		storage:  storage,
+		outDir:   outDir,
+		opts:     opts,
+		views:    make(map[int]*noteView),
+		tags:     make(map[string][]*noteView),
+		now:      time.Now(),
+		included: make(map[int]bool),
+	}
+	for _, n := range notes {
+		b.included[n.ID] = true
+	}
+	for _, n := range notes {
+		view := b.newNoteView(n)
+		b.views[n.ID] = view
+		b.order = append(b.order, view)
+		for _, tag := range n.Tags {
+			b.tags[tag] = append(b.tags[tag], view)
+		}
+	}
+	b.tree = buildTree(b.order)
+
+	if err := b.prepare(); err != nil {
+		return Result{}, err
+	}
+
+	steps := []func() error{b.writeStatic, b.writeIndex, b.writeNotes, b.writeTags, b.writeNotebooks, b.writeSearchIndex}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return Result{}, err
+		}
+	}
+
+	attachments, err := b.copyAttachments(notes)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return Result{
+		Notes:       len(notes),
+		Tags:        len(b.tags),
+		Notebooks:   b.tree.size() - 1,
+		Attachments: attachments,
+	}, nil
+}
+
+// builder holds the state of a single build
+type builder struct {
+	storage  *note.Storage
+	outDir   string
+	opts     Options
+	now      time.Time
+	included map[int]bool
+	views    map[int]*noteView
+	order    []*noteView
+	tags     map[string][]*noteView
+	tree     *notebookNode
+	// templates caches parsed templates by name
+	templates map[string]*template.Template
+}
+
+// link is a named page reference, relative to the site root
+type link struct {
+	Name  string
+	URL   string
+	Count int
+}
+
+// noteView is the data templates see for a note
+type noteView struct {
+	ID       int
+	Title    string
+	URL      string
+	HTML     template.HTML
+	Excerpt  string
+	Tags     []link
+	Notebook *link
+	Created  time.Time
+	Updated  time.Time
+	note     *note.Note
+}
+
+// noteList is the data of the "note-list" template
+type noteList struct {
+	Root  string
+	Notes []*noteView
+}
+
+// page is the data passed to every template
+type page struct {
+	Site  string
+	Title string
+	// Root is the relative path from the page to the site root
+	Root      string
+	Generated time.Time
+	Note      *noteView
+	Notes     []*noteView
+	Backlinks []*noteView
+	Tags      []link
+	Tree      *notebookNode
+}
+
+func notePath(id int) string {
+	return "notes/" + strconv.Itoa(id) + ".html"
+}
+
+func tagPath(tag string) string {
+	return "tags/" + pageName(tag) + ".html"
+}
+
+func notebookPath(notebook string) string {
+	return "notebooks/" + pageName(notebook) + ".html"
+}
+
+// pageName returns a file name for a tag or notebook page
+func pageName(name string) string {
+	if slug := markdown.Slug(name); slug != "" && slug != "index" {
+		return slug
+	}
+	return "_" + strconv.Itoa(len(name))
+}
+
+// newNoteView renders a note for the templates
+func (b *builder) newNoteView(n *note.Note) *noteView {
+	renderer := &markdown.Renderer{
+		Link: func(dest string) (string, bool) {
+			switch {
+			case strings.HasPrefix(dest, note.LinkPrefix):
+				id, err := strconv.Atoi(strings.TrimPrefix(dest, note.LinkPrefix))
+				// Links to unpublished notes keep only their text
+				if err != nil || !b.included[id] {
+					return "", false
+				}
+				return strconv.Itoa(id) + ".html", true
+			case strings.HasPrefix(dest, note.AttachmentPrefix):
+				name := strings.TrimPrefix(dest, note.AttachmentPrefix)
+				return "../attachments/" + strconv.Itoa(n.ID) + "/" + name, true
+			}
+			return dest, true
+		},
+	}
+
+	rendered := renderer.Render(n.Content)
+	view := &noteView{
+		ID:      n.ID,
+		Title:   n.Title,
+		URL:     notePath(n.ID),
+		HTML:    template.HTML(rendered),
+		Excerpt: excerpt(rendered, 160),
+		Created: n.CreatedAt,
+		Updated: n.UpdatedAt,
+		note:    n,
+	}
+	for _, tag := range n.Tags {
+		view.Tags = append(view.Tags, link{Name: tag, URL: tagPath(tag)})
+	}
+	if n.Notebook != "" {
+		view.Notebook = &link{Name: n.Notebook, URL: notebookPath(n.Notebook)}
+	}
+	return view
+}
+
+// prepare creates the output folder and removes the output of earlier builds
+func (b *builder) prepare() error {
+	if err := os.MkdirAll(b.outDir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", b.outDir, err)
+	}
+	for _, name := range generated {
+		if err := os.RemoveAll(filepath.Join(b.outDir, name)); err != nil {
+			return fmt.Errorf("failed to clean %s: %w", name, err)
+		}
+	}
+	for _, dir := range []string{"notes", "tags", "notebooks"} {
+		if err := os.MkdirAll(filepath.Join(b.outDir, dir), 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+	}
+	return nil
+}
+
+// template returns the layout combined with a page template, preferring the
+// files of the template folder
+func (b *builder) template(name string) (*template.Template, error) {
+	if t, ok := b.templates[name]; ok {
+		return t, nil
+	}
+
+	t := template.New(name).Funcs(template.FuncMap{
+		"date": func(t time.Time) string { return t.Format("2006-01-02") },
+		"list": func(root string, notes []*noteView) noteList { return noteList{Root: root, Notes: notes} },
+	})
+
+	for _, file := range []string{"layout.html", name} {
+		data, err := b.templateFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := t.Parse(string(data)); err != nil {
+			return nil, fmt.Errorf("failed to parse template %s: %w", file, err)
+		}
+	}
+
+	if b.templates == nil {
+		b.templates = make(map[string]*template.Template)
+	}
+	b.templates[name] = t
+	return t, nil
+}
+
+func (b *builder) templateFile(name string) ([]byte, error) {
+	if b.opts.Templates != "" {
+		data, err := os.ReadFile(filepath.Join(b.opts.Templates, name))
+		if err == nil {
+			return data, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read template %s: %w", name, err)
+		}
+	}
+	return files.ReadFile("templates/" + name)
+}
+
+// render writes a page using a template
+func (b *builder) render(templateName, path string, data *page) error {
+	t, err := b.template(templateName)
+	if err != nil {
+		return err
+	}
+
+	data.Site = b.opts.Title
+	data.Generated = b.now
+	data.Root = strings.Repeat("../", strings.Count(path, "/"))
+
+	f, err := os.Create(filepath.Join(b.outDir, filepath.FromSlash(path)))
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if err := t.ExecuteTemplate(f, "layout", data); err != nil {
+		return fmt.Errorf("failed to render %s: %w", path, err)
+	}
+	return nil
+}
+
+func (b *builder) writeStatic() error {
+	for _, name := range []string{"style.css", "search.js"} {
+		data, err := files.ReadFile("static/" + name)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(b.outDir, name), data, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (b *builder) writeIndex() error {
+	return b.render("index.html", "index.html", &page{Notes: b.order, Tree: b.tree.withRoot("")})
+}
+
+func (b *builder) writeNotes() error {
+	for _, view := range b.order {
+		var backlinks []*noteView
+		for _, other := range b.order {
+			if other.ID != view.ID && other.note.HasLink(view.ID) {
+				backlinks = append(backlinks, other)
+			}
+		}
+
+		data := &page{Title: view.Title, Note: view, Backlinks: backlinks}
+		if err := b.render("note.html", view.URL, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *builder) writeTags() error {
+	var tags []link
+	for tag, views := range b.tags {
+		tags = append(tags, link{Name: tag, URL: tagPath(tag), Count: len(views)})
+		if err := b.render("tag.html", tagPath(tag), &page{Title: tag, Notes: views}); err != nil {
+			return err
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].Name < tags[j].Name
+	})
+
+	return b.render("tags.html", "tags/index.html", &page{Title: "Tags", Tags: tags})
+}
+
+func (b *builder) writeNotebooks() error {
+	var write func(node *notebookNode) error
+	write = func(node *notebookNode) error {
+		for _, child := range node.Children {
+			data := &page{Title: child.Path, Notes: child.Notes, Tree: child.withRoot("../")}
+			if err := b.render("notebook.html", child.URL, data); err != nil {
+				return err
+			}
+			if err := write(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := write(b.tree); err != nil {
+		return err
+	}
+
+	return b.render("notebook.html", "notebooks/index.html", &page{Title: "Notebooks", Tree: b.tree.withRoot("../")})
+}
+
+// searchEntry is a note in search.json
+type searchEntry struct {
+	ID       int      `json:"id"`
+	Title    string   `json:"title"`
+	URL      string   `json:"url"`
+	Tags     []string `json:"tags"`
+	Notebook string   `json:"notebook"`
+	Content  string   `json:"content"`
+}
+
+func (b *builder) writeSearchIndex() error {
+	entries := make([]searchEntry, 0, len(b.order))
+	for _, view := range b.order {
+		tags := view.note.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		entries = append(entries, searchEntry{
+			ID:       view.ID,
+			Title:    view.Title,
+			URL:      view.URL,
+			Tags:     tags,
+			Notebook: view.note.Notebook,
+			Content:  view.note.Content,
+		})
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to build search index: %w", err)
+	}
+	return os.WriteFile(filepath.Join(b.outDir, "search.json"), data, 0644)
+}
+
+// copyAttachments copies the attachment files of the published notes
+func (b *builder) copyAttachments(notes []*note.Note) (int, error) {
+	count := 0
+	for _, n := range notes {
+		for _, name := range n.Attachments {
+			dir := filepath.Join(b.outDir, "attachments", strconv.Itoa(n.ID))
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return count, fmt.Errorf("failed to create attachments folder: %w", err)
+			}
+			if err := copyFile(b.storage.AttachmentPath(n.ID, name), filepath.Join(dir, name)); err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return count, fmt.Errorf("failed to copy attachment %s of note %d: %w", name, n.ID, err)
+			}
+			count++
+		}
+	}
+	return count, nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+var (
+	skippedHTML = regexp.MustCompile(`(?s)<pre>.*?</pre>|<h[1-6][^>]*>.*?</h[1-6]>`)
+	tagHTML     = regexp.MustCompile(`<[^>]*>`)
+)
+
+// excerpt returns the start of rendered content as plain text, leaving out
+// headings and code blocks
+func excerpt(rendered string, length int) string {
+	text := skippedHTML.ReplaceAllString(rendered, " ")
+	text = html.UnescapeString(tagHTML.ReplaceAllString(text, " "))
+	text = strings.Join(strings.Fields(text), " ")
+
+	if runes := []rune(text); len(runes) > length {
+		text = strings.TrimSpace(string(runes[:length])) + "…"
+	}
+	return text
+}
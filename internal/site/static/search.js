@@ -0,0 +1,48 @@
+// Client-side search over search.json, matching every word of the query
+// against the title, tags and content of each note.
+(function () {
+  var input = document.getElementById("search");
+  var results = document.getElementById("results");
+  if (!input || !results) return;
+
+  var root = input.getAttribute("data-root") || "";
+  var index = null;
+
+  function load() {
+    if (index) return Promise.resolve(index);
+    return fetch(root + "search.json")
+      .then(function (response) { return response.json(); })
+      .then(function (notes) {
+        index = notes.map(function (note) {
+          note.haystack = [note.title, note.tags.join(" "), note.notebook, note.content].join("\n").toLowerCase();
+          return note;
+        });
+        return index;
+      });
+  }
+
+  function render(notes) {
+    results.innerHTML = "";
+    notes.slice(0, 50).forEach(function (note) {
+      var item = document.createElement("li");
+      var link = document.createElement("a");
+      link.href = root + note.url;
+      link.textContent = note.title;
+      item.appendChild(link);
+      results.appendChild(item);
+    });
+  }
+
+  input.addEventListener("input", function () {
+    var words = input.value.toLowerCase().split(/\s+/).filter(Boolean);
+    if (words.length === 0) {
+      results.innerHTML = "";
+      return;
+    }
+    load().then(function (notes) {
+      render(notes.filter(function (note) {
+        return words.every(function (word) { return note.haystack.indexOf(word) !== -1; });
+      }));
+    });
+  });
+})();
@@ -0,0 +1,82 @@
+package site
+
+import (
+	"sort"
+	"strings"
+)
+
+// notebookNode is a notebook in the notebook tree. Nested notebooks are
+// written as paths such as "Go/Concurrency".
+type notebookNode struct {
+	Name string
+	Path string
+	URL  string
+	// Root is the relative path to the site root from the page showing the
+	// tree, so that the shared "tree" template can build links
+	Root string
+	// Count is the number of notes in the notebook and its sub-notebooks
+	Count    int
+	Notes    []*noteView
+	Children []*notebookNode
+}
+
+// buildTree builds the notebook tree of the given notes. The root node has
+// no name and holds the top-level notebooks.
+func buildTree(views []*noteView) *notebookNode {
+	root := &notebookNode{}
+	for _, view := range views {
+		if view.Notebook == nil {
+			continue
+		}
+
+		node := root
+		node.Count++
+		parts := strings.Split(view.Notebook.Name, "/")
+		for i, name := range parts {
+			node = node.child(name, strings.Join(parts[:i+1], "/"))
+			node.Count++
+		}
+		node.Notes = append(node.Notes, view)
+	}
+	root.sort()
+	return root
+}
+
+// child returns the child with the given name, adding it if needed
+func (n *notebookNode) child(name, path string) *notebookNode {
+	for _, child := range n.Children {
+		if child.Name == name {
+			return child
+		}
+	}
+	child := &notebookNode{Name: name, Path: path, URL: notebookPath(path)}
+	n.Children = append(n.Children, child)
+	return child
+}
+
+func (n *notebookNode) sort() {
+	sort.Slice(n.Children, func(i, j int) bool {
+		return strings.ToLower(n.Children[i].Name) < strings.ToLower(n.Children[j].Name)
+	})
+	for _, child := range n.Children {
+		child.sort()
+	}
+}
+
+// size returns the number of nodes in the tree, including n
+func (n *notebookNode) size() int {
+	size := 1
+	for _, child := range n.Children {
+		size += child.size()
+	}
+	return size
+}
+
+// withRoot sets the root path used for links on every node and returns n
+func (n *notebookNode) withRoot(root string) *notebookNode {
+	n.Root = root
+	for _, child := range n.Children {
+		child.withRoot(root)
+	}
+	return n
+}
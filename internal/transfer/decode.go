@@ -0,0 +1,197 @@
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// RecordError is a record that could not be read or imported. Reading can
+// continue after it.
+type RecordError struct {
+	Number int
+	Err    error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("record %d: %v", e.Number, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// Decoder reads records one at a time. Next returns io.EOF at the end of the
+// input and a *RecordError for a single bad record; any other error means
+// the input cannot be read further.
+type Decoder interface {
+	Next() (*Record, error)
+}
+
+// NewDecoder returns a decoder reading records in the given format. Columns
+// or keys are mapped to fields with opts.Mapping; unknown ones are ignored.
+func NewDecoder(r io.Reader, format string, opts Options) (Decoder, error) {
+	if err := CheckFormat(format); err != nil {
+		return nil, err
+	}
+
+	fields := opts.Mapping.fields()
+	switch format {
+	case FormatCSV:
+		return newCSVDecoder(r, opts, fields)
+	case FormatJSON:
+		return newJSONDecoder(r, fields)
+	default:
+		return &jsonlDecoder{reader: bufio.NewReader(r), fields: fields}, nil
+	}
+}
+
+type csvDecoder struct {
+	reader  *csv.Reader
+	columns []string
+	count   int
+}
+
+func newCSVDecoder(r io.Reader, opts Options, fields map[string]string) (*csvDecoder, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+	if opts.Comma != 0 {
+		reader.Comma = opts.Comma
+	}
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	columns := make([]string, len(header))
+	hasTitle := false
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		columns[i] = fields[name]
+		if columns[i] == FieldTitle {
+			hasTitle = true
+		}
+	}
+	if !hasTitle {
+		return nil, fmt.Errorf("no %q column found (map one with title=<column>)", opts.Mapping.Column(FieldTitle))
+	}
+
+	return &csvDecoder{reader: reader, columns: columns}, nil
+}
+
+func (d *csvDecoder) Next() (*Record, error) {
+	row, err := d.reader.Read()
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	d.count++
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, &RecordError{Number: d.count, Err: err}
+		}
+		return nil, err
+	}
+
+	record := &Record{Number: d.count, Values: make(map[string]interface{})}
+	for i, value := range row {
+		if i < len(d.columns) && d.columns[i] != "" {
+			record.Values[d.columns[i]] = value
+		}
+	}
+	return record, nil
+}
+
+// jsonDecoder reads the objects of a JSON array one at a time
+type jsonDecoder struct {
+	decoder *json.Decoder
+	fields  map[string]string
+	count   int
+}
+
+func newJSONDecoder(r io.Reader, fields map[string]string) (*jsonDecoder, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, fmt.Errorf("expected a JSON array of notes")
+	}
+
+	return &jsonDecoder{decoder: decoder, fields: fields}, nil
+}
+
+func (d *jsonDecoder) Next() (*Record, error) {
+	if !d.decoder.More() {
+		return nil, io.EOF
+	}
+	d.count++
+
+	// Decoding into a RawMessage first keeps the stream in sync when an
+	// element is not an object
+	var raw json.RawMessage
+	if err := d.decoder.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("invalid JSON in record %d: %w", d.count, err)
+	}
+	return decodeObject(raw, d.count, d.fields)
+}
+
+// jsonlDecoder reads one JSON object per line
+type jsonlDecoder struct {
+	reader *bufio.Reader
+	fields map[string]string
+	count  int
+}
+
+func (d *jsonlDecoder) Next() (*Record, error) {
+	for {
+		line, err := d.reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			if err == io.EOF {
+				return nil, io.EOF
+			}
+			continue
+		}
+
+		d.count++
+		return decodeObject(line, d.count, d.fields)
+	}
+}
+
+// decodeObject converts a JSON object into a record
+func decodeObject(data []byte, number int, fields map[string]string) (*Record, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var object map[string]interface{}
+	if err := decoder.Decode(&object); err != nil || object == nil {
+		if err == nil {
+			err = fmt.Errorf("expected an object")
+		}
+		return nil, &RecordError{Number: number, Err: err}
+	}
+
+	record := &Record{Number: number, Values: make(map[string]interface{})}
+	for key, value := range object {
+		if field, ok := fields[key]; ok {
+			record.Values[field] = value
+		}
+	}
+	return record, nil
+}
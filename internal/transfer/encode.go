@@ -0,0 +1,149 @@
+package transfer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+)
+
+// Encoder writes notes one at a time
+type Encoder interface {
+	Encode(n *note.Note) error
+	// Close finishes the output, e.g. the closing bracket of a JSON array.
+	// It does not close the underlying writer.
+	Close() error
+}
+
+// NewEncoder returns an encoder writing notes in the given format
+func NewEncoder(w io.Writer, format string, opts Options) (Encoder, error) {
+	if err := CheckFormat(format); err != nil {
+		return nil, err
+	}
+	if len(opts.Fields) == 0 {
+		opts.Fields = AllFields
+	}
+
+	switch format {
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if opts.Comma != 0 {
+			writer.Comma = opts.Comma
+		}
+		return &csvEncoder{writer: writer, opts: opts}, nil
+	default:
+		return &jsonEncoder{writer: bufio.NewWriter(w), opts: opts, array: format == FormatJSON}, nil
+	}
+}
+
+// Export writes notes in the given format
+func Export(w io.Writer, notes []*note.Note, format string, opts Options) error {
+	encoder, err := NewEncoder(w, format, opts)
+	if err != nil {
+		return err
+	}
+	for _, n := range notes {
+		if err := encoder.Encode(n); err != nil {
+			return err
+		}
+	}
+	return encoder.Close()
+}
+
+type csvEncoder struct {
+	writer        *csv.Writer
+	opts          Options
+	headerWritten bool
+}
+
+func (e *csvEncoder) writeHeader() error {
+	header := make([]string, len(e.opts.Fields))
+	for i, field := range e.opts.Fields {
+		header[i] = e.opts.Mapping.Column(field)
+	}
+	e.headerWritten = true
+	return e.writer.Write(header)
+}
+
+func (e *csvEncoder) Encode(n *note.Note) error {
+	if !e.headerWritten {
+		if err := e.writeHeader(); err != nil {
+			return err
+		}
+	}
+
+	row := make([]string, len(e.opts.Fields))
+	for i, field := range e.opts.Fields {
+		row[i] = fieldText(n, field)
+	}
+	return e.writer.Write(row)
+}
+
+func (e *csvEncoder) Close() error {
+	// An export without notes still gets a header
+	if !e.headerWritten {
+		if err := e.writeHeader(); err != nil {
+			return err
+		}
+	}
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// jsonEncoder writes a JSON array or JSON Lines. Keys are written in field
+// order, which encoding/json does not do for maps.
+type jsonEncoder struct {
+	writer *bufio.Writer
+	opts   Options
+	array  bool
+	count  int
+}
+
+func (e *jsonEncoder) Encode(n *note.Note) error {
+	switch {
+	case !e.array:
+	case e.count == 0:
+		e.writer.WriteString("[\n  ")
+	default:
+		e.writer.WriteString(",\n  ")
+	}
+	e.count++
+
+	e.writer.WriteByte('{')
+	for i, field := range e.opts.Fields {
+		if i > 0 {
+			e.writer.WriteByte(',')
+		}
+		key, err := json.Marshal(e.opts.Mapping.Column(field))
+		if err != nil {
+			return err
+		}
+		value, err := json.Marshal(fieldValue(n, field))
+		if err != nil {
+			return fmt.Errorf("failed to encode %s of note %d: %w", field, n.ID, err)
+		}
+		e.writer.Write(key)
+		e.writer.WriteByte(':')
+		e.writer.Write(value)
+	}
+	e.writer.WriteByte('}')
+
+	if !e.array {
+		e.writer.WriteByte('\n')
+	}
+	return nil
+}
+
+func (e *jsonEncoder) Close() error {
+	if e.array {
+		if e.count == 0 {
+			e.writer.WriteString("[]\n")
+		} else {
+			e.writer.WriteString("\n]\n")
+		}
+	}
+	return e.writer.Flush()
+}
@@ -0,0 +1,423 @@
+// Package transfer reads and writes notes as CSV, JSON and JSON Lines, for
+// round trips through spreadsheets and scripts.
+package transfer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+)
+
+// Supported formats
+const (
+	FormatCSV   = "csv"
+	FormatJSON  = "json"
+	FormatJSONL = "jsonl"
+)
+
+// Note fields, named like their JSON keys
+const (
+	FieldID         = "id"
+	FieldTitle      = "title"
+	FieldContent    = "content"
+	FieldTags       = "tags"
+	FieldNotebook   = "notebook"
+	FieldCreatedAt  = "created_at"
+	FieldUpdatedAt  = "updated_at"
+	FieldIsArchived = "is_archived"
+	FieldIsFavorite = "is_favorite"
+	FieldIsPinned   = "is_pinned"
+	FieldPosition   = "position"
+	FieldRemindAt   = "remind_at"
+	FieldDueAt      = "due_at"
+	FieldLinks      = "links"
+	FieldProperties = "properties"
+)
+
+// AllFields lists every field in the default column order
+var AllFields = []string{
+	FieldID, FieldTitle, FieldContent, FieldTags, FieldNotebook,
+	FieldCreatedAt, FieldUpdatedAt, FieldIsArchived, FieldIsFavorite,
+	FieldIsPinned, FieldPosition, FieldRemindAt, FieldDueAt, FieldLinks,
+	FieldProperties,
+}
+
+// Options control how notes are encoded and decoded
+type Options struct {
+	// Fields are the fields to export, in order. Defaults to AllFields.
+	Fields []string
+	// Mapping renames fields to the columns or keys used in the file
+	Mapping Mapping
+	// Comma is the CSV delimiter, ',' by default
+	Comma rune
+}
+
+// Mapping maps note fields to column or key names
+type Mapping map[string]string
+
+// ParseMapping parses "field=column" pairs
+func ParseMapping(pairs []string) (Mapping, error) {
+	mapping := make(Mapping)
+	for _, pair := range pairs {
+		field, column, ok := strings.Cut(pair, "=")
+		field = strings.TrimSpace(field)
+		column = strings.TrimSpace(column)
+		if !ok || column == "" {
+			return nil, fmt.Errorf("invalid mapping %q (use field=column)", pair)
+		}
+		if !isField(field) {
+			return nil, fmt.Errorf("unknown field %q (fields: %s)", field, strings.Join(AllFields, ", "))
+		}
+		mapping[field] = column
+	}
+	return mapping, nil
+}
+
+// Column returns the column name of a field
+func (m Mapping) Column(field string) string {
+	if column, ok := m[field]; ok {
+		return column
+	}
+	return field
+}
+
+// fields returns the column names of all fields, keyed by column
+func (m Mapping) fields() map[string]string {
+	fields := make(map[string]string)
+	for _, field := range AllFields {
+		fields[m.Column(field)] = field
+	}
+	return fields
+}
+
+// ParseFields parses a comma separated list of fields
+func ParseFields(list string) ([]string, error) {
+	var fields []string
+	for _, field := range strings.Split(list, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if !isField(field) {
+			return nil, fmt.Errorf("unknown field %q (fields: %s)", field, strings.Join(AllFields, ", "))
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// CheckFormat returns an error for unsupported formats
+func CheckFormat(format string) error {
+	switch format {
+	case FormatCSV, FormatJSON, FormatJSONL:
+		return nil
+	default:
+		return fmt.Errorf("unsupported format %q (use %s, %s or %s)", format, FormatCSV, FormatJSON, FormatJSONL)
+	}
+}
+
+func isField(name string) bool {
+	for _, field := range AllFields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldValue returns the value of a field as JSON would encode it
+func fieldValue(n *note.Note, field string) interface{} {
+	switch field {
+	case FieldID:
+		return n.ID
+	case FieldTitle:
+		return n.Title
+	case FieldContent:
+		return n.Content
+	case FieldTags:
+		if n.Tags == nil {
+			return []string{}
+		}
+		return n.Tags
+	case FieldNotebook:
+		return n.Notebook
+	case FieldCreatedAt:
+		return n.CreatedAt
+	case FieldUpdatedAt:
+		return n.UpdatedAt
+	case FieldIsArchived:
+		return n.IsArchived
+	case FieldIsFavorite:
+		return n.IsFavorite
+	case FieldIsPinned:
+		return n.IsPinned
+	case FieldPosition:
+		return n.Position
+	case FieldRemindAt:
+		return n.RemindAt
+	case FieldDueAt:
+		return n.DueAt
+	case FieldLinks:
+		if n.Links == nil {
+			return []int{}
+		}
+		return n.Links
+	case FieldProperties:
+		return n.Properties
+	}
+	return nil
+}
+
+// fieldText returns the value of a field as a CSV cell. Lists are comma
+// separated, times use RFC 3339 and properties are a JSON object.
+func fieldText(n *note.Note, field string) string {
+	switch v := fieldValue(n, field).(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	case []string:
+		return strings.Join(v, ",")
+	case []int:
+		ids := make([]string, len(v))
+		for i, id := range v {
+			ids[i] = strconv.Itoa(id)
+		}
+		return strings.Join(ids, ",")
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return ""
+		}
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+	return ""
+}
+
+// Record is a note read from a file, as field values before conversion. CSV
+// values are strings; JSON values are whatever the file contained.
+type Record struct {
+	// Number is the position of the record in the file, starting at 1
+	Number int
+	Values map[string]interface{}
+}
+
+// ID returns the ID of the record, or 0 if it has none
+func (r *Record) ID() (int, error) {
+	value, ok := r.Values[FieldID]
+	if !ok {
+		return 0, nil
+	}
+	return toInt(value)
+}
+
+// Apply sets the fields present in the record on a note
+func (r *Record) Apply(n *note.Note) error {
+	for field, value := range r.Values {
+		if err := applyField(n, field, value); err != nil {
+			return fmt.Errorf("invalid %s: %w", field, err)
+		}
+	}
+	return nil
+}
+
+// applyField converts a value and sets it on a note
+func applyField(n *note.Note, field string, value interface{}) error {
+	var err error
+	switch field {
+	case FieldTitle:
+		n.Title, err = toString(value)
+	case FieldContent:
+		n.Content, err = toString(value)
+	case FieldNotebook:
+		n.Notebook, err = toString(value)
+	case FieldTags:
+		var tags []string
+		tags, err = toStrings(value)
+		n.Tags = nil
+		for _, tag := range tags {
+			n.AddTag(tag)
+		}
+	case FieldCreatedAt:
+		n.CreatedAt, err = toTime(value)
+	case FieldUpdatedAt:
+		n.UpdatedAt, err = toTime(value)
+	case FieldIsArchived:
+		n.IsArchived, err = toBool(value)
+	case FieldIsFavorite:
+		n.IsFavorite, err = toBool(value)
+	case FieldIsPinned:
+		n.IsPinned, err = toBool(value)
+	case FieldPosition:
+		n.Position, err = toInt(value)
+	case FieldRemindAt:
+		n.RemindAt, err = toOptionalTime(value)
+	case FieldDueAt:
+		n.DueAt, err = toOptionalTime(value)
+	case FieldLinks:
+		var ids []string
+		ids, err = toStrings(value)
+		n.Links = nil
+		for _, id := range ids {
+			var target int
+			if target, err = strconv.Atoi(id); err != nil {
+				return err
+			}
+			n.AddLink(target)
+		}
+	case FieldProperties:
+		n.Properties, err = toProperties(value)
+	}
+	return err
+}
+
+func toString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	}
+	return "", fmt.Errorf("expected a string, got %v", value)
+}
+
+func toInt(value interface{}) (int, error) {
+	switch v := value.(type) {
+	case nil:
+		return 0, nil
+	case json.Number:
+		return strconv.Atoi(v.String())
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return 0, nil
+		}
+		return strconv.Atoi(strings.TrimSpace(v))
+	}
+	return 0, fmt.Errorf("expected a number, got %v", value)
+}
+
+func toBool(value interface{}) (bool, error) {
+	switch v := value.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return v, nil
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "", "false", "no", "0":
+			return false, nil
+		case "true", "yes", "1", "x":
+			return true, nil
+		}
+	}
+	return false, fmt.Errorf("expected true or false, got %v", value)
+}
+
+// toStrings accepts a list or a comma separated string
+func toStrings(value interface{}) ([]string, error) {
+	var list []string
+	switch v := value.(type) {
+	case nil:
+	case string:
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				list = append(list, part)
+			}
+		}
+	case []interface{}:
+		for _, element := range v {
+			s, err := toString(element)
+			if err != nil {
+				return nil, err
+			}
+			if s = strings.TrimSpace(s); s != "" {
+				list = append(list, s)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("expected a list, got %v", value)
+	}
+	return list, nil
+}
+
+// toProperties accepts an object or, in CSV cells, its JSON text
+func toProperties(value interface{}) (map[string]interface{}, error) {
+	if s, ok := value.(string); ok {
+		if strings.TrimSpace(s) == "" {
+			return nil, nil
+		}
+		decoder := json.NewDecoder(strings.NewReader(s))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil {
+			return nil, fmt.Errorf("expected a JSON object, got %q", s)
+		}
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		properties := make(map[string]interface{}, len(v))
+		for name, element := range v {
+			if number, ok := element.(json.Number); ok {
+				f, err := number.Float64()
+				if err != nil {
+					return nil, err
+				}
+				element = f
+			}
+			properties[name] = element
+		}
+		return properties, nil
+	}
+	return nil, fmt.Errorf("expected an object, got %v", value)
+}
+
+// timeLayouts are the accepted time formats, RFC 3339 first
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+func toTime(value interface{}) (time.Time, error) {
+	t, err := toOptionalTime(value)
+	if err != nil || t == nil {
+		return time.Time{}, err
+	}
+	return *t, nil
+}
+
+func toOptionalTime(value interface{}) (*time.Time, error) {
+	s, err := toString(value)
+	if err != nil {
+		return nil, err
+	}
+	if s = strings.TrimSpace(s); s == "" {
+		return nil, nil
+	}
+
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("unrecognized time %q", s)
+}
@@ -0,0 +1,159 @@
+package transfer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+)
+
+// What to do with a record whose ID belongs to an existing note
+const (
+	// ConflictSkip keeps the existing note and ignores the record
+	ConflictSkip = "skip"
+	// ConflictOverwrite replaces the fields of the existing note with the
+	// fields present in the record
+	ConflictOverwrite = "overwrite"
+	// ConflictRenumber imports the record as a new note with a new ID
+	ConflictRenumber = "renumber"
+)
+
+// CheckConflict returns an error for unknown conflict strategies
+func CheckConflict(conflict string) error {
+	switch conflict {
+	case ConflictSkip, ConflictOverwrite, ConflictRenumber:
+		return nil
+	default:
+		return fmt.Errorf("unknown conflict strategy %q (use %s, %s or %s)", conflict, ConflictSkip, ConflictOverwrite, ConflictRenumber)
+	}
+}
+
+// Result reports the outcome of an import
+type Result struct {
+	// Created counts new notes, including those that kept the ID of the file
+	Created     int `json:"created"`
+	Overwritten int `json:"overwritten"`
+	Renumbered  int `json:"renumbered"`
+	Skipped     int `json:"skipped"`
+	// Errors are the records that could not be imported
+	Errors []*RecordError `json:"-"`
+}
+
+// Progress is called after every record with the number of records handled
+type Progress func(done int)
+
+// Import reads every record from the decoder and writes it to storage as it
+// goes, so the input never has to fit in memory. Records without an ID, or
+// whose ID is free, are created with that ID; records whose ID is taken are
+// handled according to conflict. Bad records are collected in the result;
+// the returned error is only set if the input could not be read to the end.
+// The import is reported to change hooks as one change.
+func Import(storage *note.Storage, decoder Decoder, conflict string, progress Progress) (Result, error) {
+	var result Result
+	if err := CheckConflict(conflict); err != nil {
+		return result, err
+	}
+
+	err := storage.Group(func() error {
+		var err error
+		result, err = importRecords(storage, decoder, conflict, progress)
+		return err
+	})
+	return result, err
+}
+
+// importRecords writes the records of the decoder until its end
+func importRecords(storage *note.Storage, decoder Decoder, conflict string, progress Progress) (Result, error) {
+	var result Result
+
+	for done := 1; ; done++ {
+		record, err := decoder.Next()
+		if err == io.EOF {
+			return result, nil
+		}
+
+		var recordErr *RecordError
+		switch {
+		case errors.As(err, &recordErr):
+			result.Errors = append(result.Errors, recordErr)
+		case err != nil:
+			return result, err
+		default:
+			if err := importRecord(storage, record, conflict, &result); err != nil {
+				result.Errors = append(result.Errors, &RecordError{Number: record.Number, Err: err})
+			}
+		}
+
+		if progress != nil {
+			progress(done)
+		}
+	}
+}
+
+// importRecord writes a single record
+func importRecord(storage *note.Storage, record *Record, conflict string, result *Result) error {
+	id, err := record.ID()
+	if err != nil {
+		return fmt.Errorf("invalid id: %w", err)
+	}
+
+	var existing *note.Note
+	if id > 0 {
+		existing, _ = storage.GetNote(id)
+	}
+
+	if existing != nil && conflict == ConflictSkip {
+		result.Skipped++
+		return nil
+	}
+
+	if existing != nil && conflict == ConflictOverwrite {
+		n := existing.Clone()
+		if err := record.Apply(n); err != nil {
+			return err
+		}
+		if _, ok := record.Values[FieldUpdatedAt]; !ok {
+			n.UpdatedAt = time.Now()
+		}
+		if _, err := storage.PutNote(n); err != nil {
+			return err
+		}
+		result.Overwritten++
+		return nil
+	}
+
+	n := &note.Note{}
+	if err := record.Apply(n); err != nil {
+		return err
+	}
+	n.Title = strings.TrimSpace(n.Title)
+	n.Content = strings.TrimSpace(n.Content)
+
+	if existing != nil || id <= 0 {
+		if _, err := storage.ImportNote(n); err != nil {
+			return err
+		}
+		if existing != nil {
+			result.Renumbered++
+		} else {
+			result.Created++
+		}
+		return nil
+	}
+
+	n.ID = id
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = time.Now()
+	}
+	if n.UpdatedAt.IsZero() {
+		n.UpdatedAt = n.CreatedAt
+	}
+	if _, err := storage.PutNote(n); err != nil {
+		return err
+	}
+	result.Created++
+	return nil
+}
@@ -0,0 +1,271 @@
+package transfer
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+)
+
+func TestCSVDecoder(t *testing.T) {
+	input := "\ufeffid, Name ,content,tags,is_favorite,extra\n" +
+		"7,Go Slices,\"Dynamic arrays,\nwith a line break\",\"go, slices\",yes,ignored\n" +
+		",Short row\n" +
+		"8,\"Bad \"quote\",x\n" +
+		"9,Last,,,,\n"
+
+	decoder, err := NewDecoder(strings.NewReader(input), FormatCSV, Options{Mapping: Mapping{FieldTitle: "Name"}})
+	if err != nil {
+		t.Fatalf("NewDecoder() error = %v", err)
+	}
+
+	want := []map[string]interface{}{
+		{FieldID: "7", FieldTitle: "Go Slices", FieldContent: "Dynamic arrays,\nwith a line break", FieldTags: "go, slices", FieldIsFavorite: "yes"},
+		{FieldID: "", FieldTitle: "Short row"},
+		nil,
+		{FieldID: "9", FieldTitle: "Last", FieldContent: "", FieldTags: "", FieldIsFavorite: ""},
+	}
+	for i, values := range want {
+		record, err := decoder.Next()
+		if values == nil {
+			var recordErr *RecordError
+			if !errors.As(err, &recordErr) || recordErr.Number != i+1 {
+				t.Fatalf("record %d: Next() error = %v, want a RecordError", i+1, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("record %d: Next() error = %v", i+1, err)
+		}
+		if record.Number != i+1 {
+			t.Errorf("record %d: Number = %d", i+1, record.Number)
+		}
+		if !reflect.DeepEqual(record.Values, values) {
+			t.Errorf("record %d: Values = %v, want %v", i+1, record.Values, values)
+		}
+	}
+
+	if _, err := decoder.Next(); err != io.EOF {
+		t.Errorf("Next() at the end = %v, want io.EOF", err)
+	}
+}
+
+func TestCSVDecoderHeader(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  Options
+	}{
+		{name: "empty", input: ""},
+		{name: "no title column", input: "id,content\n1,x\n"},
+		{name: "unmapped title column", input: "Name,content\nA,x\n"},
+		{name: "other delimiter", input: "title;content\nA;x\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewDecoder(strings.NewReader(tt.input), FormatCSV, tt.opts); err == nil {
+				t.Error("NewDecoder() = nil error, want an error")
+			}
+		})
+	}
+
+	decoder, err := NewDecoder(strings.NewReader("title;content\nA;x\n"), FormatCSV, Options{Comma: ';'})
+	if err != nil {
+		t.Fatalf("NewDecoder() with ';' error = %v", err)
+	}
+	record, err := decoder.Next()
+	if err != nil || record.Values[FieldContent] != "x" {
+		t.Errorf("Next() = %v, %v, want content x", record, err)
+	}
+}
+
+func TestJSONDecoder(t *testing.T) {
+	tests := []struct {
+		format string
+		input  string
+	}{
+		{
+			format: FormatJSON,
+			input:  `[{"id": 7, "title": "Go", "tags": ["go"], "extra": true}, "not an object", {"title": "Last"}]`,
+		},
+		{
+			format: FormatJSONL,
+			input:  "{\"id\": 7, \"title\": \"Go\", \"tags\": [\"go\"], \"extra\": true}\n[1]\n\n{\"title\": \"Last\"}",
+		},
+	}
+
+	want := []map[string]interface{}{
+		{FieldID: json.Number("7"), FieldTitle: "Go", FieldTags: []interface{}{"go"}},
+		nil,
+		{FieldTitle: "Last"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			decoder, err := NewDecoder(strings.NewReader(tt.input), tt.format, Options{})
+			if err != nil {
+				t.Fatalf("NewDecoder() error = %v", err)
+			}
+			for i, values := range want {
+				record, err := decoder.Next()
+				if values == nil {
+					var recordErr *RecordError
+					if !errors.As(err, &recordErr) || recordErr.Number != i+1 {
+						t.Fatalf("record %d: Next() error = %v, want a RecordError", i+1, err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("record %d: Next() error = %v", i+1, err)
+				}
+				if !reflect.DeepEqual(record.Values, values) {
+					t.Errorf("record %d: Values = %v, want %v", i+1, record.Values, values)
+				}
+			}
+			if _, err := decoder.Next(); err != io.EOF {
+				t.Errorf("Next() at the end = %v, want io.EOF", err)
+			}
+		})
+	}
+
+	if _, err := NewDecoder(strings.NewReader(`{"title": "A"}`), FormatJSON, Options{}); err == nil {
+		t.Error("NewDecoder() of a JSON object = nil error, want an error")
+	}
+	if _, err := NewDecoder(strings.NewReader(""), "xml", Options{}); err == nil {
+		t.Error("NewDecoder() with an unknown format = nil error, want an error")
+	}
+}
+
+func TestImport(t *testing.T) {
+	tests := []struct {
+		conflict string
+		// want are the titles of notes 1 to 4 after the import
+		want   []string
+		result Result
+	}{
+		{
+			conflict: ConflictSkip,
+			want:     []string{"Existing", "", "From file", "No ID"},
+			result:   Result{Created: 2, Skipped: 1},
+		},
+		{
+			conflict: ConflictOverwrite,
+			want:     []string{"Replaced", "", "From file", "No ID"},
+			result:   Result{Created: 2, Overwritten: 1},
+		},
+		{
+			conflict: ConflictRenumber,
+			want:     []string{"Existing", "Replaced", "From file", "No ID"},
+			result:   Result{Created: 2, Renumbered: 1},
+		},
+	}
+
+	input := "id,title,content,tags,is_pinned,created_at\n" +
+		"1,Replaced,New content,new,true,\n" +
+		"3,From file,Content,\"a, b\",,2024-01-02T03:04:05Z\n" +
+		",No ID,Content,,,\n" +
+		"5,Bad flag,Content,,maybe,\n" +
+		"x,Bad ID,Content,,,\n"
+
+	for _, tt := range tests {
+		t.Run(tt.conflict, func(t *testing.T) {
+			storage, err := note.NewStorage(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := storage.CreateNote("Existing", "Old content", []string{"old"}); err != nil {
+				t.Fatal(err)
+			}
+
+			var changes []note.Change
+			storage.OnChange(func(change note.Change) {
+				changes = append(changes, change)
+			})
+
+			decoder, err := NewDecoder(strings.NewReader(input), FormatCSV, Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var progress []int
+			result, err := Import(storage, decoder, tt.conflict, func(done int) {
+				progress = append(progress, done)
+			})
+			if err != nil {
+				t.Fatalf("Import() error = %v", err)
+			}
+
+			var failed []int
+			for _, recordErr := range result.Errors {
+				failed = append(failed, recordErr.Number)
+			}
+			if !reflect.DeepEqual(failed, []int{4, 5}) {
+				t.Errorf("failed records = %v, want [4 5]", failed)
+			}
+			result.Errors = nil
+			if !reflect.DeepEqual(result, tt.result) {
+				t.Errorf("Import() = %+v, want %+v", result, tt.result)
+			}
+			if !reflect.DeepEqual(progress, []int{1, 2, 3, 4, 5}) {
+				t.Errorf("progress = %v, want [1 2 3 4 5]", progress)
+			}
+			if len(changes) != 1 || !changes[0].Grouped {
+				t.Errorf("Import() notified %d changes, want one grouped change", len(changes))
+			}
+
+			for i, title := range tt.want {
+				n, err := storage.GetNote(i + 1)
+				if title == "" {
+					if err == nil {
+						t.Errorf("note %d = %q, want no note", i+1, n.Title)
+					}
+					continue
+				}
+				if err != nil {
+					t.Errorf("note %d: %v", i+1, err)
+					continue
+				}
+				if n.Title != title {
+					t.Errorf("note %d title = %q, want %q", i+1, n.Title, title)
+				}
+			}
+
+			// Fields missing from the file keep their value when overwriting
+			first, _ := storage.GetNote(1)
+			if tt.conflict == ConflictOverwrite && (!first.IsPinned || !reflect.DeepEqual(first.Tags, []string{"new"})) {
+				t.Errorf("overwritten note = pinned %v, tags %v, want pinned with tag new", first.IsPinned, first.Tags)
+			}
+
+			fromFile, _ := storage.GetNote(3)
+			created := time.Date(2024, time.January, 2, 3, 4, 5, 0, time.UTC)
+			if !fromFile.CreatedAt.Equal(created) || !fromFile.UpdatedAt.Equal(created) {
+				t.Errorf("note 3 dates = %v, %v, want %v", fromFile.CreatedAt, fromFile.UpdatedAt, created)
+			}
+			if !reflect.DeepEqual(fromFile.Tags, []string{"a", "b"}) {
+				t.Errorf("note 3 tags = %v, want [a b]", fromFile.Tags)
+			}
+		})
+	}
+}
+
+func TestImportUnknownConflict(t *testing.T) {
+	storage, err := note.NewStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoder, err := NewDecoder(strings.NewReader("title\nA\n"), FormatCSV, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Import(storage, decoder, "merge", nil); err == nil {
+		t.Error("Import() = nil error for an unknown conflict strategy")
+	}
+	if notes := storage.GetAllNotes(); len(notes) != 0 {
+		t.Errorf("Import() created %d notes, want none", len(notes))
+	}
+}
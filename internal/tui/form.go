@@ -0,0 +1,147 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+)
+
+// Fields of the note form, in tab order
+const (
+	fieldTitle = iota
+	fieldTags
+	fieldContent
+	fieldCount
+)
+
+// form creates a note or edits the title, tags and content of one
+type form struct {
+	// id is the note being edited, 0 for a new note
+	id      int
+	title   textinput.Model
+	tags    textinput.Model
+	content textarea.Model
+	field   int
+	width   int
+}
+
+func newForm(n *note.Note, width, height int) form {
+	f := form{
+		title:   textinput.New(),
+		tags:    textinput.New(),
+		content: textarea.New(),
+	}
+	f.title.Prompt = "Title: "
+	f.tags.Prompt = "Tags:  "
+	f.tags.Placeholder = "go, concurrency"
+	f.content.Placeholder = "Write your note..."
+	f.content.ShowLineNumbers = false
+	f.content.CharLimit = 0
+
+	if n != nil {
+		f.id = n.ID
+		f.title.SetValue(n.Title)
+		f.tags.SetValue(strings.Join(n.Tags, ", "))
+		f.content.SetValue(n.Content)
+	}
+
+	f.resize(width, height)
+	return f
+}
+
+func (f *form) resize(width, height int) {
+	f.width = width
+	f.title.Width = max(width-len(f.title.Prompt)-2, 10)
+	f.tags.Width = max(width-len(f.tags.Prompt)-2, 10)
+	f.content.SetWidth(max(width-2, 10))
+	// The heading, the two inputs and a blank line above the content
+	f.content.SetHeight(max(height-4, 3))
+}
+
+// focusField focuses the current field and blurs the others
+func (f *form) focusField() tea.Cmd {
+	f.title.Blur()
+	f.tags.Blur()
+	f.content.Blur()
+
+	switch f.field {
+	case fieldTitle:
+		return f.title.Focus()
+	case fieldTags:
+		return f.tags.Focus()
+	}
+	return f.content.Focus()
+}
+
+func (f form) update(msg tea.Msg) (form, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "tab":
+			f.field = (f.field + 1) % fieldCount
+			return f, f.focusField()
+		case "shift+tab":
+			f.field = (f.field + fieldCount - 1) % fieldCount
+			return f, f.focusField()
+		case "enter":
+			if f.field != fieldContent {
+				f.field++
+				return f, f.focusField()
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+	switch f.field {
+	case fieldTitle:
+		f.title, cmd = f.title.Update(msg)
+	case fieldTags:
+		f.tags, cmd = f.tags.Update(msg)
+	default:
+		f.content, cmd = f.content.Update(msg)
+	}
+	return f, cmd
+}
+
+// save creates or updates the note with the values of the form
+func (f form) save(storage *note.Storage) (*note.Note, error) {
+	var tags []string
+	for _, tag := range strings.Split(f.tags.Value(), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	if f.id == 0 {
+		created, err := storage.CreateNote(f.title.Value(), f.content.Value(), tags)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create note: %w", err)
+		}
+		return created, nil
+	}
+
+	updated, err := storage.UpdateNote(f.id, f.title.Value(), f.content.Value(), tags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update note: %w", err)
+	}
+	return updated, nil
+}
+
+func (f form) view() string {
+	heading := "New note"
+	if f.id != 0 {
+		heading = fmt.Sprintf("Edit note %d", f.id)
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		titleStyle.Render(heading),
+		f.title.View(),
+		f.tags.View(),
+		"",
+		f.content.View(),
+	)
+}
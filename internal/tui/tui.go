@@ -0,0 +1,719 @@
+// Package tui implements the full-screen terminal interface of gonotes: a tag
+// sidebar, the note list and a preview of the selected note, with incremental
+// search and shortcuts for the common note operations.
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/midimurphdesigns/go-lang-notes/internal/note"
+)
+
+// Options configure the terminal interface
+type Options struct {
+	// Refresh is how often the notes directory is checked for changes made
+	// by other processes, such as the CLI or the web server; 0 turns live
+	// refresh off
+	Refresh time.Duration
+}
+
+// Run shows the terminal interface until the user quits
+func Run(storage *note.Storage, opts Options) error {
+	program := tea.NewProgram(newModel(storage, opts), tea.WithAltScreen())
+	_, err := program.Run()
+	return err
+}
+
+// mode is what the keyboard currently controls
+type mode int
+
+const (
+	modeBrowse mode = iota
+	modeSearch
+	modeForm
+	modeConfirm
+)
+
+// pane is a part of the browse screen that can have the focus
+type pane int
+
+const (
+	paneTags pane = iota
+	paneList
+	panePreview
+	paneCount
+)
+
+// tagsWidth is the width of the tag sidebar, borders included
+const tagsWidth = 24
+
+// refreshMsg asks to check the notes directory for changes
+type refreshMsg struct{}
+
+// tagCount is a tag of the sidebar with the number of notes having it
+type tagCount struct {
+	name  string
+	count int
+}
+
+type model struct {
+	storage *note.Storage
+	opts    Options
+
+	width, height int
+	mode          mode
+	focus         pane
+	help          bool
+
+	// notes are the notes shown in the list, after the tag and search filters
+	notes  []*note.Note
+	cursor int
+	offset int
+
+	// tags lists the tags of the shown notes; the sidebar shows "All notes"
+	// before them, so tagCursor 0 clears the tag filter
+	tags      []tagCount
+	tagCursor int
+	tagOffset int
+	tag       string
+
+	showArchived bool
+	search       textinput.Model
+	query        *note.Query
+	preview      viewport.Model
+	form         form
+
+	status    string
+	statusErr bool
+	// stamp identifies the state of the notes directory last loaded
+	stamp string
+}
+
+func newModel(storage *note.Storage, opts Options) model {
+	search := textinput.New()
+	search.Prompt = "/ "
+	search.Placeholder = "search, e.g. channels tag:go -is:pinned"
+
+	m := model{
+		storage: storage,
+		opts:    opts,
+		focus:   paneList,
+		search:  search,
+		preview: viewport.New(0, 0),
+		stamp:   storage.Stamp(),
+	}
+	m.refresh()
+	return m
+}
+
+func (m model) Init() tea.Cmd {
+	return m.tick()
+}
+
+// tick schedules the next check for changes made by other processes
+func (m model) tick() tea.Cmd {
+	if m.opts.Refresh <= 0 {
+		return nil
+	}
+	return tea.Tick(m.opts.Refresh, func(time.Time) tea.Msg {
+		return refreshMsg{}
+	})
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.resize()
+		return m, nil
+
+	case refreshMsg:
+		if stamp := m.storage.Stamp(); stamp != m.stamp {
+			m.stamp = stamp
+			if err := m.storage.Reload(); err != nil {
+				m.setError(fmt.Errorf("failed to reload notes: %w", err))
+			}
+			m.refresh()
+		}
+		return m, m.tick()
+
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+
+		switch m.mode {
+		case modeSearch:
+			return m.updateSearch(msg)
+		case modeForm:
+			return m.updateForm(msg)
+		case modeConfirm:
+			return m.updateConfirm(msg)
+		}
+		return m.updateBrowse(msg)
+	}
+
+	if m.mode == modeForm {
+		var cmd tea.Cmd
+		m.form, cmd = m.form.update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m model) updateBrowse(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.status = ""
+
+	switch msg.String() {
+	case "q":
+		return m, tea.Quit
+	case "?":
+		m.help = !m.help
+	case "tab":
+		m.focus = (m.focus + 1) % paneCount
+	case "shift+tab":
+		m.focus = (m.focus + paneCount - 1) % paneCount
+	case "/":
+		m.mode = modeSearch
+		return m, m.search.Focus()
+	case "esc":
+		m.search.SetValue("")
+		m.query = nil
+		m.tag = ""
+		m.tagCursor = 0
+		m.refresh()
+	case "up", "k":
+		m.move(-1)
+	case "down", "j":
+		m.move(1)
+	case "pgup", "ctrl+u":
+		m.move(-m.listHeight() / 2)
+	case "pgdown", "ctrl+d":
+		m.move(m.listHeight() / 2)
+	case "home", "g":
+		m.move(-len(m.notes) - len(m.tags) - m.preview.TotalLineCount())
+	case "end", "G":
+		m.move(len(m.notes) + len(m.tags) + m.preview.TotalLineCount())
+	case "enter", " ":
+		switch m.focus {
+		case paneTags:
+			m.selectTag()
+		case paneList:
+			m.focus = panePreview
+		}
+	case "A":
+		m.showArchived = !m.showArchived
+		m.refresh()
+		if m.showArchived {
+			m.setStatus("Showing archived notes")
+		} else {
+			m.setStatus("Hiding archived notes")
+		}
+	case "r":
+		if err := m.storage.Reload(); err != nil {
+			m.setError(fmt.Errorf("failed to reload notes: %w", err))
+		}
+		m.stamp = m.storage.Stamp()
+		m.refresh()
+	case "n":
+		m.mode = modeForm
+		m.form = newForm(nil, m.width, m.formHeight())
+		return m, m.form.focusField()
+	case "e":
+		if n := m.selected(); n != nil {
+			m.mode = modeForm
+			m.form = newForm(n, m.width, m.formHeight())
+			return m, m.form.focusField()
+		}
+	case "a":
+		m.change(func(n *note.Note) (*note.Note, error) {
+			return m.storage.ArchiveNote(n.ID, !n.IsArchived)
+		}, func(n *note.Note) string {
+			if n.IsArchived {
+				return "📦 Archived '%s'"
+			}
+			return "📤 Unarchived '%s'"
+		})
+	case "f":
+		m.change(func(n *note.Note) (*note.Note, error) {
+			return m.storage.FavoriteNote(n.ID, !n.IsFavorite)
+		}, func(n *note.Note) string {
+			if n.IsFavorite {
+				return "⭐ Added '%s' to favorites"
+			}
+			return "Removed '%s' from favorites"
+		})
+	case "p":
+		m.change(func(n *note.Note) (*note.Note, error) {
+			return m.storage.PinNote(n.ID, !n.IsPinned)
+		}, func(n *note.Note) string {
+			if n.IsPinned {
+				return "📌 Pinned '%s'"
+			}
+			return "Unpinned '%s'"
+		})
+	case "d":
+		if m.selected() != nil {
+			m.mode = modeConfirm
+		}
+	}
+	return m, nil
+}
+
+func (m model) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.search.SetValue("")
+		m.query = nil
+		fallthrough
+	case "enter":
+		m.mode = modeBrowse
+		m.search.Blur()
+		m.refresh()
+		return m, nil
+	}
+
+	previous := m.search.Value()
+	var cmd tea.Cmd
+	m.search, cmd = m.search.Update(msg)
+	if m.search.Value() == previous {
+		return m, cmd
+	}
+
+	// Incomplete filters such as "is:" are reported while typing; the list
+	// keeps the results of the last valid query meanwhile
+	query, err := note.ParseQuery(m.search.Value())
+	if err != nil {
+		m.setError(err)
+		return m, cmd
+	}
+	m.status = ""
+	m.query = query
+	m.cursor, m.offset = 0, 0
+	m.refresh()
+	return m, cmd
+}
+
+func (m model) updateConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.mode = modeBrowse
+	n := m.selected()
+	if n == nil || (msg.String() != "y" && msg.String() != "Y") {
+		m.setStatus("Delete cancelled")
+		return m, nil
+	}
+
+	if err := m.storage.DeleteNote(n.ID); err != nil {
+		m.setError(fmt.Errorf("failed to delete note: %w", err))
+	} else {
+		m.setStatus(fmt.Sprintf("🗑️  Deleted '%s'", n.Title))
+	}
+	m.stamp = m.storage.Stamp()
+	m.refresh()
+	return m, nil
+}
+
+func (m model) updateForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = modeBrowse
+		m.setStatus("Discarded changes")
+		return m, nil
+	case "ctrl+s":
+		saved, err := m.form.save(m.storage)
+		if err != nil {
+			m.setError(err)
+			return m, nil
+		}
+		m.mode = modeBrowse
+		m.stamp = m.storage.Stamp()
+		m.refresh()
+		m.selectNote(saved.ID)
+		m.setStatus(fmt.Sprintf("✅ Saved '%s'", saved.Title))
+		return m, nil
+	}
+
+	m.status = ""
+	var cmd tea.Cmd
+	m.form, cmd = m.form.update(msg)
+	return m, cmd
+}
+
+// change applies fn to the selected note and reports the result with the
+// message returned by describe, a format taking the note title
+func (m *model) change(fn func(n *note.Note) (*note.Note, error), describe func(n *note.Note) string) {
+	n := m.selected()
+	if n == nil {
+		return
+	}
+
+	changed, err := fn(n)
+	if err != nil {
+		m.setError(err)
+		return
+	}
+	m.stamp = m.storage.Stamp()
+	m.refresh()
+	m.setStatus(fmt.Sprintf(describe(changed), changed.Title))
+}
+
+// refresh reloads the shown notes and tags from the storage, keeping the
+// selected note selected if it is still shown
+func (m *model) refresh() {
+	selected := 0
+	if n := m.selected(); n != nil {
+		selected = n.ID
+	}
+
+	notes := m.storage.GetActiveNotes()
+	if m.showArchived {
+		notes = m.storage.GetAllNotes()
+	}
+
+	// The sidebar counts tags before the tag and search filters, so every
+	// tag stays reachable
+	counts := make(map[string]int)
+	for _, n := range notes {
+		for _, tag := range n.Tags {
+			counts[tag]++
+		}
+	}
+	m.tags = m.tags[:0]
+	for name, count := range counts {
+		m.tags = append(m.tags, tagCount{name: name, count: count})
+	}
+	sort.Slice(m.tags, func(i, j int) bool { return m.tags[i].name < m.tags[j].name })
+	m.tagCursor = min(m.tagCursor, len(m.tags))
+
+	m.notes = m.notes[:0]
+	for _, n := range notes {
+		if m.tag != "" && !hasTag(n, m.tag) {
+			continue
+		}
+		if m.query != nil && !m.query.Match(n) {
+			continue
+		}
+		m.notes = append(m.notes, n)
+	}
+
+	m.cursor = min(m.cursor, max(len(m.notes)-1, 0))
+	m.selectNote(selected)
+}
+
+// hasTag reports whether a note has tag or one of its nested tags, like the
+// tag: filter of queries
+func hasTag(n *note.Note, tag string) bool {
+	for _, t := range n.Tags {
+		if t == tag || strings.HasPrefix(t, tag+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// selectNote moves the cursor to the note with id if it is shown
+func (m *model) selectNote(id int) {
+	for i, n := range m.notes {
+		if n.ID == id {
+			m.cursor = i
+		}
+	}
+	m.scrollList()
+	m.updatePreview()
+}
+
+// selectTag applies the tag under the sidebar cursor as filter
+func (m *model) selectTag() {
+	if m.tagCursor == 0 {
+		m.tag = ""
+	} else {
+		m.tag = m.tags[m.tagCursor-1].name
+	}
+	m.cursor, m.offset = 0, 0
+	m.refresh()
+}
+
+func (m *model) selected() *note.Note {
+	if m.cursor < 0 || m.cursor >= len(m.notes) {
+		return nil
+	}
+	return m.notes[m.cursor]
+}
+
+// move moves the cursor of the focused pane by delta lines
+func (m *model) move(delta int) {
+	switch m.focus {
+	case paneTags:
+		m.tagCursor = clamp(m.tagCursor+delta, 0, len(m.tags))
+		m.tagOffset = scroll(m.tagOffset, m.tagCursor, m.listHeight())
+	case paneList:
+		m.cursor = clamp(m.cursor+delta, 0, max(len(m.notes)-1, 0))
+		m.scrollList()
+		m.updatePreview()
+	case panePreview:
+		if delta < 0 {
+			m.preview.LineUp(-delta)
+		} else {
+			m.preview.LineDown(delta)
+		}
+	}
+}
+
+func (m *model) scrollList() {
+	m.offset = scroll(m.offset, m.cursor, m.listHeight())
+}
+
+// scroll returns the offset of a list showing height lines so that cursor
+// is visible
+func scroll(offset, cursor, height int) int {
+	if height <= 0 {
+		return 0
+	}
+	if cursor < offset {
+		return cursor
+	}
+	if cursor >= offset+height {
+		return cursor - height + 1
+	}
+	return offset
+}
+
+func (m *model) setStatus(status string) {
+	m.status, m.statusErr = status, false
+}
+
+func (m *model) setError(err error) {
+	m.status, m.statusErr = err.Error(), true
+}
+
+func clamp(v, low, high int) int {
+	return max(low, min(v, high))
+}
+
+// Styles
+var (
+	borderStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("240"))
+	focusedBorderStyle = borderStyle.Copy().BorderForeground(lipgloss.Color("39"))
+	titleStyle         = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("39"))
+	selectedStyle      = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("230")).Background(lipgloss.Color("62"))
+	dimStyle           = lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+	tagStyle           = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	errorStyle         = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	statusStyle        = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+)
+
+// listHeight is the number of lines inside the panes of the browse screen
+func (m model) listHeight() int {
+	// Header, footer and the pane borders
+	return max(m.height-4, 1)
+}
+
+// formHeight is the height available to the note form
+func (m model) formHeight() int {
+	return max(m.height-2, 5)
+}
+
+// paneWidths returns the outer widths of the note list and preview panes
+func (m model) paneWidths() (list, preview int) {
+	rest := max(m.width-tagsWidth, 20)
+	list = rest * 2 / 5
+	return list, rest - list
+}
+
+func (m *model) resize() {
+	_, previewWidth := m.paneWidths()
+	m.preview.Width = max(previewWidth-2, 1)
+	m.preview.Height = m.listHeight()
+	m.scrollList()
+	m.updatePreview()
+	if m.mode == modeForm {
+		m.form.resize(m.width, m.formHeight())
+	}
+}
+
+// updatePreview shows the selected note in the preview pane
+func (m *model) updatePreview() {
+	n := m.selected()
+	if n == nil {
+		m.preview.SetContent(dimStyle.Render("No note selected"))
+		return
+	}
+
+	width := max(m.preview.Width, 10)
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(lipgloss.NewStyle().Width(width).Render(n.Title)) + "\n")
+
+	var flags []string
+	if n.IsPinned {
+		flags = append(flags, "📌 pinned")
+	}
+	if n.IsFavorite {
+		flags = append(flags, "⭐ favorite")
+	}
+	if n.IsArchived {
+		flags = append(flags, "📦 archived")
+	}
+	if n.Encrypted {
+		flags = append(flags, "🔒 encrypted")
+	}
+	if len(flags) > 0 {
+		b.WriteString(strings.Join(flags, "  ") + "\n")
+	}
+	if n.Notebook != "" {
+		b.WriteString(dimStyle.Render("Notebook: "+n.Notebook) + "\n")
+	}
+	if len(n.Tags) > 0 {
+		b.WriteString(tagStyle.Render("Tags: "+strings.Join(n.Tags, ", ")) + "\n")
+	}
+	for _, name := range n.PropertyNames() {
+		b.WriteString(dimStyle.Render(name+": "+n.PropertyString(name)) + "\n")
+	}
+	if n.DueAt != nil {
+		b.WriteString(dimStyle.Render("Due: "+n.DueAt.Format("2006-01-02 15:04")) + "\n")
+	}
+	b.WriteString(dimStyle.Render(fmt.Sprintf("Created %s · Updated %s",
+		n.CreatedAt.Format("2006-01-02 15:04"), n.UpdatedAt.Format("2006-01-02 15:04"))) + "\n")
+	b.WriteString(dimStyle.Render(strings.Repeat("─", width)) + "\n")
+	b.WriteString(lipgloss.NewStyle().Width(width).Render(n.Content))
+
+	m.preview.SetContent(b.String())
+	m.preview.GotoTop()
+}
+
+func (m model) View() string {
+	if m.width == 0 {
+		return "Loading notes..."
+	}
+	if m.mode == modeForm {
+		return lipgloss.JoinVertical(lipgloss.Left, m.headerView(), m.form.view(), m.footerView())
+	}
+
+	listWidth, previewWidth := m.paneWidths()
+	body := lipgloss.JoinHorizontal(lipgloss.Top,
+		m.pane(paneTags, m.tagsView(tagsWidth-2), tagsWidth),
+		m.pane(paneList, m.listView(listWidth-2), listWidth),
+		m.pane(panePreview, m.preview.View(), previewWidth),
+	)
+	return lipgloss.JoinVertical(lipgloss.Left, m.headerView(), body, m.footerView())
+}
+
+// pane draws the border around the content of a pane
+func (m model) pane(p pane, content string, width int) string {
+	style := borderStyle
+	if m.focus == p {
+		style = focusedBorderStyle
+	}
+	return style.
+		Width(width - 2).
+		Height(m.listHeight()).
+		MaxHeight(m.listHeight() + 2).
+		Render(content)
+}
+
+func (m model) headerView() string {
+	header := titleStyle.Render("📝 gonotes") + dimStyle.Render(fmt.Sprintf("  %d notes", len(m.notes)))
+	if m.tag != "" {
+		header += tagStyle.Render("  #" + m.tag)
+	}
+	if m.showArchived {
+		header += dimStyle.Render("  +archived")
+	}
+	if locked := m.storage.LockedNotes(); len(locked) > 0 {
+		header += dimStyle.Render(fmt.Sprintf("  🔒 %d hidden", len(locked)))
+	}
+
+	if m.mode == modeSearch || m.search.Value() != "" {
+		header += "  " + m.search.View()
+	}
+	return lipgloss.NewStyle().MaxWidth(m.width).Render(header)
+}
+
+func (m model) footerView() string {
+	var footer string
+	switch {
+	case m.mode == modeConfirm:
+		footer = errorStyle.Render(fmt.Sprintf("Delete '%s'? (y/N)", m.selected().Title))
+	case m.status != "" && m.statusErr:
+		footer = errorStyle.Render(m.status)
+	case m.status != "":
+		footer = statusStyle.Render(m.status)
+	case m.mode == modeForm:
+		footer = dimStyle.Render("tab next field · ctrl+s save · esc cancel")
+	case m.mode == modeSearch:
+		footer = dimStyle.Render("enter keep results · esc clear search")
+	case m.help:
+		footer = dimStyle.Render("j/k move · tab switch pane · enter select · / search · n new · e edit · a archive · f favorite · p pin · d delete · A show archived · r reload · esc clear filters · q quit")
+	default:
+		footer = dimStyle.Render("/ search · n new · e edit · a archive · f favorite · d delete · ? more · q quit")
+	}
+	return lipgloss.NewStyle().MaxWidth(m.width).Render(footer)
+}
+
+func (m model) tagsView(width int) string {
+	lines := make([]string, 0, len(m.tags)+1)
+	lines = append(lines, "All notes")
+	for _, tag := range m.tags {
+		lines = append(lines, fmt.Sprintf("#%s %s", tag.name, dimStyle.Render(fmt.Sprint(tag.count))))
+	}
+
+	selected := 0
+	for i, tag := range m.tags {
+		if tag.name == m.tag {
+			selected = i + 1
+		}
+	}
+
+	height := m.listHeight()
+	var b strings.Builder
+	for i := m.tagOffset; i < len(lines) && i < m.tagOffset+height; i++ {
+		line := lipgloss.NewStyle().MaxWidth(width).Render(lines[i])
+		switch {
+		case m.focus == paneTags && i == m.tagCursor:
+			line = selectedStyle.Render(line)
+		case i == selected:
+			line = tagStyle.Render("› ") + line
+		}
+		b.WriteString(line + "\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func (m model) listView(width int) string {
+	if len(m.notes) == 0 {
+		return dimStyle.Render("No notes found")
+	}
+
+	height := m.listHeight()
+	var b strings.Builder
+	for i := m.offset; i < len(m.notes) && i < m.offset+height; i++ {
+		n := m.notes[i]
+		status := ""
+		if n.IsPinned {
+			status += "📌"
+		}
+		if n.IsFavorite {
+			status += "⭐"
+		}
+		if n.IsArchived {
+			status += "📦"
+		}
+		if status != "" {
+			status += " "
+		}
+
+		line := lipgloss.NewStyle().MaxWidth(width).Render(fmt.Sprintf("%s%s", status, n.Title))
+		if i == m.cursor {
+			line = selectedStyle.Width(width).Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
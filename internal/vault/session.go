@@ -0,0 +1,101 @@
+package vault
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// DefaultSessionTimeout is how long an unlocked session lasts by default
+const DefaultSessionTimeout = 30 * time.Minute
+
+// session is the key cached for a notes directory between commands
+type session struct {
+	Key     []byte    `json:"key"`
+	Expires time.Time `json:"expires"`
+}
+
+// sessionPath returns the file caching the key of notesDir. It lives in the
+// user's runtime directory, which is usually in memory and private to the
+// user, or in a private folder of the temporary directory otherwise.
+func sessionPath(notesDir string) (string, error) {
+	abs, err := filepath.Abs(notesDir)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(abs))
+	name := hex.EncodeToString(sum[:8]) + ".key"
+
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir != "" {
+		dir = filepath.Join(dir, "gonotes")
+	} else {
+		dir = filepath.Join(os.TempDir(), "gonotes-"+strconv.Itoa(os.Getuid()))
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create session directory: %w", err)
+	}
+	if info, err := os.Stat(dir); err == nil && info.Mode().Perm()&0077 != 0 {
+		return "", fmt.Errorf("session directory %s is accessible to other users", dir)
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// SaveSession caches key for notesDir until timeout has passed
+func SaveSession(notesDir string, key *Key, timeout time.Duration) error {
+	path, err := sessionPath(notesDir)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(session{Key: key.Bytes(), Expires: time.Now().Add(timeout)})
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	return nil
+}
+
+// LoadSession returns the cached key of notesDir, or nil if there is no
+// session or it has expired
+func LoadSession(notesDir string) (*Key, error) {
+	path, err := sessionPath(notesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session: %w", err)
+	}
+
+	var s session
+	if err := json.Unmarshal(data, &s); err != nil || time.Now().After(s.Expires) {
+		os.Remove(path)
+		return nil, nil
+	}
+	return NewKey(s.Key)
+}
+
+// ClearSession forgets the cached key of notesDir
+func ClearSession(notesDir string) error {
+	path, err := sessionPath(notesDir)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to clear session: %w", err)
+	}
+	return nil
+}
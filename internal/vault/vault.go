@@ -0,0 +1,185 @@
+// Package vault encrypts note files with AES-256-GCM under a key derived
+// from a passphrase with scrypt. The parameters needed to derive the key
+// again, and a value to check it against, are kept in a key file next to the
+// notes; the passphrase and the key itself are never written there.
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// FileName is the name of the key file inside the metadata directory
+const FileName = "encryption.json"
+
+// scrypt parameters for new key files; about 100ms on a laptop
+const (
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+	keySize = 32
+)
+
+// checkValue is encrypted into the key file to recognize the right key
+const checkValue = "gonotes"
+
+var (
+	// ErrWrongPassphrase is returned when a passphrase does not match the
+	// key file
+	ErrWrongPassphrase = errors.New("wrong passphrase")
+	// ErrDecrypt is returned when data cannot be decrypted with a key, because
+	// it was encrypted with another key or was modified
+	ErrDecrypt = errors.New("cannot decrypt: wrong key or damaged data")
+)
+
+// File describes how the key of a notes directory is derived
+type File struct {
+	Version int    `json:"version"`
+	KDF     string `json:"kdf"`
+	Salt    []byte `json:"salt"`
+	N       int    `json:"n"`
+	R       int    `json:"r"`
+	P       int    `json:"p"`
+	// Check is checkValue encrypted with the key
+	Check []byte `json:"check"`
+}
+
+// Key is a derived encryption key
+type Key struct {
+	aead cipher.AEAD
+	raw  []byte
+}
+
+// Create derives a key from a new passphrase and returns the key file
+// describing it
+func Create(passphrase string) (*File, *Key, error) {
+	if passphrase == "" {
+		return nil, nil, fmt.Errorf("passphrase cannot be empty")
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	f := &File{Version: 1, KDF: "scrypt", Salt: salt, N: scryptN, R: scryptR, P: scryptP}
+	key, err := f.derive(passphrase)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	f.Check, err = key.Seal([]byte(checkValue), []byte("check"))
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, key, nil
+}
+
+// Load reads a key file
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &File{}
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("invalid key file: %w", err)
+	}
+	if f.KDF != "scrypt" {
+		return nil, fmt.Errorf("unsupported key derivation %q", f.KDF)
+	}
+	return f, nil
+}
+
+// Save writes the key file
+func (f *File) Save(path string) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode key file: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+	return nil
+}
+
+// Unlock derives the key from a passphrase and checks it
+func (f *File) Unlock(passphrase string) (*Key, error) {
+	key, err := f.derive(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if !f.Matches(key) {
+		return nil, ErrWrongPassphrase
+	}
+	return key, nil
+}
+
+// Matches reports whether key is the key described by the file
+func (f *File) Matches(key *Key) bool {
+	check, err := key.Open(f.Check, []byte("check"))
+	return err == nil && string(check) == checkValue
+}
+
+func (f *File) derive(passphrase string) (*Key, error) {
+	raw, err := scrypt.Key([]byte(passphrase), f.Salt, f.N, f.R, f.P, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	return NewKey(raw)
+}
+
+// NewKey returns a key from its raw bytes, as returned by Bytes
+func NewKey(raw []byte) (*Key, error) {
+	if len(raw) != keySize {
+		return nil, fmt.Errorf("invalid key size %d", len(raw))
+	}
+
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{aead: aead, raw: append([]byte(nil), raw...)}, nil
+}
+
+// Bytes returns the raw key
+func (k *Key) Bytes() []byte {
+	return append([]byte(nil), k.raw...)
+}
+
+// Seal encrypts plaintext. The additional data is authenticated but not
+// encrypted and must be passed to Open again, which binds the ciphertext to
+// its context, such as the ID of the note.
+func (k *Key) Seal(plaintext, additional []byte) ([]byte, error) {
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return k.aead.Seal(nonce, nonce, plaintext, additional), nil
+}
+
+// Open decrypts data returned by Seal
+func (k *Key) Open(data, additional []byte) ([]byte, error) {
+	size := k.aead.NonceSize()
+	if len(data) < size {
+		return nil, ErrDecrypt
+	}
+
+	plaintext, err := k.aead.Open(nil, data[:size], data[size:], additional)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,117 @@
+package vault
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestUnlock(t *testing.T) {
+	file, key, err := Create("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The key file is read back from disk, as by a later run
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := file.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unlocked, err := loaded.Unlock("correct horse")
+	if err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+	if !bytes.Equal(unlocked.Bytes(), key.Bytes()) {
+		t.Error("Unlock() derived another key than Create")
+	}
+
+	if _, err := loaded.Unlock("wrong horse"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Unlock() with a wrong passphrase error = %v, want ErrWrongPassphrase", err)
+	}
+
+	_, other, err := Create("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Matches(other) {
+		t.Error("Matches() = true for a key with another salt")
+	}
+}
+
+func TestCreateEmptyPassphrase(t *testing.T) {
+	if _, _, err := Create(""); err == nil {
+		t.Error("Create(\"\") = nil error, want an error")
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	key, err := NewKey(bytes.Repeat([]byte{7}, keySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := []byte("meet at noon")
+	additional := []byte("note:1")
+
+	sealed, err := key.Seal(plaintext, additional)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(sealed, plaintext) {
+		t.Error("Seal() output contains the plaintext")
+	}
+	again, err := key.Seal(plaintext, additional)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(sealed, again) {
+		t.Error("Seal() returned the same output twice, nonces are reused")
+	}
+
+	opened, err := key.Open(sealed, additional)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if !bytes.Equal(opened, plaintext) {
+		t.Errorf("Open() = %q, want %q", opened, plaintext)
+	}
+
+	otherKey, err := NewKey(bytes.Repeat([]byte{8}, keySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	flipped := append([]byte(nil), sealed...)
+	flipped[len(flipped)-1] ^= 1
+
+	tests := []struct {
+		name       string
+		key        *Key
+		data       []byte
+		additional []byte
+	}{
+		{name: "other additional data", key: key, data: sealed, additional: []byte("note:2")},
+		{name: "missing additional data", key: key, data: sealed, additional: nil},
+		{name: "modified ciphertext", key: key, data: flipped, additional: additional},
+		{name: "truncated", key: key, data: sealed[:4], additional: additional},
+		{name: "other key", key: otherKey, data: sealed, additional: additional},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.key.Open(tt.data, tt.additional); !errors.Is(err, ErrDecrypt) {
+				t.Errorf("Open() error = %v, want ErrDecrypt", err)
+			}
+		})
+	}
+}
+
+func TestNewKeySize(t *testing.T) {
+	if _, err := NewKey(make([]byte, 16)); err == nil {
+		t.Error("NewKey() with 16 bytes = nil error, want an error")
+	}
+}